@@ -1,17 +1,30 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"percipio.com/gopi/lib/app"
 )
 
+// Exit codes, so CI can tell a regression apart from a broken run
+const (
+	exitError       = 1 // Invalid configuration or a failed run
+	exitDegradation = 2 // The run degraded against its baseline, with --fail-on-degradation
+)
+
 func main() {
 	application, err := app.New()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	if err := application.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		if errors.Is(err, app.ErrDegradation) {
+			os.Exit(exitDegradation)
+		}
+		os.Exit(exitError)
 	}
-	application.Run()
 }
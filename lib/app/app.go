@@ -1,113 +1,768 @@
 package app
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"percipio.com/gopi/lib/config"
 	"percipio.com/gopi/lib/history"
 	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/report"
 	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/score"
 	"percipio.com/gopi/lib/stats"
+	"percipio.com/gopi/lib/util"
+	"percipio.com/gopi/lib/validate"
 	"percipio.com/gopi/lib/viz"
 )
 
+// leakCheckGrace is how long goroutines get to wind down after a run before
+// the remainder are reported as leaked.
+const leakCheckGrace = 2 * time.Second
+
+// webhookTimeout bounds how long a degradation notification may hold up the
+// end of a run.
+const webhookTimeout = 10 * time.Second
+
+// ErrDegradation is returned by Run when --fail-on-degradation is set and the
+// run degraded against its baseline, so CI can tell a regression apart from
+// a run that failed.
+var ErrDegradation = errors.New("performance degradation detected")
+
 type App struct {
 	runner       *runner.Runner
 	config       *config.Config
 	historyStore *history.Store
+	replay       []runner.RequestRecord
+	merged       []runner.Result          // Results loaded from --merge files
+	budgets      map[string]time.Duration // P95 latency budgets by "METHOD URL"
+	out          io.Writer                // Destination for the human-readable summary
+
+	// compareRunner runs the endpoints against the second --compare-branch base URL
+	compareRunner *runner.Runner
 }
 
 type EndpointConfig struct {
-	URL     string            `json:"url"`
-	Method  string            `json:"method"`
-	Headers map[string]string `json:"headers,omitempty"`
-	Body    string            `json:"body,omitempty"`
+	// Name identifies the endpoint in the results when grouping by name
+	Name     string            `json:"name,omitempty"`
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Validate []validate.Rule   `json:"validate,omitempty"`
+	// ExpectStatus is the status code, such as 200, or class, such as "2xx",
+	// the responses must have to count as successful. Unset, any response
+	// counts.
+	ExpectStatus StatusExpectation `json:"expectStatus,omitempty"`
+	// Assert maps JSON paths, such as "$.status", to the value the response
+	// body must have there to count as successful
+	Assert map[string]json.RawMessage `json:"assert,omitempty"`
+	// Threshold overrides --threshold for this endpoint's degradation check
+	Threshold *float64 `json:"threshold,omitempty"`
+	// LatencyBudgetMS is the P95 latency the endpoint must stay within for
+	// the run to pass, 0 for no budget
+	LatencyBudgetMS int `json:"latencyBudgetMs,omitempty"`
+	// Weight is the endpoint's share of the requests relative to the other
+	// endpoints, 1 if unset
+	Weight int `json:"weight,omitempty"`
+	// Hosts are base URLs the endpoint's requests are spread across, such as
+	// the instances of a cluster. They override --hosts.
+	Hosts []string `json:"hosts,omitempty"`
+	// HostSelection overrides --host-selection for this endpoint
+	HostSelection string `json:"hostSelection,omitempty"`
+	// Poll makes the endpoint asynchronous, polling a status URL from each
+	// response until the work is done
+	Poll *PollConfig `json:"poll,omitempty"`
+	// Extract captures values from the responses of a scenario step for the
+	// later steps, so it is only accepted in scenarios
+	Extract []ExtractConfig `json:"extract,omitempty"`
+
+	targets []runner.Target  // URL of the endpoint on each host
+	poll    *runner.Poll     // Poll as checked by preparePoll
+	extract []runner.Extract // Extract as checked by prepareExtract
+}
+
+// key returns the key the endpoint's statistics are stored under when
+// requests are grouped by groupBy, one of the stats Key strategies.
+func (e EndpointConfig) key(groupBy string) string {
+	return stats.EndpointKey(groupBy, e.Method, e.URL, e.Name)
 }
 
 type TestConfig []EndpointConfig
 
 func New() (*App, error) {
-	logger.Info("Initializing application...")
 	cfg, err := config.ParseFlags()
 	if err != nil {
 		return nil, err
 	}
 
-	testConfig, err := loadTestConfig(cfg.FilePath)
+	if cfg.StdoutReserved() {
+		// Keep stdout clean for the report itself
+		logger.SetOutput(os.Stderr)
+	}
+	logger.Info("Initializing application...")
+
+	if cfg.ValidateTemplate {
+		return &App{config: cfg, out: summaryOutput(cfg)}, nil
+	}
+
+	if cfg.VerifyHistory || cfg.ExportCSV != "" {
+		historyStore, err := history.NewStore("", cfg.ThresholdPct, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open history: %w", err)
+		}
+		return &App{config: cfg, historyStore: historyStore, out: summaryOutput(cfg)}, nil
+	}
+
+	if cfg.MergeFiles != "" {
+		return newMergeApp(cfg)
+	}
+
+	if cfg.ReplayFile != "" || cfg.HARFile != "" {
+		var records []runner.RequestRecord
+		if cfg.HARFile != "" {
+			records, err = runner.LoadHAR(cfg.HARFile, cfg.HARFilter)
+		} else {
+			records, err = runner.LoadRecording(cfg.ReplayFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		urls := make([]string, 0, len(records))
+		for _, record := range records {
+			urls = append(urls, record.URL)
+		}
+		requestLimit, err := enforceSafety(cfg, urls)
+		if err != nil {
+			return nil, err
+		}
+
+		benchRunner := runner.NewRunner(cfg.ThreadCount, cfg.RequestCount)
+		configureRunner(benchRunner, cfg, requestLimit)
+		return &App{
+			runner: benchRunner,
+			config: cfg,
+			replay: records,
+			out:    summaryOutput(cfg),
+		}, nil
+	}
+
+	testConfig, scenarios, err := loadTestConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load test config: %w", err)
 	}
+	if len(testConfig) == 0 && !cfg.TestLoadUser {
+		return nil, fmt.Errorf("scenarios only run in --test-load-user; add endpoints to use other modes")
+	}
+
+	data, err := loadDataSet(cfg, testConfig, scenarios)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CompareBranch != "" {
+		return newCompareApp(cfg, testConfig, data)
+	}
+
+	urls := make([]string, 0, len(testConfig))
+	for _, endpoint := range testConfig {
+		urls = append(urls, targetURLs(endpoint)...)
+	}
+	for _, scenario := range scenarios {
+		for _, step := range scenario.Steps {
+			urls = append(urls, targetURLs(step.EndpointConfig)...)
+		}
+	}
+	requestLimit, err := enforceSafety(cfg, urls)
+	if err != nil {
+		return nil, err
+	}
+
+	historyStore, err := newHistoryStore(cfg, testConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	benchRunner := runner.NewRunner(cfg.ThreadCount, cfg.RequestCount)
+	configureRunner(benchRunner, cfg, requestLimit)
+	benchRunner.SetDataSet(data)
+	addTasks(benchRunner, testConfig)
+	addScenarios(benchRunner, scenarios)
+
+	logger.Info("Loaded %d endpoints and %d scenarios from config file", len(testConfig), len(scenarios))
+
+	return &App{
+		runner:       benchRunner,
+		config:       cfg,
+		historyStore: historyStore,
+		budgets:      latencyBudgets(testConfig, cfg.GroupBy),
+		out:          summaryOutput(cfg),
+	}, nil
+}
 
+// latencyBudgets collects the endpoints' latency budgets, keyed like
+// stats.Statistics.EndpointStats when grouped by groupBy.
+func latencyBudgets(testConfig TestConfig, groupBy string) map[string]time.Duration {
+	budgets := make(map[string]time.Duration)
 	for _, endpoint := range testConfig {
-		task := runner.Task{
-			URL:     endpoint.URL,
-			Method:  endpoint.Method,
-			Headers: endpoint.Headers,
+		if endpoint.LatencyBudgetMS > 0 {
+			budgets[endpoint.key(groupBy)] = time.Duration(endpoint.LatencyBudgetMS) * time.Millisecond
 		}
-		if endpoint.Body != "" {
-			task.Body = []byte(endpoint.Body)
+	}
+	return budgets
+}
+
+// newHistoryStore opens the history store with the --threshold degradation
+// threshold, overridden by any per-endpoint thresholds in testConfig. It fails
+// only if the --baseline run can't be loaded, so that isn't found out after
+// the test has run.
+func newHistoryStore(cfg *config.Config, testConfig TestConfig) (*history.Store, error) {
+	historyStore, err := history.NewStore("", cfg.ThresholdPct, !cfg.NoGit)
+	if err != nil {
+		logger.Warn("Failed to initialize history store: %v. Continuing without history tracking.", err)
+		return nil, nil
+	}
+	historyStore.SetLatencyMetric(cfg.LatencyMetric)
+	historyStore.SetBaselineWindow(cfg.BaselineWindow)
+	if cfg.Baseline != "" {
+		if _, err := historyStore.LoadByRunID(cfg.Baseline); err != nil {
+			return nil, fmt.Errorf("invalid --baseline: %w", err)
 		}
-		benchRunner.AddTask(task)
+		historyStore.SetBaseline(cfg.Baseline)
 	}
+	for _, endpoint := range testConfig {
+		if endpoint.Threshold != nil {
+			historyStore.SetEndpointThreshold(endpoint.key(cfg.GroupBy), *endpoint.Threshold)
+		}
+	}
+	return historyStore, nil
+}
 
-	logger.Info("Loaded %d endpoints from config file", len(testConfig))
+// newMergeApp loads the raw results of runs made on separate machines so they
+// can be reported as a single run. Each file's target rate is summed, since
+// together they made up the offered load.
+func newMergeApp(cfg *config.Config) (*App, error) {
+	var merged []runner.Result
+	var targetRPS float64
+	for _, path := range cfg.MergePaths() {
+		results, err := runner.LoadRawResults(path)
+		if err != nil {
+			return nil, err
+		}
 
-	historyStore, err := history.NewStore("", 10.0, !cfg.NoGit)
+		var fileTarget float64
+		for _, result := range results {
+			fileTarget = max(fileTarget, result.TargetRPS)
+		}
+		targetRPS += fileTarget
+
+		logger.Info("Loaded %d results from %s", len(results), path)
+		merged = append(merged, results...)
+	}
+
+	for i := range merged {
+		merged[i].TargetRPS = targetRPS
+	}
+
+	historyStore, err := newHistoryStore(cfg, nil)
 	if err != nil {
-		logger.Warn("Failed to initialize history store: %v. Continuing without history tracking.", err)
-		historyStore = nil
+		return nil, err
 	}
 
 	return &App{
-		runner:       benchRunner,
 		config:       cfg,
 		historyStore: historyStore,
+		merged:       merged,
+		out:          summaryOutput(cfg),
 	}, nil
 }
 
-func loadTestConfig(filepath string) (TestConfig, error) {
-	data, err := os.ReadFile(filepath)
+// configureRunner applies the command line flags that shape how a runner
+// sends requests, along with the request limit from the safety checks.
+func configureRunner(benchRunner *runner.Runner, cfg *config.Config, requestLimit int) {
+	benchRunner.SetRequestLimit(requestLimit)
+	benchRunner.SetRate(cfg.Rate)
+	benchRunner.SetAbortOnErrorRate(cfg.AbortErrorRate)
+	benchRunner.SetWarmup(cfg.Warmup)
+	benchRunner.SetErrorLogLimit(cfg.ErrorLogLimit)
+	benchRunner.SetConnectionLimit(cfg.ConnectionCount)
+	benchRunner.SetIdleConnections(cfg.MaxIdleConns)
+	benchRunner.SetConsistencyCheck(cfg.CheckConsistency)
+	benchRunner.SetTimeout(time.Duration(cfg.Timeout) * time.Second)
+	benchRunner.SetAuthorization(cfg.Authorization())
+	benchRunner.SetFollowRedirects(!cfg.NoRedirects)
+	benchRunner.SetHTTP2(cfg.HTTP2)
+	benchRunner.SetAcceptEncoding(strings.Join(cfg.AcceptEncodingList(), ", "))
+	// ParseFlags has already loaded --ca-cert once, so this can't fail.
+	tlsConfig, _ := cfg.TLSConfig()
+	benchRunner.SetTLSConfig(tlsConfig)
+	if cfg.InsecureSkipVerify {
+		logger.Warn("TLS certificates aren't verified (--insecure-skip-verify); only use this against test environments")
+	}
+	benchRunner.SetConnectTimeouts(cfg.ConnectTimeout, cfg.TLSHandshakeTimeout)
+	// ParseFlags has already rejected malformed --resolve entries.
+	resolve, _ := cfg.ResolveOverrides()
+	benchRunner.SetResolve(resolve)
+	if cfg.ThrottleKBps > 0 || cfg.AddedLatency > 0 {
+		logger.Warn("Simulating a slow client network; latencies include the throttling and aren't purely server-side")
+		benchRunner.SetThrottle(runner.Throttle{KBps: cfg.ThrottleKBps, Latency: cfg.AddedLatency})
+	}
+	benchRunner.SetRetry(runner.RetryConfig{
+		MaxRetries: cfg.Retries,
+		Backoff:    cfg.RetryBackoff,
+		MaxBackoff: cfg.RetryMaxBackoff,
+		Jitter:     cfg.RetryJitter,
+		BudgetPct:  cfg.RetryBudget,
+
+		FinalLatency: cfg.RetryLatency == "final",
+	})
+	benchRunner.SetFaults(runner.FaultConfig{
+		Rate:  cfg.FaultRate,
+		Kinds: cfg.FaultKindList(),
+	})
+}
+
+// summaryOutput returns where the human-readable summary is printed. It moves
+// to stderr when the report itself is written to stdout, and is dropped when
+// the results are written as JSON.
+func summaryOutput(cfg *config.Config) io.Writer {
+	if cfg.Output == config.OutputJSON {
+		return io.Discard
+	}
+	if cfg.StdoutReserved() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// newCompareApp sets up one runner per --compare-branch base URL, each running
+// the same endpoints with their scheme and host replaced by the base URL.
+func newCompareApp(cfg *config.Config, testConfig TestConfig, data *runner.DataSet) (*App, error) {
+	var deployments []TestConfig
+	var urls []string
+	for _, base := range cfg.CompareBases() {
+		rebased := make(TestConfig, len(testConfig))
+		for i, endpoint := range testConfig {
+			endpointURL, err := rebaseURL(endpoint.URL, base)
+			if err != nil {
+				return nil, err
+			}
+			endpoint.URL = endpointURL
+			// The compared deployment decides the host, not the endpoint
+			endpoint.targets = nil
+			rebased[i] = endpoint
+			urls = append(urls, endpointURL)
+		}
+		deployments = append(deployments, rebased)
+	}
+
+	// The runners are sized by the thread count, so it must be capped first
+	requestLimit, err := enforceSafety(cfg, urls)
+	if err != nil {
+		return nil, err
+	}
+	var runners []*runner.Runner
+	for _, rebased := range deployments {
+		benchRunner := runner.NewRunner(cfg.ThreadCount, cfg.RequestCount)
+		addTasks(benchRunner, rebased)
+		configureRunner(benchRunner, cfg, requestLimit)
+		benchRunner.SetDataSet(data)
+		runners = append(runners, benchRunner)
+	}
+
+	logger.Info("Loaded %d endpoints to compare across 2 deployments", len(testConfig))
+
+	return &App{
+		runner:        runners[0],
+		compareRunner: runners[1],
+		config:        cfg,
+		out:           summaryOutput(cfg),
+	}, nil
+}
+
+// rebaseURL points endpointURL at base, keeping its path and query.
+func rebaseURL(endpointURL, base string) (string, error) {
+	endpoint, err := url.Parse(endpointURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint URL %s: %w", endpointURL, err)
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return "", fmt.Errorf("invalid base URL %s", base)
+	}
+
+	endpoint.Scheme = baseURL.Scheme
+	endpoint.Host = baseURL.Host
+	endpoint.Path = strings.TrimSuffix(baseURL.Path, "/") + endpoint.Path
+	return endpoint.String(), nil
+}
+
+// resolveEndpointURL joins a relative endpoint path such as /users onto base.
+// Absolute endpoint URLs are returned unchanged.
+func resolveEndpointURL(endpointURL, base string) (string, error) {
+	endpoint, err := url.Parse(endpointURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint URL %s: %w", endpointURL, err)
+	}
+	if endpoint.IsAbs() {
+		return endpointURL, nil
+	}
+	if base == "" {
+		return "", fmt.Errorf("endpoint %s is a relative path but no --base-url was given", endpointURL)
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return "", fmt.Errorf("invalid base URL %s", base)
+	}
+
+	joined := baseURL.JoinPath(endpoint.Path)
+	joined.RawQuery = endpoint.RawQuery
+	joined.Fragment = ""
+	if _, err := url.ParseRequestURI(joined.String()); err != nil {
+		return "", fmt.Errorf("invalid endpoint URL %s joined onto %s: %w", endpointURL, base, err)
+	}
+	return joined.String(), nil
+}
+
+// StatusExpectation is an endpoint's expectStatus, which can be written as a
+// number or a string.
+type StatusExpectation string
+
+func (s *StatusExpectation) UnmarshalJSON(data []byte) error {
+	var code int
+	if err := json.Unmarshal(data, &code); err == nil {
+		*s = StatusExpectation(strconv.Itoa(code))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expectStatus must be a status code or class such as \"2xx\"")
+	}
+	*s = StatusExpectation(text)
+	return nil
+}
+
+// rules returns the endpoint's validation rules, including those given by
+// its expectStatus and assert shorthands.
+func (e EndpointConfig) rules() []validate.Rule {
+	if e.ExpectStatus == "" && len(e.Assert) == 0 {
+		return e.Validate
+	}
+
+	var rules []validate.Rule
+	if e.ExpectStatus != "" {
+		rules = append(rules, validate.Rule{Type: validate.RuleExpectStatus, Value: string(e.ExpectStatus)})
+	}
+	paths := make([]string, 0, len(e.Assert))
+	for path := range e.Assert {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		// Already parsed as part of the config, so this can't fail
+		expected, _ := validate.DecodeJSON(e.Assert[path])
+		rules = append(rules, validate.Rule{
+			Type:  validate.RuleJSONPathEquals,
+			Path:  path,
+			Value: validate.FormatValue(expected),
+		})
+	}
+	return append(rules, e.Validate...)
+}
+
+func addTasks(benchRunner *runner.Runner, testConfig TestConfig) {
+	for _, endpoint := range testConfig {
+		benchRunner.AddTask(newTask(endpoint))
+	}
+}
+
+func newTask(endpoint EndpointConfig) runner.Task {
+	task := runner.Task{
+		Name:    endpoint.Name,
+		URL:     endpoint.URL,
+		Method:  endpoint.Method,
+		Headers: endpoint.Headers,
+		Rules:   endpoint.rules(),
+		Hosts:   hostPool(endpoint),
+		Poll:    endpoint.poll,
+		Extract: endpoint.extract,
+		Weight:  endpoint.Weight,
+	}
+	if endpoint.Body != "" {
+		task.Body = []byte(endpoint.Body)
+	}
+	return task
+}
+
+// enforceSafety refuses to target protected hosts without explicit
+// confirmation, and caps concurrency when they are targeted. It returns the
+// total request limit to apply, or 0 for no limit.
+func enforceSafety(cfg *config.Config, urls []string) (int, error) {
+	safety, err := config.LoadSafetyConfig(cfg.SafetyConfigFile)
+	if err != nil {
+		return 0, err
+	}
+	for _, pattern := range strings.Split(cfg.ProtectedHosts, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			safety.ProtectedHosts = append(safety.ProtectedHosts, pattern)
+		}
+	}
+
+	var protected []string
+	for _, rawURL := range urls {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		if safety.IsProtected(parsed.Hostname()) {
+			protected = append(protected, parsed.Hostname())
+		}
+	}
+
+	if len(protected) == 0 {
+		return 0, nil
+	}
+
+	if !cfg.IKnowWhatImDoing {
+		return 0, fmt.Errorf("endpoints target protected host %s; rerun with --i-know-what-im-doing to confirm", protected[0])
+	}
+
+	logger.Warn("Running against protected host %s with safety limits applied", protected[0])
+	if safety.MaxConcurrency > 0 {
+		cfg.ThreadCount = min(cfg.ThreadCount, safety.MaxConcurrency)
+		cfg.StartUsers = min(cfg.StartUsers, safety.MaxConcurrency)
+		cfg.MaxUsers = min(cfg.MaxUsers, safety.MaxConcurrency)
+		logger.Warn("- Concurrency capped at %d", safety.MaxConcurrency)
+	}
+	if safety.MaxRequests > 0 {
+		logger.Warn("- Total requests capped at %d", safety.MaxRequests)
+	}
+
+	return safety.MaxRequests, nil
+}
+
+// loadTestConfig reads the config file, which is either a list of endpoints
+// or a TestFile object that adds scenarios.
+func loadTestConfig(cfg *config.Config) (TestConfig, []ScenarioConfig, error) {
+	file, err := readTestFile(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := file.Endpoints
+
+	if len(config) == 0 && len(file.Scenarios) == 0 {
+		return nil, nil, fmt.Errorf("no endpoints defined in config file")
+	}
+	if cfg.Scenario {
+		if len(file.Scenarios) > 0 {
+			return nil, nil, fmt.Errorf("--scenario runs the endpoints as a scenario, but the config file already defines scenarios")
+		}
+		file.Scenarios = []ScenarioConfig{endpointScenario(config)}
+		config = nil
+	}
+
+	for i, endpoint := range config {
+		baseURL, err := resolveHosts(&config[i], cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		endpointURL, err := resolveEndpointURL(endpoint.URL, baseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		config[i].URL = endpointURL
+
+		if err := validate.Check(endpoint.rules()); err != nil {
+			return nil, nil, fmt.Errorf("invalid validation rules for %s: %w", endpointURL, err)
+		}
+		if err := preparePoll(&config[i]); err != nil {
+			return nil, nil, err
+		}
+		if len(endpoint.Extract) > 0 {
+			return nil, nil, fmt.Errorf("extract for %s only applies to scenario steps, or with --scenario, which run in order", endpointURL)
+		}
+
+		if endpoint.Threshold != nil && *endpoint.Threshold < 0 {
+			return nil, nil, fmt.Errorf("threshold for %s cannot be negative", endpointURL)
+		}
+
+		if endpoint.LatencyBudgetMS < 0 {
+			return nil, nil, fmt.Errorf("latencyBudgetMs for %s cannot be negative", endpointURL)
+		}
+
+		if endpoint.Weight < 0 {
+			return nil, nil, fmt.Errorf("weight for %s cannot be negative", endpointURL)
+		}
+	}
+
+	if err := prepareScenarios(file.Scenarios, cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return config, file.Scenarios, nil
+}
+
+// readTestFile reads the endpoints and scenarios from --file, or the
+// endpoints from the --postman collection.
+func readTestFile(cfg *config.Config) (TestFile, error) {
+	if cfg.Postman != "" {
+		endpoints, err := loadPostman(cfg.Postman, cfg.PostmanEnv)
+		return TestFile{Endpoints: endpoints}, err
+	}
+
+	data, err := os.ReadFile(cfg.FilePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return TestFile{}, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config TestConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	var file TestFile
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = json.Unmarshal(data, &file.Endpoints)
+	}
+	if err != nil {
+		return TestFile{}, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return file, nil
+}
+
+// Run executes the selected test mode. It returns an error when the run as a
+// whole is considered failed.
+func (a *App) Run() error {
+	baseline := runtime.NumGoroutine()
+	stopMetrics := func() {}
+	if a.config.MetricsAddr != "" && a.runner != nil {
+		stop, err := a.serveMetrics(a.config.MetricsAddr)
+		if err != nil {
+			return err
+		}
+		stopMetrics = stop
+	}
+	closeStatsD := func() {}
+	if a.config.StatsDAddr != "" && a.runner != nil {
+		statsd, err := report.NewStatsD(a.config.StatsDAddr)
+		if err != nil {
+			stopMetrics()
+			return err
+		}
+		a.runner.SetOnResult(statsd.Observe)
+		if a.compareRunner != nil {
+			a.compareRunner.SetOnResult(statsd.Observe)
+		}
+		logger.Info("Sending request metrics to StatsD at %s", a.config.StatsDAddr)
+		closeStatsD = func() { statsd.Close() }
 	}
 
-	if len(config) == 0 {
-		return nil, fmt.Errorf("no endpoints defined in config file")
+	err := a.runMode(context.Background())
+	stopMetrics()
+	closeStatsD()
+
+	if a.runner != nil {
+		a.runner.CloseIdleConnections()
+	}
+	if a.compareRunner != nil {
+		a.compareRunner.CloseIdleConnections()
 	}
+	runner.CheckGoroutineLeaks(baseline, leakCheckGrace)
 
-	return config, nil
+	return err
 }
 
-func (a *App) Run() {
+func (a *App) runMode(ctx context.Context) error {
 	switch {
+	case a.config.ValidateTemplate:
+		logger.Info("Validating report template...")
+		return a.validateTemplate()
+	case a.config.VerifyHistory:
+		logger.Info("Verifying history...")
+		return a.verifyHistory()
+	case a.config.ExportCSV != "":
+		logger.Info("Exporting history...")
+		return a.exportCSV()
 	case a.config.TestPerf:
 		logger.Info("Running performance test...")
-		a.runStandardTest()
+		return a.runStandardTest(ctx)
 	case a.config.TestLoadUser:
 		logger.Info("Running user load test...")
-		a.runUserLoadTest()
+		return a.runUserLoadTest(ctx)
 	case a.config.TestLoadData:
 		logger.Info("Running data load test...")
-		a.runDataLoadTest()
+		return a.runDataLoadTest(ctx)
+	case a.config.ReplayFile != "" || a.config.HARFile != "":
+		logger.Info("Running replay...")
+		a.runReplay(ctx)
+	case a.config.CompareBranch != "":
+		logger.Info("Running deployment comparison...")
+		a.runCompareTest(ctx)
+	case a.config.MergeFiles != "":
+		logger.Info("Merging results of %d runs...", len(a.config.MergePaths()))
+		return a.reportResults(a.merged)
 	}
+	return nil
 }
 
 // Move existing Run() logic to this method
-func (a *App) runStandardTest() {
+func (a *App) runStandardTest(ctx context.Context) error {
 	logger.Info("Starting performance test...")
-	results := a.runner.Run()
-	statistics := stats.Calculate(results)
+	if a.config.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.MaxDuration)
+		defer cancel()
+	}
+	results := a.runner.Run(ctx)
+	limited := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if limited {
+		logger.Warn("Performance test stopped at --max-duration %v", a.config.MaxDuration)
+	}
+	a.saveRecording(results)
+	a.saveRawResults(results)
+	logger.Info("Performance test completed")
+
+	err := a.reportResults(results)
+	if limited {
+		fmt.Fprintf(a.out, "\nRun limited by --max-duration %v: %d of %d planned requests completed\n",
+			a.config.MaxDuration, len(results), a.runner.PlannedRequests())
+	}
+	if aborted := a.runner.Aborted(); aborted != nil {
+		fmt.Fprintf(a.out, "\nRun ABORTED after %d of %d planned requests: %v (--abort-on-error-rate %.2f%%)\n",
+			len(results), a.runner.PlannedRequests(), aborted, a.config.AbortErrorRate)
+		if err == nil {
+			err = fmt.Errorf("run aborted: %w", aborted)
+		}
+	}
+	return err
+}
+
+// reportResults computes statistics for results, records them in the history
+// and prints and writes the reports. It returns an error when the run as a
+// whole is considered failed.
+func (a *App) reportResults(results []runner.Result) error {
+	statistics := stats.CalculateBy(results, a.config.GroupBy)
+	statistics.AddLatencyWindows(results, a.config.LatencyWindow)
+	// ParseFlags has already rejected malformed --percentiles.
+	percentiles, _ := a.config.PercentileList()
+	statistics.AddPercentiles(results, percentiles)
+	// ParseFlags has already rejected malformed --histogram-buckets.
+	bounds, _ := a.config.HistogramBounds()
+	statistics.SetHistogramBounds(results, bounds)
+	healthScore := score.Calculate(statistics, a.scoreConfig())
+	statistics.HealthScore = healthScore.Total
 
 	var testHistory *history.TestHistory
 	if a.historyStore != nil {
@@ -119,101 +774,208 @@ func (a *App) runStandardTest() {
 	}
 
 	// Print current test results
-	logger.Info("Performance test completed")
-	for endpoint, stats := range statistics.EndpointStats {
-		fmt.Printf("\nEndpoint: %s\n", endpoint)
-		fmt.Printf("  Average Latency: %.2fms\n", float64(stats.AverageDuration.Milliseconds()))
-		fmt.Printf("  P50 Latency: %.2fms\n", float64(stats.P50Latency.Milliseconds()))
-		fmt.Printf("  P95 Latency: %.2fms\n", float64(stats.P95Latency.Milliseconds()))
-		fmt.Printf("  P99 Latency: %.2fms\n", float64(stats.P99Latency.Milliseconds()))
-		fmt.Printf("  Requests/sec: %.2f\n", stats.RequestsPerSecond)
-		fmt.Printf("  Success Rate: %.2f%%\n", successRate(stats))
+	a.writeJSONOutput(statistics)
+	if statistics.WarmupRequests > 0 {
+		fmt.Fprintf(a.out, "\nDiscarded %d warmup requests from the statistics\n", statistics.WarmupRequests)
+	}
+	printEndpointStats(a.out, statistics)
+	printConnectionRates(a.out, statistics)
+
+	fmt.Fprintf(a.out, "\nHealth Score: %.1f/100\n", healthScore.Total)
+	fmt.Fprintf(a.out, "  Success Rate: %.1f\n", healthScore.SuccessRate)
+	fmt.Fprintf(a.out, "  P95 Latency vs SLA: %.1f\n", healthScore.Latency)
+	fmt.Fprintf(a.out, "  Throughput vs Target: %.1f\n", healthScore.Throughput)
+
+	printThroughputShortfalls(a.out, statistics, a.config.RPSTolerance)
+	if a.config.CheckConsistency {
+		printInconsistentResponses(a.out, statistics)
+	}
+	a.writeOpenMetrics(statistics)
+	a.writeMarkdown(statistics, testHistory)
+	a.writeJUnit(statistics, testHistory)
+
+	errorRate := statistics.ErrorRate()
+	fmt.Fprintf(a.out, "\nOverall Transferred: %s in, %s out\n",
+		util.FormatBytes(float64(statistics.TotalBytesIn)), util.FormatBytes(float64(statistics.TotalBytesOut)))
+	fmt.Fprintf(a.out, "Overall Error Rate: %.2f%%\n", errorRate)
+	a.printRetryBudget()
+	runErr := a.checkMaxErrorRate(errorRate)
+	if err := a.checkGeneratorSaturation(); err != nil && runErr == nil {
+		runErr = err
+	}
+	if err := a.checkLatencyBudgets(statistics); err != nil && runErr == nil {
+		runErr = err
 	}
 
 	// Only show historical comparisons if we have a history store and test history
 	if a.historyStore != nil && testHistory != nil {
 		if testHistory.Degradation {
 			logger.Warn("Performance degradation detected!")
-			fmt.Printf("\nPerformance Comparison (Baseline: %s)\n", testHistory.BaselineID)
+			fmt.Fprintf(a.out, "\nPerformance Comparison (Baseline: %s)\n", testHistory.BaselineID)
 			for endpoint, comparison := range testHistory.Endpoints {
 				if comparison.Degradation {
-					fmt.Printf("\nEndpoint: %s\n", endpoint)
-					fmt.Printf("  Latency Increase: %.2f%%\n", comparison.Changes.LatencyIncrease)
-					fmt.Printf("  Error Rate Increase: %.2f%%\n", comparison.Changes.ErrorRateIncrease)
-					fmt.Printf("  Throughput Decrease: %.2f%%\n", comparison.Changes.ThroughputDecrease)
-					fmt.Printf("  Success Rate Decrease: %.2f%%\n", comparison.Changes.SuccessRateDecrease)
+					fmt.Fprintf(a.out, "\nEndpoint: %s\n", endpoint)
+					fmt.Fprintf(a.out, "  Latency (%s) Increase: %.2f%%\n", testHistory.LatencyMetric, comparison.Changes.LatencyIncrease)
+					fmt.Fprintf(a.out, "  Error Rate Increase: %.2f%%\n", comparison.Changes.ErrorRateIncrease)
+					fmt.Fprintf(a.out, "  Throughput Decrease: %.2f%%\n", comparison.Changes.ThroughputDecrease)
+					fmt.Fprintf(a.out, "  Success Rate Decrease: %.2f%%\n", comparison.Changes.SuccessRateDecrease)
 				}
 			}
+			a.notifyDegradation(testHistory)
+		}
+
+		if err := a.degradationError(testHistory.Degradation, testHistory.BaselineID); err != nil && runErr == nil {
+			runErr = err
 		}
 
+		printDistributionChanges(a.out, testHistory)
+
 		// Try to generate graphs
 		summary, err := a.historyStore.GetSummary()
 		if err != nil {
 			logger.Error("Failed to load performance summary: %v", err)
 		} else {
-			reportPath, err := viz.GenerateGraph(summary, "performance-reports")
-			if err != nil {
-				logger.Error("Failed to generate performance graphs: %v", err)
-			} else {
-				absPath, _ := filepath.Abs(reportPath)
-				logger.Info("Performance graphs generated in performance-reports directory")
-				fmt.Printf("\nView results at: file://%s\n", absPath)
-			}
+			a.writeReport(summary, testHistory, a.reportMetadata(results))
 		}
 	}
+
+	return runErr
+}
+
+// checkMaxErrorRate fails the run when errorRate, a percentage, exceeds
+// --max-error-rate.
+func (a *App) checkMaxErrorRate(errorRate float64) error {
+	if a.config.MaxErrorRate < 0 || errorRate <= a.config.MaxErrorRate {
+		return nil
+	}
+	logger.Error("Overall error rate %.2f%% exceeds the maximum of %.2f%%", errorRate, a.config.MaxErrorRate)
+	fmt.Fprintf(a.out, "Run FAILED: error rate exceeds --max-error-rate %.2f%%\n", a.config.MaxErrorRate)
+	return fmt.Errorf("overall error rate %.2f%% exceeds maximum of %.2f%%", errorRate, a.config.MaxErrorRate)
 }
 
-func (a *App) runUserLoadTest() {
+// runUserLoadTest runs the user load test and prints its summary. It fails if
+// the test was aborted because the target stopped responding.
+func (a *App) runUserLoadTest(ctx context.Context) error {
 	logger.Info("Starting user load test...")
 
+	resume, finish, err := a.loadTestResume(history.TestTypeLoadUser)
+	if err != nil {
+		return err
+	}
+
+	var rampDuration time.Duration
+	if a.config.Ramp == config.RampLinear {
+		rampDuration = a.config.RampDuration
+	}
+
 	config := runner.UserLoadConfig{
 		StartUsers:      a.config.StartUsers,
 		MaxUsers:        a.config.MaxUsers,
 		StepUsers:       a.config.StepUsers,
 		DurationPerStep: time.Duration(a.config.StepDuration) * time.Second,
+		ThinkTime: runner.ThinkTime{
+			Distribution: a.config.ThinkDist,
+			Min:          a.config.ThinkMin,
+			Max:          a.config.ThinkMax,
+			Mean:         a.config.ThinkMean,
+			StdDev:       a.config.ThinkStdDev,
+		},
+		IdleTimeout:  a.config.IdleTimeout,
+		RampDuration: rampDuration,
+		Resume:       resume,
 	}
 
 	logger.Info("Load test configuration:")
 	logger.Info("- Starting with %d users", config.StartUsers)
 	logger.Info("- Maximum users: %d", config.MaxUsers)
-	logger.Info("- Step size: %d users", config.StepUsers)
-	logger.Info("- Step duration: %v", config.DurationPerStep)
-	logger.Info("- Total steps: %d", (config.MaxUsers-config.StartUsers)/config.StepUsers+1)
+	if config.RampDuration > 0 {
+		logger.Info("- Linear ramp over %v", config.RampDuration)
+	} else {
+		logger.Info("- Step size: %d users", config.StepUsers)
+		logger.Info("- Step duration: %v", config.DurationPerStep)
+		logger.Info("- Total steps: %d", (config.MaxUsers-config.StartUsers)/config.StepUsers+1)
+	}
+	logger.Info("- Think time: %s", config.ThinkTime.Distribution)
+	if config.IdleTimeout > 0 {
+		logger.Info("- Idle timeout: %v", config.IdleTimeout)
+	}
 
-	results := a.runner.RunUserLoadTest(config)
+	results := a.runner.RunUserLoadTest(ctx, config)
+	if len(results) == 0 || results[len(results)-1].AbortedAfter == 0 {
+		// An aborted test keeps its checkpoint to retry the aborted step
+		finish()
+	}
+	a.saveRecording(flattenResults(results))
 	loadStats := stats.CalculateLoadTest(results)
+	a.writeJSONOutput(loadStats)
 
+	var testHistory *history.LoadTestHistory
 	if a.historyStore != nil {
-		if _, err := a.historyStore.SaveLoadTestResults(loadStats, history.TestTypeLoadUser); err != nil {
+		if testHistory, err = a.historyStore.SaveLoadTestResults(loadStats, history.TestTypeLoadUser); err != nil {
 			logger.Error("Failed to save load test history: %v", err)
 		}
 	}
 
-	fmt.Printf("\nUser Load Test Summary\n")
-	fmt.Printf("====================\n")
-	fmt.Printf("Total Duration: %v\n", loadStats.TestDuration)
-	fmt.Printf("Total Requests: %d\n", loadStats.TotalRequests)
-	fmt.Printf("Overall Average Latency: %v\n\n", loadStats.AverageLatency)
+	fmt.Fprintf(a.out, "\nUser Load Test Summary\n")
+	fmt.Fprintf(a.out, "====================\n")
+	fmt.Fprintf(a.out, "Total Duration: %v\n", loadStats.TestDuration)
+	fmt.Fprintf(a.out, "Total Requests: %d\n", loadStats.TotalRequests)
+	fmt.Fprintf(a.out, "Overall Error Rate: %.2f%%\n", loadStats.ErrorRate())
+	fmt.Fprintf(a.out, "Overall Average Latency: %v\n\n", loadStats.AverageLatency)
 
-	fmt.Printf("Step-by-Step Results:\n")
-	fmt.Printf("-------------------\n")
+	fmt.Fprintf(a.out, "Step-by-Step Results:\n")
+	fmt.Fprintf(a.out, "-------------------\n")
+	var abortErr error
 	for _, step := range loadStats.Steps {
-		fmt.Printf("Concurrent Users: %d\n", step.UserCount)
-		fmt.Printf("  Average Latency: %v\n", step.AverageLatency)
-		fmt.Printf("  Requests/sec: %.2f\n", step.RequestsPerSecond)
-		fmt.Printf("  Success Rate: %.2f%%\n", step.SuccessRate)
-		fmt.Printf("  Error Rate: %.2f%%\n\n", step.ErrorRate)
+		fmt.Fprintf(a.out, "Concurrent Users: %d\n", step.UserCount)
+		fmt.Fprintf(a.out, "  Average Latency: %v\n", step.AverageLatency)
+		fmt.Fprintf(a.out, "  Requests/sec: %.2f\n", step.RequestsPerSecond)
+		fmt.Fprintf(a.out, "  Success Rate: %.2f%%\n", step.SuccessRate)
+		fmt.Fprintf(a.out, "  Error Rate: %.2f%%\n", step.ErrorRate)
+		for name, count := range step.Journeys {
+			fmt.Fprintf(a.out, "  Completed %s Journeys: %d\n", name, count)
+		}
+		if len(step.ScenarioSteps) > 0 {
+			fmt.Fprintf(a.out, "  Scenario Steps:\n")
+			for _, ss := range step.ScenarioSteps {
+				fmt.Fprintf(a.out, "    %s #%d %s %s: avg %v, p50 %v, p95 %v, %d/%d failed\n",
+					ss.Scenario, ss.Step, ss.Method, ss.URL, ss.AverageDuration, ss.P50Latency, ss.P95Latency,
+					ss.FailedRequests, ss.Requests)
+			}
+		}
+		if step.AbortedAfter > 0 {
+			fmt.Fprintf(a.out, "  ABORTED after %v: no successful response for %v\n",
+				step.AbortedAfter.Round(time.Second), a.config.IdleTimeout)
+			abortErr = fmt.Errorf("load test aborted at %d users, %v into the step: target unresponsive for %v",
+				step.UserCount, step.AbortedAfter.Round(time.Second), a.config.IdleTimeout)
+		}
+		fmt.Fprintf(a.out, "\n")
+	}
+	printLoadTestComparison(a.out, testHistory, "Concurrent Users")
+
+	if abortErr != nil {
+		return abortErr
+	}
+	if err := a.checkMaxErrorRate(loadStats.ErrorRate()); err != nil {
+		return err
 	}
+	return a.loadTestDegradationError(testHistory)
 }
 
-func (a *App) runDataLoadTest() {
+func (a *App) runDataLoadTest(ctx context.Context) error {
 	logger.Info("Starting data load test...")
 
+	resume, finish, err := a.loadTestResume(history.TestTypeLoadData)
+	if err != nil {
+		return err
+	}
+
 	config := runner.DataLoadConfig{
 		InitialDataSize:    a.config.InitialDataSize,
 		MaxDataSize:        a.config.MaxDataSize,
 		DataSizeMultiplier: a.config.DataSizeMultiplier,
 		StepsCount:         a.config.DataStepCount,
+		Resume:             resume,
 	}
 
 	logger.Info("Data load test configuration:")
@@ -222,29 +984,712 @@ func (a *App) runDataLoadTest() {
 	logger.Info("- Size multiplier: %.1fx", config.DataSizeMultiplier)
 	logger.Info("- Number of steps: %d", config.StepsCount)
 
-	results := a.runner.RunDataLoadTest(config)
+	results := a.runner.RunDataLoadTest(ctx, config)
+	finish()
+	a.saveRecording(flattenResults(results))
 	loadStats := stats.CalculateLoadTest(results)
+	a.writeJSONOutput(loadStats)
 
+	var testHistory *history.LoadTestHistory
 	if a.historyStore != nil {
-		if _, err := a.historyStore.SaveLoadTestResults(loadStats, history.TestTypeLoadData); err != nil {
+		if testHistory, err = a.historyStore.SaveLoadTestResults(loadStats, history.TestTypeLoadData); err != nil {
 			logger.Error("Failed to save load test history: %v", err)
 		}
 	}
 
-	fmt.Printf("\nData Load Test Summary\n")
-	fmt.Printf("=====================\n")
-	fmt.Printf("Total Duration: %v\n", loadStats.TestDuration)
-	fmt.Printf("Total Requests: %d\n", loadStats.TotalRequests)
-	fmt.Printf("Overall Average Latency: %v\n\n", loadStats.AverageLatency)
+	fmt.Fprintf(a.out, "\nData Load Test Summary\n")
+	fmt.Fprintf(a.out, "=====================\n")
+	fmt.Fprintf(a.out, "Total Duration: %v\n", loadStats.TestDuration)
+	fmt.Fprintf(a.out, "Total Requests: %d\n", loadStats.TotalRequests)
+	fmt.Fprintf(a.out, "Overall Error Rate: %.2f%%\n", loadStats.ErrorRate())
+	fmt.Fprintf(a.out, "Overall Average Latency: %v\n\n", loadStats.AverageLatency)
 
-	fmt.Printf("Step-by-Step Results:\n")
-	fmt.Printf("-------------------\n")
+	fmt.Fprintf(a.out, "Step-by-Step Results:\n")
+	fmt.Fprintf(a.out, "-------------------\n")
 	for _, step := range loadStats.Steps {
-		fmt.Printf("Data Size: %d records\n", step.DataSize)
-		fmt.Printf("  Average Latency: %v\n", step.AverageLatency)
-		fmt.Printf("  Requests/sec: %.2f\n", step.RequestsPerSecond)
-		fmt.Printf("  Success Rate: %.2f%%\n", step.SuccessRate)
-		fmt.Printf("  Error Rate: %.2f%%\n\n", step.ErrorRate)
+		fmt.Fprintf(a.out, "Data Size: %d records\n", step.DataSize)
+		fmt.Fprintf(a.out, "  Average Latency: %v\n", step.AverageLatency)
+		fmt.Fprintf(a.out, "  Requests/sec: %.2f\n", step.RequestsPerSecond)
+		fmt.Fprintf(a.out, "  Success Rate: %.2f%%\n", step.SuccessRate)
+		fmt.Fprintf(a.out, "  Error Rate: %.2f%%\n\n", step.ErrorRate)
+	}
+	printLoadTestComparison(a.out, testHistory, "Data Size")
+
+	if err := a.checkMaxErrorRate(loadStats.ErrorRate()); err != nil {
+		return err
+	}
+	return a.loadTestDegradationError(testHistory)
+}
+
+// loadTestDegradationError applies --fail-on-degradation to a saved load test.
+func (a *App) loadTestDegradationError(testHistory *history.LoadTestHistory) error {
+	if testHistory == nil || testHistory.Comparison == nil {
+		return nil
+	}
+	return a.degradationError(testHistory.Comparison.Degradation, testHistory.BaselineID)
+}
+
+// degradationError returns ErrDegradation for a run that degraded against
+// the baseline baselineID if --fail-on-degradation is set.
+func (a *App) degradationError(degraded bool, baselineID string) error {
+	if !degraded || !a.config.FailOnDegraded {
+		return nil
+	}
+	fmt.Fprintf(a.out, "Run FAILED: performance degraded against baseline %s\n", baselineID)
+	return fmt.Errorf("%w against baseline %s", ErrDegradation, baselineID)
+}
+
+// printLoadTestComparison prints how each step of a load test changed from
+// the baseline run, labelling the steps' load with loadName. Nothing is
+// printed without a baseline.
+func printLoadTestComparison(w io.Writer, testHistory *history.LoadTestHistory, loadName string) {
+	if testHistory == nil || testHistory.Comparison == nil {
+		return
+	}
+	comparison := testHistory.Comparison
+
+	fmt.Fprintf(w, "Load Test Comparison (Baseline: %s)\n", testHistory.BaselineID)
+	fmt.Fprintf(w, "-------------------\n")
+	for _, step := range comparison.Steps {
+		status := "ok"
+		if step.Degradation {
+			status = "DEGRADED"
+		}
+		fmt.Fprintf(w, "%s: %d (%s)\n", loadName, step.Load, status)
+		fmt.Fprintf(w, "  Average Latency: %v -> %v (%+.2f%%)\n",
+			step.Previous.AverageLatency, step.Current.AverageLatency, step.Changes.LatencyIncrease)
+		fmt.Fprintf(w, "  Requests/sec: %.2f -> %.2f (%+.2f%%)\n",
+			step.Previous.RequestsPerSecond, step.Current.RequestsPerSecond, -step.Changes.ThroughputDecrease)
+		fmt.Fprintf(w, "  Success Rate: %.2f%% -> %.2f%%\n", step.Previous.SuccessRate, step.Current.SuccessRate)
+		fmt.Fprintf(w, "  Error Rate: %.2f%% -> %.2f%%\n", step.Previous.ErrorRate, step.Current.ErrorRate)
+	}
+	fmt.Fprintf(w, "Max %s Completed: %d -> %d\n", loadName, comparison.BaselineMaxLoad, comparison.MaxLoad)
+
+	if comparison.Degradation {
+		logger.Warn("Load test degradation detected against baseline %s (threshold %.2f%%)",
+			testHistory.BaselineID, comparison.ThresholdPct)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (a *App) runReplay(ctx context.Context) {
+	results := a.runner.Replay(ctx, a.replay)
+	a.saveRawResults(results)
+	statistics := stats.CalculateBy(results, a.config.GroupBy)
+	percentiles, _ := a.config.PercentileList()
+	statistics.AddPercentiles(results, percentiles)
+	bounds, _ := a.config.HistogramBounds()
+	statistics.SetHistogramBounds(results, bounds)
+
+	logger.Info("Replay completed")
+	a.writeJSONOutput(statistics)
+	printEndpointStats(a.out, statistics)
+	a.writeOpenMetrics(statistics)
+}
+
+func (a *App) runCompareTest(ctx context.Context) {
+	bases := a.config.CompareBases()
+
+	logger.Info("Testing deployment A: %s", bases[0])
+	resultsA := a.runner.Run(ctx)
+	logger.Info("Testing deployment B: %s", bases[1])
+	resultsB := a.compareRunner.Run(ctx)
+	a.saveRecording(append(resultsA, resultsB...))
+
+	statsA := stats.CalculateBy(resultsA, a.config.GroupBy)
+	statsB := stats.CalculateBy(resultsB, a.config.GroupBy)
+	bounds, _ := a.config.HistogramBounds()
+	statsA.SetHistogramBounds(resultsA, bounds)
+	statsB.SetHistogramBounds(resultsB, bounds)
+	a.writeJSONOutput(map[string]*stats.Statistics{bases[0]: statsA, bases[1]: statsB})
+	fmt.Fprintf(a.out, "\n%s", stats.FormatComparison(bases[0], statsA, bases[1], statsB))
+}
+
+// writeJSONOutput applies --output json, writing a run's statistics to stdout
+// in place of the summary.
+func (a *App) writeJSONOutput(v any) {
+	if a.config.Output != config.OutputJSON {
+		return
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		logger.Error("Failed to write JSON output: %v", err)
+	}
+}
+
+// saveRecording writes the request sequence of a run to the --record file.
+func (a *App) saveRecording(results []runner.Result) {
+	if a.config.RecordFile == "" {
+		return
+	}
+
+	if err := runner.SaveRecording(a.config.RecordFile, runner.NewRecording(results)); err != nil {
+		logger.Error("Failed to save request recording: %v", err)
+		return
+	}
+	logger.Info("Recorded %d requests to %s", len(results), a.config.RecordFile)
+}
+
+// saveRawResults writes every result of a run to the --raw-out file.
+func (a *App) saveRawResults(results []runner.Result) {
+	if a.config.RawOut == "" {
+		return
+	}
+
+	if err := runner.SaveRawResults(a.config.RawOut, results); err != nil {
+		logger.Error("Failed to save raw results: %v", err)
+		return
+	}
+	logger.Info("Wrote %d raw results to %s", len(results), a.config.RawOut)
+}
+
+// writeOpenMetrics writes the final metrics of a run to the --openmetrics-out
+// file, labelled with the commit and branch the run was recorded against.
+func (a *App) writeOpenMetrics(statistics *stats.Statistics) {
+	if a.config.OpenMetricsOut == "" {
+		return
+	}
+
+	labels := map[string]string{}
+	if a.historyStore != nil {
+		gitInfo := a.historyStore.GitInfo()
+		labels["commit"] = gitInfo.CommitHash
+		labels["branch"] = gitInfo.Branch
+	}
+
+	f, err := os.Create(a.config.OpenMetricsOut)
+	if err != nil {
+		logger.Error("Failed to create OpenMetrics file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if err := stats.WriteOpenMetrics(f, statistics, labels); err != nil {
+		logger.Error("Failed to write OpenMetrics file: %v", err)
+		return
+	}
+	logger.Info("OpenMetrics written to: %s", a.config.OpenMetricsOut)
+}
+
+// writeMarkdown applies --markdown, writing the results table to a file or
+// to stdout for a CI step to post as a PR comment.
+func (a *App) writeMarkdown(statistics *stats.Statistics, testHistory *history.TestHistory) {
+	if a.config.MarkdownOut == "" {
+		return
+	}
+
+	if a.config.MarkdownOut == "-" {
+		if err := viz.WriteMarkdown(os.Stdout, statistics, testHistory); err != nil {
+			logger.Error("Failed to write Markdown: %v", err)
+		}
+		return
+	}
+
+	f, err := os.Create(a.config.MarkdownOut)
+	if err != nil {
+		logger.Error("Failed to create Markdown file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if err := viz.WriteMarkdown(f, statistics, testHistory); err != nil {
+		logger.Error("Failed to write Markdown file: %v", err)
+		return
+	}
+	logger.Info("Markdown written to: %s", a.config.MarkdownOut)
+}
+
+// writeJUnit applies --junit, writing the endpoints as JUnit test cases for
+// CI to show in its test UI.
+func (a *App) writeJUnit(statistics *stats.Statistics, testHistory *history.TestHistory) {
+	if a.config.JUnitOut == "" {
+		return
+	}
+
+	f, err := os.Create(a.config.JUnitOut)
+	if err != nil {
+		logger.Error("Failed to create JUnit file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if err := report.WriteJUnit(f, statistics, testHistory, a.budgets); err != nil {
+		logger.Error("Failed to write JUnit file: %v", err)
+		return
+	}
+	logger.Info("JUnit report written to: %s", a.config.JUnitOut)
+}
+
+// notifyDegradation posts a summary of the endpoints that degraded in
+// testHistory to --webhook-url, if set. A failure to notify is only logged,
+// as it says nothing about the run.
+func (a *App) notifyDegradation(testHistory *history.TestHistory) {
+	if a.config.WebhookURL == "" {
+		return
+	}
+
+	notice := report.NewDegradationNotice(testHistory)
+	var body []byte
+	var err error
+	if a.config.WebhookFormat == config.WebhookSlack {
+		body, err = notice.SlackMessage()
+	} else {
+		body, err = json.Marshal(notice)
+	}
+	if err != nil {
+		logger.Warn("Failed to encode degradation webhook: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("Failed to create degradation webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Leave out the URL, which for Slack is a secret
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		logger.Warn("Failed to send degradation webhook: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		logger.Warn("Degradation webhook returned status %d", resp.StatusCode)
+		return
+	}
+	logger.Info("Degradation webhook sent")
+}
+
+// writeReport writes the report for summary either to stdout or to a new
+// file in the report directory, in the configured format.
+func (a *App) writeReport(summary *history.Summary, testHistory *history.TestHistory, metadata *viz.ReportMetadata) {
+	opts := viz.GraphOptions{
+		Width:           a.config.GraphWidth,
+		PointLimit:      a.config.GraphPoints,
+		Padding:         a.config.GraphPadding,
+		TopN:            a.config.ReportTop,
+		ChangeThreshold: a.config.ReportChanged,
+		Metadata:        metadata,
+	}
+
+	if a.config.ReportStdout {
+		var err error
+		if a.config.ReportFormat == config.ReportFormatJSON {
+			err = viz.WriteJSONReport(os.Stdout, summary, metadata)
+		} else {
+			err = viz.WriteGraph(os.Stdout, summary, testHistory, opts)
+		}
+		if err != nil {
+			logger.Error("Failed to write report to stdout: %v", err)
+		}
+		return
+	}
+
+	var reportPath string
+	var err error
+	if a.config.ReportFormat == config.ReportFormatJSON {
+		reportPath, err = viz.GenerateJSONReport(summary, metadata, "performance-reports")
+	} else {
+		reportPath, err = viz.GenerateGraph(summary, testHistory, "performance-reports", opts)
+	}
+	if err != nil {
+		logger.Error("Failed to generate performance report: %v", err)
+		return
+	}
+
+	absPath, _ := filepath.Abs(reportPath)
+	logger.Info("Performance report generated in performance-reports directory")
+	fmt.Fprintf(a.out, "\nView results at: file://%s\n", absPath)
+	a.pruneReports("performance-reports")
+}
+
+// pruneReports applies --report-retention to the report directory.
+func (a *App) pruneReports(reportDir string) {
+	if a.config.ReportRetention <= 0 {
+		return
+	}
+
+	removed, err := viz.PruneReports(reportDir, a.config.ReportRetention)
+	if err != nil {
+		logger.Warn("Failed to prune old reports: %v", err)
+	}
+	if len(removed) > 0 {
+		logger.Info("Removed %d old reports, keeping the %d most recent", len(removed), a.config.ReportRetention)
+	}
+}
+
+func flattenResults(loadResults []runner.LoadTestResult) []runner.Result {
+	var results []runner.Result
+	for _, step := range loadResults {
+		results = append(results, step.Results...)
+	}
+	return results
+}
+
+func printEndpointStats(w io.Writer, statistics *stats.Statistics) {
+	for endpoint, stats := range statistics.EndpointStats {
+		fmt.Fprintf(w, "\nEndpoint: %s\n", endpoint)
+		fmt.Fprintf(w, "  Average Latency: %.2fms\n", float64(stats.AverageDuration.Milliseconds()))
+		fmt.Fprintf(w, "  Latency Std Dev: %.2fms\n", float64(stats.StdDevDuration.Microseconds())/1000)
+		fmt.Fprintf(w, "  P50 Latency: %.2fms\n", float64(stats.P50Latency.Milliseconds()))
+		fmt.Fprintf(w, "  P95 Latency: %.2fms\n", float64(stats.P95Latency.Milliseconds()))
+		fmt.Fprintf(w, "  P99 Latency: %.2fms\n", float64(stats.P99Latency.Milliseconds()))
+		fmt.Fprintf(w, "  P99.9 Latency: %.2fms\n", float64(stats.P999Latency.Milliseconds()))
+		for _, pl := range stats.ExtraPercentiles() {
+			fmt.Fprintf(w, "  %s Latency: %.2fms\n", pl.Label(), float64(pl.Latency.Milliseconds()))
+		}
+		fmt.Fprintf(w, "  Average TTFB: %.2fms\n", float64(stats.AverageTTFB.Milliseconds()))
+		fmt.Fprintf(w, "  P50 TTFB: %.2fms\n", float64(stats.P50TTFB.Milliseconds()))
+		fmt.Fprintf(w, "  P95 TTFB: %.2fms\n", float64(stats.P95TTFB.Milliseconds()))
+		fmt.Fprintf(w, "  P99 TTFB: %.2fms\n", float64(stats.P99TTFB.Milliseconds()))
+		if p := stats.Phases; p.Total() > 0 {
+			fmt.Fprintf(w, "  Avg DNS/Connect/TLS/Server/Other: %.2fms/%.2fms/%.2fms/%.2fms/%.2fms\n",
+				float64(p.DNS.Microseconds())/1000, float64(p.Connect.Microseconds())/1000,
+				float64(p.TLS.Microseconds())/1000, float64(p.Server.Microseconds())/1000,
+				float64(p.Other.Microseconds())/1000)
+		}
+		printHistogram(w, stats.Histogram)
+		fmt.Fprintf(w, "  Requests/sec: %.2f\n", stats.RequestsPerSecond)
+		if stats.TargetRPS > 0 {
+			fmt.Fprintf(w, "  Target/Achieved RPS: %.2f/%.2f\n", stats.TargetRPS, stats.AchievedRPS)
+		}
+		fmt.Fprintf(w, "  Transferred: %s in, %s out (%s/s in, %s/s out)\n",
+			util.FormatBytes(float64(stats.BytesIn)), util.FormatBytes(float64(stats.BytesOut)),
+			util.FormatBytes(stats.BytesInPerSecond), util.FormatBytes(stats.BytesOutPerSecond))
+		fmt.Fprintf(w, "  Success Rate: %.2f%%\n", successRate(stats))
+		if len(stats.Protocols) > 0 {
+			fmt.Fprintf(w, "  Protocols: %s\n", formatProtocols(stats.Protocols))
+		}
+		if stats.RetriedRequests > 0 {
+			fmt.Fprintf(w, "  Retries: %d (%d requests retried)\n", stats.Retries, stats.RetriedRequests)
+		}
+		for rule, count := range stats.RuleFailures {
+			fmt.Fprintf(w, "  Failed %s validation: %d\n", rule, count)
+		}
+		if injected := stats.InjectedRequests(); injected > 0 {
+			fmt.Fprintf(w, "  Injected Faults: %d sent, %d rejected (excluded from the figures above)\n",
+				injected, stats.InjectedRejected)
+			for fault, count := range stats.InjectedFaults {
+				fmt.Fprintf(w, "    %s: %d\n", fault, count)
+			}
+		}
+		if f := stats.Failures; f.Total() > 0 {
+			fmt.Fprintf(w, "  Failures: %d timeouts, %d DNS, %d connection, %d validation, %d other\n",
+				f.Timeouts, f.DNSErrors, f.ConnectionErrors, f.Validation, f.Other)
+		}
+		if errs := stats.UniqueErrors(); len(errs) > 0 {
+			fmt.Fprintf(w, "  Unique Errors: %d\n", len(errs))
+			for _, e := range errs {
+				fmt.Fprintf(w, "    %6d  %s\n", e.Count, e.Message)
+			}
+		}
+
+		cs := stats.ConnectionStats
+		fmt.Fprintf(w, "  Connections: %d (%.2f requests/connection)\n", cs.Connections, cs.RequestsPerConnection)
+		fmt.Fprintf(w, "  New/Reused Connection Requests: %d/%d (%.1f%% reused)\n", cs.NewConnRequests, cs.ReusedRequests, cs.ReusedPct())
+		fmt.Fprintf(w, "  New Connection P50/P95: %.2fms/%.2fms\n",
+			float64(cs.NewConnP50Latency.Microseconds())/1000, float64(cs.NewConnP95Latency.Microseconds())/1000)
+		fmt.Fprintf(w, "  Reused Connection P50/P95: %.2fms/%.2fms\n",
+			float64(cs.ReusedP50Latency.Microseconds())/1000, float64(cs.ReusedP95Latency.Microseconds())/1000)
+		fmt.Fprintf(w, "  Average Connection Wait: %.2fms\n", float64(cs.AverageConnWait.Microseconds())/1000)
+		if c := stats.Compression; c != nil {
+			fmt.Fprintf(w, "  Average Response Body: %d bytes received, %d decoded (%.2fx compression)\n",
+				c.AverageCompressed(), c.AverageUncompressed(), c.Ratio())
+		}
+
+		if len(stats.Hosts) > 1 {
+			fmt.Fprintf(w, "  Hosts:\n")
+			for _, host := range stats.HostList() {
+				hs := stats.Hosts[host]
+				fmt.Fprintf(w, "    %s: %d requests, %d failed, avg %.2fms, P50/P95 %.2fms/%.2fms\n",
+					host, hs.Requests, hs.FailedRequests,
+					float64(hs.AverageDuration.Microseconds())/1000,
+					float64(hs.P50Latency.Microseconds())/1000, float64(hs.P95Latency.Microseconds())/1000)
+			}
+		}
+	}
+}
+
+// histogramWidth is the length of the bar of the fullest histogram bucket.
+const histogramWidth = 40
+
+// printHistogram draws the latency histogram's buckets from the first to the
+// last non-empty one as bars.
+func printHistogram(w io.Writer, h *stats.Histogram) {
+	if h == nil {
+		return
+	}
+	first, last := h.Range()
+	if first < 0 {
+		return
+	}
+
+	peak := 0
+	for _, count := range h.Counts {
+		peak = max(peak, count)
+	}
+	fmt.Fprintf(w, "  Latency Histogram:\n")
+	for i := first; i <= last; i++ {
+		bar := strings.Repeat("#", (h.Counts[i]*histogramWidth+peak-1)/peak)
+		fmt.Fprintf(w, "    %8s %7d %s\n", h.Label(i), h.Counts[i], bar)
+	}
+}
+
+// formatProtocols lists the responses per protocol, e.g. "HTTP/2.0 98, HTTP/1.1 2".
+func formatProtocols(protocols map[string]int) string {
+	names := make([]string, 0, len(protocols))
+	for name := range protocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, protocols[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkGeneratorSaturation compares the rate requests were actually offered
+// at against --rate. Falling short means the generator, not the server, was
+// the bottleneck, which makes the results meaningless as a fixed-load test.
+// It only fails the run with --fail-if-generator-saturated.
+func (a *App) checkGeneratorSaturation() error {
+	if a.runner == nil || a.config.Rate <= 0 {
+		return nil
+	}
+	offered := a.runner.OfferedRPS()
+	if offered == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(a.out, "\nOffered RPS: %.2f (target %.2f)\n", offered, a.config.Rate)
+	if offered >= a.config.Rate*(1-a.config.RPSTolerance/100) {
+		return nil
+	}
+
+	logger.Warn("Load generator only offered %.2f of the %.2f requests/second asked for; "+
+		"increase --thread-count or split the load across several machines and combine them with --merge",
+		offered, a.config.Rate)
+	if !a.config.FailIfGeneratorSaturated {
+		return nil
+	}
+	fmt.Fprintf(a.out, "Run FAILED: load generator saturated\n")
+	return fmt.Errorf("load generator saturated: offered %.2f of %.2f requests/second", offered, a.config.Rate)
+}
+
+// printRetryBudget prints how much of the --retry-budget the run used.
+func (a *App) printRetryBudget() {
+	if a.runner == nil {
+		return
+	}
+	usage := a.runner.RetryBudgetUsage()
+	if usage.Pct <= 0 {
+		return
+	}
+
+	allowed := float64(usage.Requests) * usage.Pct / 100
+	fmt.Fprintf(a.out, "Retry Budget: %d of %.0f retries used (%.0f%% of %d requests), %d denied\n",
+		usage.Retries, allowed, usage.Pct, usage.Requests, usage.Denied)
+	if usage.Denied > 0 {
+		logger.Warn("Retry budget exhausted: %d retries were skipped and their failures recorded as is", usage.Denied)
+	}
+}
+
+// validateTemplate renders the HTML report template against synthetic data
+// and reports whether it succeeded.
+func (a *App) validateTemplate() error {
+	if err := viz.ValidateTemplate(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Report template OK\n")
+	return nil
+}
+
+// verifyHistory reports where the history summary disagrees with the run
+// files and, with --repair-history, rebuilds it from them.
+func (a *App) verifyHistory() error {
+	report, err := a.historyStore.VerifyIntegrity()
+	if err != nil {
+		return fmt.Errorf("failed to verify history: %w", err)
+	}
+
+	fmt.Fprintf(a.out, "History: %d run files, %d runs in the summary\n", report.RunFiles, report.SummaryRuns)
+	if report.OK() {
+		fmt.Fprintf(a.out, "History OK\n")
+		return nil
+	}
+	fmt.Fprintf(a.out, "\nHistory Problems\n")
+	for _, problem := range report.Problems {
+		fmt.Fprintf(a.out, "  %s\n", problem)
+	}
+
+	if !a.config.RepairHistory {
+		fmt.Fprintf(a.out, "\nRun with --repair-history to rebuild the summary from the run files\n")
+		return fmt.Errorf("history has %d problems", len(report.Problems))
+	}
+	summary, err := a.historyStore.RebuildSummary()
+	if err != nil {
+		return fmt.Errorf("failed to repair history: %w", err)
+	}
+	fmt.Fprintf(a.out, "\nRebuilt the summary from %d runs\n", summary.RunCount)
+	return nil
+}
+
+// exportCSV writes the endpoint history to the --export-csv file or stdout.
+func (a *App) exportCSV() error {
+	if a.config.ExportCSV == "-" {
+		return a.historyStore.ExportCSV(os.Stdout)
+	}
+
+	f, err := os.Create(a.config.ExportCSV)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer f.Close()
+
+	if err := a.historyStore.ExportCSV(f); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	logger.Info("History exported to: %s", a.config.ExportCSV)
+	return nil
+}
+
+// checkLatencyBudgets prints whether each endpoint with a latencyBudgetMs kept
+// its P95 latency within budget, and fails the run if any didn't.
+func (a *App) checkLatencyBudgets(statistics *stats.Statistics) error {
+	checks := statistics.CheckBudgets(a.budgets)
+	if len(checks) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(a.out, "\nLatency Budgets (P95)\n")
+	failed := 0
+	for _, check := range checks {
+		status := "PASS"
+		if !check.Met {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(a.out, "  %s %s: %v (budget %v)\n", status, check.Endpoint, check.P95, check.Budget)
+	}
+	fmt.Fprintf(a.out, "%d/%d endpoints within budget\n", len(checks)-failed, len(checks))
+
+	if failed == 0 {
+		return nil
+	}
+	fmt.Fprintf(a.out, "Run FAILED: latency budget exceeded\n")
+	return fmt.Errorf("%d of %d endpoints exceeded their latency budget", failed, len(checks))
+}
+
+// printThroughputShortfalls lists endpoints that couldn't sustain the offered
+// load, i.e. whose achieved RPS fell more than tolerance percent below --rate.
+func printThroughputShortfalls(w io.Writer, statistics *stats.Statistics, tolerance float64) {
+	header := false
+	for endpoint, es := range statistics.EndpointStats {
+		if !es.BelowTarget(tolerance) {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "\nThroughput Below Target (tolerance %.0f%%)\n", tolerance)
+			header = true
+		}
+		fmt.Fprintf(w, "\nEndpoint: %s\n", endpoint)
+		fmt.Fprintf(w, "  Target RPS: %.2f\n", es.TargetRPS)
+		fmt.Fprintf(w, "  Achieved RPS: %.2f (%.1f%% of target)\n", es.AchievedRPS, es.AchievedRPS/es.TargetRPS*100)
+	}
+}
+
+// printConnectionRates shows how fast new connections were opened to each
+// host, which reveals connection churn that the request rate alone hides.
+func printConnectionRates(w io.Writer, statistics *stats.Statistics) {
+	header := false
+	for _, host := range statistics.ConnectionRateHosts() {
+		rate := statistics.ConnectionRates[host]
+		if rate.NewConnections == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "\nNew Connections\n")
+			header = true
+		}
+		fmt.Fprintf(w, "  %s: %d for %d requests, avg %.2f/s, peak %d/s\n",
+			host, rate.NewConnections, rate.Requests, rate.AveragePerSec, rate.PeakPerSec)
+	}
+}
+
+// printInconsistentResponses lists endpoints that returned different bodies or
+// status codes to identical requests.
+func printInconsistentResponses(w io.Writer, statistics *stats.Statistics) {
+	header := false
+	for endpoint, es := range statistics.EndpointStats {
+		cs := es.Consistency
+		if cs == nil || !cs.Inconsistent() {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "\nInconsistent Responses\n")
+			header = true
+		}
+		fmt.Fprintf(w, "\nEndpoint: %s\n", endpoint)
+		fmt.Fprintf(w, "  Distinct Bodies: %d (%d-%d bytes)\n", len(cs.BodyVariants), cs.MinBodySize, cs.MaxBodySize)
+		fmt.Fprintf(w, "  Status Codes:")
+		for _, code := range cs.StatusList() {
+			fmt.Fprintf(w, " %d x%d", code, cs.StatusCodes[code])
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	if !header {
+		fmt.Fprintf(w, "\nAll endpoints returned consistent responses\n")
+	}
+}
+
+// printDistributionChanges lists endpoints whose latency distribution differs
+// significantly from the baseline according to a Kolmogorov-Smirnov test.
+func printDistributionChanges(w io.Writer, testHistory *history.TestHistory) {
+	if testHistory.BaselineID == "" {
+		return
+	}
+
+	header := false
+	for endpoint, comparison := range testHistory.Endpoints {
+		ks := comparison.Distribution
+		if ks == nil || !ks.Significant {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "\nLatency Distribution Changes (Baseline: %s)\n", testHistory.BaselineID)
+			header = true
+		}
+		fmt.Fprintf(w, "\nEndpoint: %s\n", endpoint)
+		fmt.Fprintf(w, "  KS Statistic: %.4f\n", ks.Statistic)
+		fmt.Fprintf(w, "  p-value: %.4f\n", ks.PValue)
+	}
+	if !header {
+		fmt.Fprintf(w, "\nNo statistically significant latency distribution changes from baseline %s\n", testHistory.BaselineID)
+	}
+}
+
+func (a *App) scoreConfig() score.Config {
+	return score.Config{
+		Weights: score.Weights{
+			SuccessRate: a.config.ScoreSuccessWeight,
+			Latency:     a.config.ScoreLatencyWeight,
+			Throughput:  a.config.ScoreThroughputWeight,
+		},
+		LatencySLA: time.Duration(a.config.ScoreSLAP95) * time.Millisecond,
+		TargetRPS:  a.config.ScoreTargetRPS,
 	}
 }
 
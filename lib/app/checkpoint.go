@@ -0,0 +1,57 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"percipio.com/gopi/lib/config"
+	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/runner"
+)
+
+// loadTestResume sets up checkpointing for a testType load test. With
+// --resume it picks up the steps the interrupted run completed; otherwise the
+// run gets a new ID to resume it by. The returned func removes the checkpoint
+// and is to be called once the test has run to completion.
+func (a *App) loadTestResume(testType string) (runner.Resume, func(), error) {
+	runID := a.config.Resume
+	var completed []runner.LoadTestResult
+	if runID != "" {
+		var err error
+		completed, err = runner.LoadCheckpoint(a.checkpointPath(runID), testType)
+		if err != nil {
+			return runner.Resume{}, nil, err
+		}
+		logger.Info("Resuming run %s from step %d", runID, len(completed)+1)
+	} else {
+		runID = time.Now().Format("20060102-150405")
+		logger.Info("Checkpointing progress as run %s; continue an interrupted run with --resume %s", runID, runID)
+	}
+
+	path := a.checkpointPath(runID)
+	resume := runner.Resume{
+		Completed: completed,
+		OnStep: func(steps []runner.LoadTestResult) {
+			if err := runner.SaveCheckpoint(path, testType, steps); err != nil {
+				logger.Error("Failed to save checkpoint: %v", err)
+			}
+		},
+	}
+	finish := func() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove checkpoint %s: %v", path, err)
+		}
+	}
+	return resume, finish, nil
+}
+
+// checkpointPath returns where the progress of runID is kept, in the
+// checkpoints directory of the history store, one file per run ID.
+func (a *App) checkpointPath(runID string) string {
+	dir := config.DefaultHistoryDir
+	if a.historyStore != nil {
+		dir = a.historyStore.BaseDir()
+	}
+	return filepath.Join(dir, "checkpoints", runID+".json")
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"percipio.com/gopi/lib/config"
+	"percipio.com/gopi/lib/runner"
+)
+
+// loadDataSet reads --data-file, if set, and checks that every placeholder in
+// the endpoints and scenario steps names one of its columns or, in a
+// scenario, a value extracted by an earlier step.
+func loadDataSet(cfg *config.Config, endpoints TestConfig, scenarios []ScenarioConfig) (*runner.DataSet, error) {
+	var data *runner.DataSet
+	if cfg.DataFile != "" {
+		var err error
+		if data, err = runner.LoadDataSet(cfg.DataFile, cfg.DataSelection == config.HostRandom); err != nil {
+			return nil, err
+		}
+		for _, endpoint := range endpoints {
+			if err := checkPlaceholders(endpoint, data.HasColumn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, scenario := range scenarios {
+		if data == nil && !scenario.extracts() {
+			// Without either, ${...} is just text the step sends as is
+			continue
+		}
+		extracted := make(map[string]bool)
+		known := func(name string) bool {
+			return extracted[name] || data.HasColumn(name)
+		}
+		for _, step := range scenario.Steps {
+			if err := checkPlaceholders(step.EndpointConfig, known); err != nil {
+				return nil, fmt.Errorf("scenario %q: %w", scenario.Name, err)
+			}
+			for _, extract := range step.Extract {
+				extracted[extract.Name] = true
+			}
+		}
+	}
+	return data, nil
+}
+
+// checkPlaceholders reports the placeholders of endpoint that known doesn't
+// recognize.
+func checkPlaceholders(endpoint EndpointConfig, known func(name string) bool) error {
+	names := runner.Placeholders(endpoint.URL)
+	for k, v := range endpoint.Headers {
+		names = append(names, runner.Placeholders(k)...)
+		names = append(names, runner.Placeholders(v)...)
+	}
+	names = append(names, runner.Placeholders(endpoint.Body)...)
+
+	var missing []string
+	for _, name := range names {
+		if !known(name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s has placeholders that are neither a data file column nor extracted by an earlier step: %s", endpoint.URL, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+
+	"percipio.com/gopi/lib/config"
+	"percipio.com/gopi/lib/runner"
+)
+
+// resolveHosts defaults the endpoint's hosts and host selection to --hosts
+// and --host-selection, and works out the URL of the endpoint on each host.
+// It returns the base URL relative endpoint paths are joined to: --base-url,
+// or the first host when there is none.
+func resolveHosts(endpoint *EndpointConfig, cfg *config.Config) (string, error) {
+	if len(endpoint.Hosts) == 0 {
+		endpoint.Hosts = cfg.HostList()
+	}
+	if endpoint.HostSelection == "" {
+		endpoint.HostSelection = cfg.HostSelection
+	}
+	if endpoint.HostSelection != config.HostRoundRobin && endpoint.HostSelection != config.HostRandom {
+		return "", fmt.Errorf("hostSelection for %s must be round-robin or random", endpoint.URL)
+	}
+
+	endpoint.targets = nil
+	for _, host := range endpoint.Hosts {
+		targetURL, err := hostURL(endpoint.URL, host)
+		if err != nil {
+			return "", fmt.Errorf("invalid host for %s: %w", endpoint.URL, err)
+		}
+		endpoint.targets = append(endpoint.targets, runner.Target{Host: host, URL: targetURL})
+	}
+
+	if cfg.BaseURL == "" && len(endpoint.Hosts) > 0 {
+		return endpoint.Hosts[0], nil
+	}
+	return cfg.BaseURL, nil
+}
+
+// hostURL returns the URL of the endpoint on host, joining relative paths
+// onto it and moving absolute URLs over to it.
+func hostURL(endpointURL, host string) (string, error) {
+	if endpoint, err := url.Parse(endpointURL); err == nil && endpoint.IsAbs() {
+		return rebaseURL(endpointURL, host)
+	}
+	return resolveEndpointURL(endpointURL, host)
+}
+
+// hostPool returns the pool the endpoint's requests are spread across, or nil
+// when it has no hosts of its own.
+func hostPool(endpoint EndpointConfig) *runner.HostPool {
+	if len(endpoint.targets) == 0 {
+		return nil
+	}
+	return runner.NewHostPool(endpoint.targets, endpoint.HostSelection == config.HostRandom)
+}
+
+// targetURLs returns every URL the endpoint's requests may be sent to.
+func targetURLs(endpoint EndpointConfig) []string {
+	if len(endpoint.targets) == 0 {
+		return []string{endpoint.URL}
+	}
+	urls := make([]string, 0, len(endpoint.targets))
+	for _, target := range endpoint.targets {
+		urls = append(urls, target.URL)
+	}
+	return urls
+}
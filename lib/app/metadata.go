@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"percipio.com/gopi/lib/config"
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/viz"
+)
+
+// reportMetadata records the settings that produced results, for the report
+// header.
+func (a *App) reportMetadata(results []runner.Result) *viz.ReportMetadata {
+	cfg := a.config
+	metadata := &viz.ReportMetadata{
+		Version:     config.Version,
+		GeneratedAt: time.Now(),
+		Duration:    runDuration(results),
+	}
+	add := func(name, value string) {
+		metadata.Settings = append(metadata.Settings, viz.Setting{Name: name, Value: value})
+	}
+
+	if cfg.MergeFiles != "" {
+		add("Mode", "merge")
+		add("Merged files", strings.Join(cfg.MergePaths(), ", "))
+	} else {
+		add("Mode", "performance test")
+		if cfg.Postman != "" {
+			add("Postman collection", cfg.Postman)
+		} else {
+			add("Endpoints file", cfg.FilePath)
+		}
+		if cfg.BaseURL != "" {
+			add("Base URL", cfg.BaseURL)
+		}
+		if hosts := cfg.HostList(); len(hosts) > 0 {
+			add("Hosts", fmt.Sprintf("%s (%s)", strings.Join(hosts, ", "), cfg.HostSelection))
+		}
+		if cfg.DataFile != "" {
+			add("Data file", fmt.Sprintf("%s (%s)", cfg.DataFile, cfg.DataSelection))
+		}
+		add("Threads", strconv.Itoa(cfg.ThreadCount))
+		connections := "one per thread"
+		if cfg.ConnectionCount > 0 {
+			connections = fmt.Sprintf("%d per host, shared", cfg.ConnectionCount)
+		}
+		if cfg.MaxIdleConns > 0 {
+			connections += fmt.Sprintf(", at most %d idle", cfg.MaxIdleConns)
+		}
+		add("Connections", connections)
+		add("Requests per endpoint", strconv.Itoa(cfg.RequestCount))
+		if cfg.Warmup > 0 {
+			add("Warmup requests per endpoint", strconv.Itoa(cfg.Warmup))
+		}
+		if cfg.MaxDuration > 0 {
+			add("Max duration", cfg.MaxDuration.String())
+		}
+	}
+
+	rate := "unlimited"
+	if cfg.Rate > 0 {
+		rate = fmt.Sprintf("%.2f requests/sec", cfg.Rate)
+	}
+	add("Rate limit", rate)
+	if cfg.MergeFiles == "" {
+		add("Retries", fmt.Sprintf("%d (%s jitter, %v-%v backoff)", cfg.Retries, cfg.RetryJitter, cfg.RetryBackoff, cfg.RetryMaxBackoff))
+		if cfg.RetryBudget > 0 {
+			add("Retry budget", fmt.Sprintf("%.1f%% of requests", cfg.RetryBudget))
+		}
+		add("Connect / TLS timeout", fmt.Sprintf("%v / %v", cfg.ConnectTimeout, cfg.TLSHandshakeTimeout))
+		if cfg.ThrottleKBps > 0 || cfg.AddedLatency > 0 {
+			add("Client network throttling", fmt.Sprintf("%s, +%v latency (included in latencies)", bandwidthLabel(cfg.ThrottleKBps), cfg.AddedLatency))
+		}
+		if cfg.InsecureSkipVerify {
+			add("TLS verification", "disabled")
+		}
+		if cfg.HTTP2 {
+			add("HTTP/2", "negotiated with HTTPS endpoints")
+		}
+		if encodings := cfg.AcceptEncodingList(); len(encodings) > 0 {
+			add("Accept-Encoding", strings.Join(encodings, ", ")+" (decoding included in latencies)")
+		}
+		if cfg.NoRedirects {
+			add("Redirects", "not followed, 3xx responses recorded")
+		}
+		if len(cfg.Resolve) > 0 {
+			add("Host overrides", strings.Join(cfg.Resolve, ", "))
+		}
+		if cfg.FaultRate > 0 {
+			add("Injected faults", fmt.Sprintf("%.1f%% (%s)", cfg.FaultRate, strings.Join(cfg.FaultKindList(), ", ")))
+		}
+	}
+	add("Degradation threshold", fmt.Sprintf("%.1f%%", cfg.ThresholdPct))
+	add("Degradation latency metric", cfg.LatencyMetric)
+	add("Git tracking", strconv.FormatBool(!cfg.NoGit))
+
+	return metadata
+}
+
+// runDuration returns the wall-clock time from the first request sent to
+// the last response received.
+func runDuration(results []runner.Result) time.Duration {
+	var first, last time.Time
+	for _, result := range results {
+		if first.IsZero() || result.StartTime.Before(first) {
+			first = result.StartTime
+		}
+		if result.EndTime.After(last) {
+			last = result.EndTime
+		}
+	}
+	return last.Sub(first).Round(time.Millisecond)
+}
+
+func bandwidthLabel(kbps int) string {
+	if kbps <= 0 {
+		return "unlimited bandwidth"
+	}
+	return fmt.Sprintf("%d kbps", kbps)
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/runner"
+)
+
+// metricsShutdownTimeout bounds how long stopping the metrics server waits
+// for scrapes in progress.
+const metricsShutdownTimeout = 5 * time.Second
+
+// serveMetrics records the requests of the app's runners as live metrics and
+// serves them on addr, until the returned function shuts the server down.
+func (a *App) serveMetrics(addr string) (func(), error) {
+	metrics := runner.NewMetrics()
+	a.runner.SetMetrics(metrics)
+	if a.compareRunner != nil {
+		a.compareRunner.SetMetrics(metrics)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve metrics: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			logger.Warn("Metrics server stopped: %v", err)
+		}
+	}()
+	logger.Info("Serving live metrics on http://%s/metrics", listener.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Warn("Failed to shut down the metrics server: %v", err)
+		}
+		<-done
+	}, nil
+}
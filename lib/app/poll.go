@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/validate"
+)
+
+// Poll defaults, for endpoints that leave the interval or timeout unset
+const (
+	defaultPollInterval = time.Second
+	defaultPollTimeout  = time.Minute
+)
+
+// PollConfig makes an endpoint asynchronous: after each request its status
+// URL is polled until the work is done, see runner.Poll.
+type PollConfig struct {
+	// URLFrom is where the submit response gives the status URL, either
+	// "header:Location" or a JSON path such as "$.statusUrl"
+	URLFrom  string          `json:"urlFrom"`
+	Interval string          `json:"interval,omitempty"`
+	Timeout  string          `json:"timeout,omitempty"`
+	Until    []validate.Rule `json:"until"`
+	FailIf   []validate.Rule `json:"failIf,omitempty"`
+}
+
+// preparePoll checks the endpoint's poll settings and converts them for the
+// runner. Endpoints without polling are left alone.
+func preparePoll(endpoint *EndpointConfig) error {
+	endpoint.poll = nil
+	cfg := endpoint.Poll
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.URLFrom == "" || cfg.URLFrom == "header:" {
+		return fmt.Errorf("poll for %s needs urlFrom, a \"header:\" name or JSON path", endpoint.URL)
+	}
+	if len(cfg.Until) == 0 {
+		return fmt.Errorf("poll for %s needs until rules to tell when it is done", endpoint.URL)
+	}
+	if err := validate.Check(cfg.Until); err != nil {
+		return fmt.Errorf("invalid poll until rules for %s: %w", endpoint.URL, err)
+	}
+	if err := validate.Check(cfg.FailIf); err != nil {
+		return fmt.Errorf("invalid poll failIf rules for %s: %w", endpoint.URL, err)
+	}
+
+	poll := &runner.Poll{
+		URLFrom:  strings.TrimSpace(cfg.URLFrom),
+		Interval: defaultPollInterval,
+		Timeout:  defaultPollTimeout,
+		Until:    cfg.Until,
+		FailIf:   cfg.FailIf,
+	}
+	if cfg.Interval != "" {
+		interval, err := time.ParseDuration(cfg.Interval)
+		if err != nil || interval < 0 {
+			return fmt.Errorf("invalid poll interval %q for %s", cfg.Interval, endpoint.URL)
+		}
+		poll.Interval = interval
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("invalid poll timeout %q for %s", cfg.Timeout, endpoint.URL)
+		}
+		poll.Timeout = timeout
+	}
+
+	endpoint.poll = poll
+	return nil
+}
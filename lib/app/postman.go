@@ -0,0 +1,229 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// postmanVariable matches a {{name}} placeholder in a Postman request.
+var postmanVariable = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// postmanCollection is the part of a Postman v2 collection that describes
+// its requests.
+type postmanCollection struct {
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+// postmanItem is a request, or a folder of items when Request is nil.
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	URL    postmanURL        `json:"url"`
+	Header []postmanKeyValue `json:"header,omitempty"`
+	Body   *postmanBody      `json:"body,omitempty"`
+}
+
+// UnmarshalJSON also accepts the short form of a request, a plain GET URL.
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = postmanRequest{Method: "GET", URL: postmanURL(raw)}
+		return nil
+	}
+	type request postmanRequest
+	return json.Unmarshal(data, (*request)(r))
+}
+
+// postmanURL is a request URL, which Postman writes either as a string or as
+// an object holding the string in "raw".
+type postmanURL string
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = postmanURL(raw)
+		return nil
+	}
+	var parsed struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	*u = postmanURL(parsed.Raw)
+	return nil
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw,omitempty"`
+	URLEncoded []postmanKeyValue `json:"urlencoded,omitempty"`
+}
+
+// postmanKeyValue is a header, variable or form field. Environment files
+// mark their variables with Enabled rather than Disabled.
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+func (kv postmanKeyValue) active() bool {
+	return !kv.Disabled && (kv.Enabled == nil || *kv.Enabled)
+}
+
+// loadPostman converts the requests of the Postman collection at path into
+// endpoints, named by their folder path such as "Users / Get user". Its
+// {{variables}} are filled from the collection's variables, overridden by the
+// Postman environment file at envPath if set.
+func loadPostman(path, envPath string) (TestConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Postman collection: %w", err)
+	}
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse Postman collection: %w", err)
+	}
+
+	vars := make(map[string]string)
+	for _, v := range collection.Variable {
+		if v.active() {
+			vars[v.Key] = v.Value
+		}
+	}
+	if envPath != "" {
+		data, err := os.ReadFile(envPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Postman environment: %w", err)
+		}
+		var env struct {
+			Values []postmanKeyValue `json:"values"`
+		}
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, fmt.Errorf("failed to parse Postman environment: %w", err)
+		}
+		for _, v := range env.Values {
+			if v.active() {
+				vars[v.Key] = v.Value
+			}
+		}
+	}
+
+	var endpoints TestConfig
+	if err := addPostmanItems(&endpoints, collection.Item, "", vars); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// addPostmanItems appends the requests in items, under the folder prefix, to
+// endpoints.
+func addPostmanItems(endpoints *TestConfig, items []postmanItem, prefix string, vars map[string]string) error {
+	for _, item := range items {
+		name := item.Name
+		if prefix != "" {
+			name = prefix + " / " + item.Name
+		}
+		if item.Request == nil {
+			if err := addPostmanItems(endpoints, item.Item, name, vars); err != nil {
+				return err
+			}
+			continue
+		}
+
+		endpoint, err := postmanEndpoint(name, item.Request, vars)
+		if err != nil {
+			return fmt.Errorf("Postman request %q: %w", name, err)
+		}
+		*endpoints = append(*endpoints, endpoint)
+	}
+	return nil
+}
+
+// postmanEndpoint converts one Postman request into an endpoint, filling in
+// its variables.
+func postmanEndpoint(name string, request *postmanRequest, vars map[string]string) (EndpointConfig, error) {
+	var missing []string
+	expand := func(s string) string {
+		return postmanVariable.ReplaceAllStringFunc(s, func(placeholder string) string {
+			key := postmanVariable.FindStringSubmatch(placeholder)[1]
+			if value, ok := vars[key]; ok {
+				return value
+			}
+			missing = append(missing, key)
+			return placeholder
+		})
+	}
+
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = "GET"
+	}
+	endpoint := EndpointConfig{
+		Name:   name,
+		URL:    expand(string(request.URL)),
+		Method: method,
+	}
+	for _, header := range request.Header {
+		if !header.active() {
+			continue
+		}
+		if endpoint.Headers == nil {
+			endpoint.Headers = make(map[string]string)
+		}
+		endpoint.Headers[expand(header.Key)] = expand(header.Value)
+	}
+
+	if body := request.Body; body != nil {
+		switch body.Mode {
+		case "", "none":
+		case "raw":
+			endpoint.Body = expand(body.Raw)
+		case "urlencoded":
+			form := url.Values{}
+			for _, field := range body.URLEncoded {
+				if field.active() {
+					form.Add(expand(field.Key), expand(field.Value))
+				}
+			}
+			endpoint.Body = form.Encode()
+			if !hasHeader(endpoint.Headers, "Content-Type") {
+				if endpoint.Headers == nil {
+					endpoint.Headers = make(map[string]string)
+				}
+				endpoint.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+			}
+		default:
+			return EndpointConfig{}, fmt.Errorf("unsupported body mode %q, only raw and urlencoded bodies can be imported", body.Mode)
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return EndpointConfig{}, fmt.Errorf("undefined variables %s; set them in the collection or --postman-env", strings.Join(slices.Compact(missing), ", "))
+	}
+	return endpoint, nil
+}
+
+// hasHeader reports whether headers sets name, in any case.
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"percipio.com/gopi/lib/config"
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/validate"
+)
+
+// TestFile is the object form of the config file, which adds scenarios to
+// the plain list of endpoints.
+type TestFile struct {
+	Endpoints TestConfig       `json:"endpoints"`
+	Scenarios []ScenarioConfig `json:"scenarios,omitempty"`
+}
+
+// ScenarioConfig is a weighted user journey for user load tests.
+type ScenarioConfig struct {
+	Name   string               `json:"name"`
+	Weight int                  `json:"weight,omitempty"` // Relative chance of being picked, 1 if unset
+	Steps  []ScenarioStepConfig `json:"steps"`
+}
+
+// ScenarioStepConfig is an endpoint within a scenario, with an optional
+// pause before the next step such as "2s". Without one, the --think-* flags
+// apply.
+type ScenarioStepConfig struct {
+	EndpointConfig
+	Think string `json:"think,omitempty"`
+}
+
+// ExtractConfig captures a value from a step's response for the later steps
+// of the journey to use as a ${name} placeholder, see runner.Extract. It takes
+// either a JSON path such as "$.id" or a regex, whose first group is captured
+// if it has one.
+type ExtractConfig struct {
+	Name     string `json:"name"`
+	JSONPath string `json:"jsonPath,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+}
+
+// endpointsScenario names the scenario --scenario makes of the endpoints.
+const endpointsScenario = "endpoints"
+
+// endpointScenario turns the endpoints into a scenario, so each user runs
+// them in the order they are listed.
+func endpointScenario(endpoints TestConfig) ScenarioConfig {
+	scenario := ScenarioConfig{Name: endpointsScenario, Weight: 1}
+	for _, endpoint := range endpoints {
+		scenario.Steps = append(scenario.Steps, ScenarioStepConfig{EndpointConfig: endpoint})
+	}
+	return scenario
+}
+
+// extractName matches the names a ${name} placeholder can use.
+var extractName = regexp.MustCompile(`^[\w.-]+$`)
+
+// extracts reports whether any step of the scenario extracts a value.
+func (s ScenarioConfig) extracts() bool {
+	for _, step := range s.Steps {
+		if len(step.Extract) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareScenarios checks the scenarios and resolves their step URLs against
+// --base-url or their hosts.
+func prepareScenarios(scenarios []ScenarioConfig, cfg *config.Config) error {
+	for i := range scenarios {
+		scenario := &scenarios[i]
+		if scenario.Name == "" {
+			return fmt.Errorf("scenario %d has no name", i+1)
+		}
+		if len(scenario.Steps) == 0 {
+			return fmt.Errorf("scenario %q has no steps", scenario.Name)
+		}
+		if scenario.Weight < 0 {
+			return fmt.Errorf("scenario %q weight cannot be negative", scenario.Name)
+		}
+		if scenario.Weight == 0 {
+			scenario.Weight = 1
+		}
+
+		for j := range scenario.Steps {
+			step := &scenario.Steps[j]
+			baseURL, err := resolveHosts(&step.EndpointConfig, cfg)
+			if err != nil {
+				return fmt.Errorf("scenario %q: %w", scenario.Name, err)
+			}
+			stepURL, err := resolveEndpointURL(step.URL, baseURL)
+			if err != nil {
+				return fmt.Errorf("scenario %q: %w", scenario.Name, err)
+			}
+			step.URL = stepURL
+
+			if err := validate.Check(step.rules()); err != nil {
+				return fmt.Errorf("scenario %q: invalid validation rules for %s: %w", scenario.Name, stepURL, err)
+			}
+			if err := preparePoll(&step.EndpointConfig); err != nil {
+				return fmt.Errorf("scenario %q: %w", scenario.Name, err)
+			}
+			if err := prepareExtract(&step.EndpointConfig); err != nil {
+				return fmt.Errorf("scenario %q: %w", scenario.Name, err)
+			}
+			if step.Weight != 0 {
+				return fmt.Errorf("scenario %q: weight for %s only applies to endpoints; weight the scenario instead", scenario.Name, stepURL)
+			}
+			if step.Think != "" {
+				think, err := time.ParseDuration(step.Think)
+				if err != nil || think < 0 {
+					return fmt.Errorf("scenario %q: invalid think time %q for %s", scenario.Name, step.Think, stepURL)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// prepareExtract checks the step's extract rules and converts them for the
+// runner.
+func prepareExtract(endpoint *EndpointConfig) error {
+	endpoint.extract = nil
+	for _, cfg := range endpoint.Extract {
+		if !extractName.MatchString(cfg.Name) {
+			return fmt.Errorf("extract for %s needs a name of letters, digits, '_', '.' or '-', got %q", endpoint.URL, cfg.Name)
+		}
+		if (cfg.JSONPath == "") == (cfg.Regex == "") {
+			return fmt.Errorf("extract %s for %s needs exactly one of jsonPath or regex", cfg.Name, endpoint.URL)
+		}
+
+		extract := runner.Extract{Name: cfg.Name, JSONPath: cfg.JSONPath}
+		if cfg.Regex != "" {
+			re, err := regexp.Compile(cfg.Regex)
+			if err != nil {
+				return fmt.Errorf("invalid regex for extract %s for %s: %w", cfg.Name, endpoint.URL, err)
+			}
+			extract.Regex = re
+		} else if err := validate.CheckPath(cfg.JSONPath); err != nil {
+			return fmt.Errorf("invalid jsonPath for extract %s for %s: %w", cfg.Name, endpoint.URL, err)
+		}
+		endpoint.extract = append(endpoint.extract, extract)
+	}
+	return nil
+}
+
+func addScenarios(benchRunner *runner.Runner, scenarios []ScenarioConfig) {
+	for _, scenario := range scenarios {
+		s := runner.Scenario{Name: scenario.Name, Weight: scenario.Weight}
+		for _, step := range scenario.Steps {
+			// Already validated by prepareScenarios
+			think, _ := time.ParseDuration(step.Think)
+			s.Steps = append(s.Steps, runner.ScenarioStep{
+				Task:      newTask(step.EndpointConfig),
+				ThinkTime: think,
+			})
+		}
+		benchRunner.AddScenario(s)
+	}
+}
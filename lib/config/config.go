@@ -1,32 +1,157 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"flag"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	FilePath        string
-	ThreadCount     int
-	ConnectionCount int
-	RequestCount    int
-	NoGit           bool
-	TestPerf        bool
-	TestLoadUser    bool
-	TestLoadData    bool
+	FilePath         string
+	Postman          string
+	PostmanEnv       string
+	BaseURL          string
+	ThreadCount      int
+	ConnectionCount  int
+	MaxIdleConns     int
+	RequestCount     int
+	MaxDuration      time.Duration
+	Warmup           int
+	NoGit            bool
+	MaxErrorRate     float64
+	AbortErrorRate   float64
+	FailOnDegraded   bool
+	ThresholdPct     float64
+	LatencyMetric    string
+	Baseline         string
+	BaselineWindow   int
+	GroupBy          string
+	Percentiles      string
+	HistogramBuckets string
+	ErrorLogLimit    int
+	Retries          int
+	RetryBackoff     time.Duration
+	RetryMaxBackoff  time.Duration
+	RetryJitter      string
+	RetryBudget      float64
+	RetryLatency     string
+	TestPerf         bool
+	TestLoadUser     bool
+	TestLoadData     bool
+	RecordFile       string
+	OpenMetricsOut   string
+	MetricsAddr      string
+	StatsDAddr       string
+	ReplayFile       string
+	HARFile          string
+	HARFilter        string
+	CompareBranch    string
+	MergeFiles       string
+	RawOut           string
+
+	// Connection config
+	Timeout             int // Seconds
+	ConnectTimeout      time.Duration
+	TLSHandshakeTimeout time.Duration
+	Resolve             stringList
+	NoRedirects         bool
+	HTTP2               bool
+	AcceptEncoding      string
+	InsecureSkipVerify  bool
+	CACert              string
+
+	// Auth config, applied to endpoints without their own Authorization header
+	AuthBearer string
+	AuthBasic  string
+
+	// Multi-host config
+	Hosts         string
+	HostSelection string
+
+	// Data-driven request config
+	DataFile      string
+	DataSelection string
+
+	// Network throttling config
+	ThrottleKBps int
+	AddedLatency time.Duration
+
+	// Rate limiting config
+	Rate                     float64
+	RPSTolerance             float64
+	FailIfGeneratorSaturated bool
+
+	// Fault injection config
+	FaultRate  float64
+	FaultKinds string
+
+	// Response consistency config
+	CheckConsistency bool
+
+	// Safety config
+	SafetyConfigFile string
+	ProtectedHosts   string
+	IKnowWhatImDoing bool
 
 	// User load test config
 	StartUsers   int
 	MaxUsers     int
 	StepUsers    int
 	StepDuration int
+	Ramp         string
+	RampDuration time.Duration
+	IdleTimeout  time.Duration
+	Resume       string
+	Scenario     bool
+	ThinkDist    string
+	ThinkMin     time.Duration
+	ThinkMax     time.Duration
+	ThinkMean    time.Duration
+	ThinkStdDev  time.Duration
 
 	// Data load test config
 	InitialDataSize    int
 	MaxDataSize        int
 	DataSizeMultiplier float64
 	DataStepCount      int
+
+	// Report config
+	GraphWidth   float64
+	GraphPoints  int
+	ReportTop    int
+	GraphPadding float64
+
+	ReportChanged   float64
+	LatencyWindow   time.Duration
+	ReportRetention int
+	ReportStdout    bool
+	ReportFormat    string
+	MarkdownOut     string
+	Output          string
+	JUnitOut        string
+	WebhookURL      string
+	WebhookFormat   string
+
+	ValidateTemplate bool
+	VerifyHistory    bool
+	RepairHistory    bool
+	ExportCSV        string
+
+	// Health score config
+	ScoreSLAP95           int
+	ScoreTargetRPS        float64
+	ScoreSuccessWeight    float64
+	ScoreLatencyWeight    float64
+	ScoreThroughputWeight float64
 }
 
 func ParseFlags() (*Config, error) {
@@ -34,23 +159,92 @@ func ParseFlags() (*Config, error) {
 
 	flag.StringVar(&config.FilePath, "file", "", "JSON file containing endpoints")
 	flag.StringVar(&config.FilePath, "f", "", "JSON file containing endpoints (shorthand)")
+	flag.StringVar(&config.Postman, "postman", "", "Postman collection to take the endpoints from instead of --file")
+	flag.StringVar(&config.PostmanEnv, "postman-env", "", "Postman environment file with values for the collection's {{variables}}")
+	flag.StringVar(&config.BaseURL, "base-url", "", "Base URL that relative endpoint paths are joined to")
+	flag.StringVar(&config.Hosts, "hosts", "", "Comma-separated base URLs to spread every endpoint's requests across")
+	flag.StringVar(&config.HostSelection, "host-selection", HostRoundRobin, "How requests are spread across hosts: round-robin or random")
+	flag.StringVar(&config.DataFile, "data-file", "", "CSV file whose rows fill the ${column} placeholders of each request")
+	flag.StringVar(&config.DataSelection, "data-selection", HostRoundRobin, "How requests take --data-file rows: round-robin or random")
 	flag.IntVar(&config.ThreadCount, "thread-count", 1, "Number of threads to use")
 	flag.IntVar(&config.ThreadCount, "tc", 1, "Number of threads to use (shorthand)")
-	flag.IntVar(&config.ConnectionCount, "connection-count", 1, "Number of connections to use")
-	flag.IntVar(&config.ConnectionCount, "cc", 1, "Number of connections to use (shorthand)")
+	flag.IntVar(&config.ConnectionCount, "connection-count", DefaultConnectionCount, "Maximum connections per host shared by all threads (0 for one per thread)")
+	flag.IntVar(&config.ConnectionCount, "cc", DefaultConnectionCount, "Maximum connections per host shared by all threads (shorthand)")
+	flag.IntVar(&config.ConnectionCount, "max-conns-per-host", DefaultConnectionCount, "Maximum connections per host shared by all threads (same as --connection-count)")
+	flag.IntVar(&config.MaxIdleConns, "max-idle-conns", 0, "Maximum idle connections the threads keep for reuse (0 for one per thread or per --connection-count)")
 	flag.IntVar(&config.RequestCount, "request-count", 1, "Number of requests per endpoint")
 	flag.IntVar(&config.RequestCount, "rc", 1, "Number of requests per endpoint (shorthand)")
+	flag.IntVar(&config.Warmup, "warmup", 0, "Warmup requests per endpoint sent before the measured ones and left out of the statistics")
+	flag.DurationVar(&config.MaxDuration, "max-duration", 0, "Stop a performance test after this long, reporting the requests completed so far (0 for no limit)")
 	flag.BoolVar(&config.NoGit, "no-git", false, "Use timestamp-based hashes instead of git commits")
+	flag.Float64Var(&config.MaxErrorRate, "max-error-rate", -1, "Fail the run if the overall error rate exceeds this percentage (negative to disable)")
+	flag.Float64Var(&config.AbortErrorRate, "abort-on-error-rate", -1, "Stop a performance test early once the error rate over the last second exceeds this percentage (negative to disable)")
+	flag.BoolVar(&config.FailOnDegraded, "fail-on-degradation", false, "Fail the run with exit code 2 if it degraded against the baseline")
+	flag.Float64Var(&config.Rate, "rate", 0, "Target requests per second across all workers (0 for unlimited)")
+	flag.BoolVar(&config.FailIfGeneratorSaturated, "fail-if-generator-saturated", false, "Fail the run if requests couldn't be offered at --rate")
+	flag.Float64Var(&config.ThresholdPct, "threshold", DefaultThresholdPct, "Percentage change against the baseline that counts as a degradation")
+	flag.StringVar(&config.LatencyMetric, "latency-metric", "avg", "Latency statistic compared against the baseline: avg, p50, p95 or p99")
+	flag.StringVar(&config.Baseline, "baseline", "", "Run ID of the saved run to compare against instead of the latest")
+	flag.IntVar(&config.BaselineWindow, "baseline-window", DefaultBaselineWindow, "Compare against the average of this many recent runs")
+	flag.StringVar(&config.Percentiles, "percentiles", DefaultPercentiles, "Comma-separated latency percentiles to report, such as 50,90,99,99.9")
+	flag.StringVar(&config.HistogramBuckets, "histogram-buckets", "", "Comma-separated upper bounds of the latency histogram buckets, such as 10ms,50ms,250ms,1s")
+	flag.StringVar(&config.GroupBy, "group-by", "method-url", "How requests are grouped into endpoints in stats, history and reports: method-url, url, name or path")
+	flag.BoolVar(&config.CheckConsistency, "check-consistency", false, "Hash response bodies and report endpoints whose responses vary between identical requests")
+	flag.IntVar(&config.ErrorLogLimit, "error-log-limit", DefaultErrorLogLimit, "Failures logged per endpoint before the rest are only counted (0 logs all)")
+	flag.IntVar(&config.Timeout, "timeout", DefaultTimeout, "Maximum time for each request in seconds (0 for no limit)")
+	flag.DurationVar(&config.ConnectTimeout, "connect-timeout", DefaultConnectTimeout, "Maximum time to establish a TCP connection (0 for no separate limit)")
+	flag.DurationVar(&config.TLSHandshakeTimeout, "tls-handshake-timeout", DefaultTLSHandshakeTimeout, "Maximum time for the TLS handshake (0 for no separate limit)")
+	flag.StringVar(&config.AuthBearer, "auth-bearer", "", "Send this bearer token to endpoints without their own Authorization header")
+	flag.StringVar(&config.AuthBasic, "auth-basic", "", "Send these user:pass basic auth credentials to endpoints without their own Authorization header")
+	flag.BoolVar(&config.InsecureSkipVerify, "insecure-skip-verify", false, "Accept any TLS certificate (insecure, for test environments only)")
+	flag.StringVar(&config.CACert, "ca-cert", "", "PEM file of CA certificates to trust in addition to the system ones")
+	flag.BoolVar(&config.HTTP2, "http2", false, "Negotiate HTTP/2 with HTTPS endpoints that support it")
+	flag.StringVar(&config.AcceptEncoding, "accept-encoding", "", "Comma-separated response compression to ask for: gzip, deflate; bodies are decoded within the measured latency")
+	flag.BoolVar(&config.NoRedirects, "no-redirects", false, "Record 3xx responses as they are instead of following redirects")
+	flag.Var(&config.Resolve, "resolve", "Connect to host:port at ip instead of resolving it, as host:port:ip (repeatable)")
+	flag.IntVar(&config.ThrottleKBps, "throttle-kbps", 0, "Limit each connection to this bandwidth in kilobits per second to simulate a slow client (0 for unlimited)")
+	flag.DurationVar(&config.AddedLatency, "added-latency", 0, "Add this network delay to every request to simulate a slow client (0 for none)")
+	flag.IntVar(&config.Retries, "retries", 0, "Retry network errors and 5xx responses up to this many times")
+	flag.DurationVar(&config.RetryBackoff, "retry-backoff", DefaultRetryBackoff, "Base delay before the first retry, doubled on each attempt")
+	flag.DurationVar(&config.RetryMaxBackoff, "retry-max-backoff", DefaultRetryMaxBackoff, "Maximum delay between retries")
+	flag.StringVar(&config.RetryJitter, "retry-jitter", "full", "Retry jitter strategy: none, full, equal or decorrelated")
+	flag.Float64Var(&config.RetryBudget, "retry-budget", 0, "Cap retries at this percentage of the requests sent across the run (0 for no cap)")
+	flag.StringVar(&config.RetryLatency, "retry-latency", "total", "Latency recorded for retried requests: total, covering every attempt and backoff, or final, the last attempt alone")
+	flag.Float64Var(&config.FaultRate, "fault-rate", 0, "Percentage of requests to deliberately make invalid for resilience testing")
+	flag.StringVar(&config.FaultKinds, "fault-kinds", DefaultFaultKinds, "Comma-separated faults to inject: bad-auth, oversized-body, bad-content-type")
+	flag.Float64Var(&config.RPSTolerance, "rps-tolerance", DefaultRPSTolerance, "Percentage the achieved RPS may fall below --rate before an endpoint is flagged")
 
 	flag.BoolVar(&config.TestPerf, "test-perf", false, "Run performance test")
 	flag.BoolVar(&config.TestLoadUser, "test-load-user", false, "Run user load test")
 	flag.BoolVar(&config.TestLoadData, "test-load-data", false, "Run data load test")
+	flag.StringVar(&config.ReplayFile, "replay", "", "Replay the request sequence recorded in a file")
+	flag.StringVar(&config.HARFile, "har", "", "Replay the requests captured in a HAR file")
+	flag.StringVar(&config.HARFilter, "har-filter", "", "Replay only the HAR requests to this domain and its subdomains")
+	flag.StringVar(&config.CompareBranch, "compare-branch", "", "Run the endpoints against two comma-separated base URLs and compare them")
+	flag.StringVar(&config.MergeFiles, "merge", "", "Merge comma-separated raw result files from separate runs into one report")
+	flag.StringVar(&config.RawOut, "raw-out", "", "Write every request result as newline-delimited JSON for --merge")
+	flag.StringVar(&config.RecordFile, "record", "", "Record the exact request sequence of the run to a file")
+
+	// Safety flags
+	flag.StringVar(&config.SafetyConfigFile, "safety-config", DefaultSafetyConfigFile, "JSON file listing protected hosts and their limits")
+	flag.StringVar(&config.ProtectedHosts, "protected-hosts", "", "Comma-separated host patterns to protect in addition to the safety config")
+	flag.BoolVar(&config.IKnowWhatImDoing, "i-know-what-im-doing", false, "Confirm running against protected hosts")
 
 	// User load test flags
 	flag.IntVar(&config.StartUsers, "start-users", 2, "Initial number of concurrent users")
 	flag.IntVar(&config.MaxUsers, "max-users", 50, "Maximum number of concurrent users")
 	flag.IntVar(&config.StepUsers, "step-users", 5, "Number of users to add per step")
 	flag.IntVar(&config.StepDuration, "step-duration", 60, "Duration of each step in seconds")
+	flag.StringVar(&config.Ramp, "ramp", RampStep, "How users are added: step or linear")
+	flag.DurationVar(&config.RampDuration, "ramp-duration", 0, "Time a linear ramp takes to go from --start-users to --max-users")
+	flag.DurationVar(&config.IdleTimeout, "idle-timeout", 0, "Abort the load test after this long without a successful response (0 to disable)")
+	flag.StringVar(&config.Resume, "resume", "", "Resume an interrupted load test from its checkpoint by run ID")
+	flag.BoolVar(&config.Scenario, "scenario", false, "Have each user run the endpoints in order as one journey")
+	flag.StringVar(&config.ThinkDist, "think-dist", "uniform", "Think time distribution: constant, uniform, exponential or normal")
+	flag.DurationVar(&config.ThinkMin, "think-min", 100*time.Millisecond, "Minimum think time for the uniform distribution")
+	flag.DurationVar(&config.ThinkMax, "think-max", time.Second, "Maximum think time for the uniform distribution")
+	flag.DurationVar(&config.ThinkMean, "think-mean", 500*time.Millisecond, "Think time for constant, mean for exponential and normal")
+	flag.DurationVar(&config.ThinkStdDev, "think-stddev", 100*time.Millisecond, "Think time standard deviation for the normal distribution")
 
 	// Data load test flags
 	flag.IntVar(&config.InitialDataSize, "initial-data", 1000, "Initial data size")
@@ -58,6 +252,36 @@ func ParseFlags() (*Config, error) {
 	flag.Float64Var(&config.DataSizeMultiplier, "data-multiplier", 5.0, "Data size multiplier per step")
 	flag.IntVar(&config.DataStepCount, "data-steps", 4, "Number of data load steps")
 
+	// Report flags
+	flag.Float64Var(&config.GraphWidth, "graph-width", DefaultGraphWidth, "Width of the report graphs in pixels")
+	flag.IntVar(&config.GraphPoints, "graph-points", DefaultGraphPoints, "Number of latest points shown by default in the report (0 for all)")
+	flag.Float64Var(&config.GraphPadding, "graph-padding", DefaultGraphPadding, "Padding around the report graphs in pixels")
+	flag.IntVar(&config.ReportTop, "report-top", 0, "Graph only the N endpoints with the worst latency or regression (0 for all)")
+	flag.Float64Var(&config.ReportChanged, "report-changed", 0, "Graph only endpoints that changed by more than this percentage against the baseline (0 for all)")
+	flag.DurationVar(&config.LatencyWindow, "latency-window", DefaultLatencyWindow, "Window size for latency percentiles over time (0 to disable)")
+	flag.StringVar(&config.MarkdownOut, "markdown", "", "Write per-endpoint stats and baseline changes as a Markdown table to this file, or - for stdout")
+	flag.StringVar(&config.Output, "output", OutputText, "Result output on stdout: text for the summary or json for the statistics as JSON")
+	flag.StringVar(&config.JUnitOut, "junit", "", "Write a JUnit XML report with a test case per endpoint to this file")
+	flag.StringVar(&config.WebhookURL, "webhook-url", "", "POST a summary of the degraded endpoints to this URL when the run degrades")
+	flag.StringVar(&config.WebhookFormat, "webhook-format", WebhookJSON, "Webhook message format: json or slack")
+	flag.BoolVar(&config.ReportStdout, "report-stdout", false, "Write the report to stdout instead of the report directory")
+	flag.StringVar(&config.ReportFormat, "report-format", ReportFormatHTML, "Report format: html or json")
+	flag.StringVar(&config.OpenMetricsOut, "openmetrics-out", "", "Write final metrics in OpenMetrics text format to this file")
+	flag.StringVar(&config.MetricsAddr, "metrics-addr", "", "Serve live Prometheus metrics on this address, such as :9090, while the test runs")
+	flag.StringVar(&config.StatsDAddr, "statsd-addr", "", "Send request metrics to the StatsD or Datadog agent at this host:port as the test runs")
+	flag.BoolVar(&config.ValidateTemplate, "validate-template", false, "Check that the HTML report template renders, without running a test")
+	flag.BoolVar(&config.VerifyHistory, "verify-history", false, "Check that the history summary matches the run files, without running a test")
+	flag.BoolVar(&config.RepairHistory, "repair-history", false, "With --verify-history, rebuild the summary from the run files if they disagree")
+	flag.StringVar(&config.ExportCSV, "export-csv", "", "Write the endpoint history as CSV to this file, or - for stdout, without running a test")
+	flag.IntVar(&config.ReportRetention, "report-retention", 0, "Keep only the N most recent reports (0 keeps all)")
+
+	// Health score flags
+	flag.IntVar(&config.ScoreSLAP95, "score-sla-p95", DefaultScoreSLAP95, "P95 latency SLA in milliseconds used by the health score")
+	flag.Float64Var(&config.ScoreTargetRPS, "score-target-rps", 0, "Target requests/sec used by the health score (0 to ignore throughput)")
+	flag.Float64Var(&config.ScoreSuccessWeight, "score-weight-success", DefaultScoreSuccessWeight, "Weight of the success rate in the health score")
+	flag.Float64Var(&config.ScoreLatencyWeight, "score-weight-latency", DefaultScoreLatencyWeight, "Weight of P95 latency in the health score")
+	flag.Float64Var(&config.ScoreThroughputWeight, "score-weight-throughput", DefaultScoreThroughputWeight, "Weight of throughput in the health score")
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: api-perf-tester [options] --test-mode
 
@@ -65,22 +289,115 @@ Required Test Mode (choose one):
   --test-perf           Run standard performance test
   --test-load-user      Run user connection load test
   --test-load-data      Run data volume load test
+  --replay <path>       Replay a request sequence saved with --record
+  --har <path>          Replay the requests of a HAR capture, such as from a browser
+  --compare-branch <a>,<b>
+                        Run the endpoints against two deployments side by side
+  --merge <a>,<b>,...   Merge --raw-out files from separate runs into one report
 
 Note: For CI/CD, run test modes sequentially in separate steps.
 See examples/workflows/performance.yml for reference.
 
 Options:
   -f, --file <path>            JSON file containing endpoints
+  --postman <path>             Postman collection to take the endpoints from instead
+  --postman-env <path>         Postman environment with values for {{variables}}
+  --base-url <url>             Base URL that relative endpoint paths are joined to
+  --hosts <a>,<b>,...          Spread every endpoint's requests across these base URLs
+  --host-selection <name>      How requests are spread across hosts: round-robin
+                               or random (default: round-robin)
+  --data-file <path>           CSV whose rows fill the ${column} placeholders of requests
+  --data-selection <name>      How requests take data rows: round-robin or random
+                               (default: round-robin)
   -tc, --thread-count <num>    Number of threads to use (default: 1)
-  -cc, --connection-count <num> Number of connections to use (default: 1)
+  -cc, --connection-count <num> Maximum connections per host shared by all threads;
+                               requests queue for a free one (default: 0, one per thread)
+  --max-conns-per-host <num>   Same as --connection-count
+  --max-idle-conns <num>       Maximum idle connections kept for reuse, independent of
+                               the thread count (default: 0, one per thread or
+                               connection)
   -rc, --request-count <num>    Number of requests per endpoint (default: 1)
+  --warmup <num>               Warmup requests per endpoint, sent first and left out of
+                               the statistics (default: 0)
+  --max-duration <duration>    Stop a --test-perf run after this long and report the
+                               requests completed so far (default: 0, no limit)
   --no-git                     Use timestamp-based hashes instead of git commits
+  --max-error-rate <pct>       Fail the run if the overall error rate exceeds this (default: disabled)
+  --abort-on-error-rate <pct>  Stop a --test-perf run early, failing it, once the error rate
+                               over the last second exceeds this (default: disabled)
+  --fail-on-degradation        Exit with code 2 if the run degraded against the baseline
+  --threshold <pct>            Change vs the baseline that counts as a degradation (default: 10)
+  --latency-metric <name>      Latency compared vs the baseline: avg, p50, p95 or p99 (default: avg)
+  --baseline <run-id>          Compare against this saved run instead of the latest
+  --baseline-window <num>      Compare against the average of the last N runs (default: 1)
+  --percentiles <list>         Latency percentiles to report (default: 50,95,99)
+  --histogram-buckets <list>   Latency histogram bucket bounds, such as 10ms,50ms,1s
+                               (default: 1ms to 10s in 1-2-5 steps)
+  --group-by <name>            Group requests into endpoints by method-url, url, name
+                               or path (default: method-url)
+  --rate <rps>                 Target requests per second across all workers (default: 0, unlimited)
+  --rps-tolerance <pct>        Shortfall from --rate before an endpoint is flagged (default: 10)
+  --fail-if-generator-saturated
+                               Fail the run if requests couldn't be offered at --rate
+  --check-consistency          Report endpoints whose body or status varies across
+                               identical requests
+  --error-log-limit <num>      Failures logged per endpoint, 0 logs all (default: 10)
+  --timeout <seconds>          Maximum time for each request (default: 30, 0 for no limit)
+  --connect-timeout <duration> Maximum time to establish a connection (default: 10s)
+  --tls-handshake-timeout <duration>
+                               Maximum time for the TLS handshake (default: 10s)
+  --auth-bearer <token>        Bearer token for endpoints without an Authorization header
+  --auth-basic <user:pass>     Basic auth credentials for endpoints without an
+                               Authorization header
+  --http2                      Negotiate HTTP/2 with HTTPS endpoints that support it
+  --accept-encoding <list>     Ask for compressed responses (gzip, deflate) and decode
+                               them within the measured latency, reporting body sizes
+  --no-redirects               Measure 3xx responses instead of following redirects
+  --insecure-skip-verify       Accept any TLS certificate (insecure, test environments only)
+  --ca-cert <path>             Trust the CA certificates in this PEM file as well
+  --resolve <host:port:ip>     Connect to host:port at ip, keeping the Host header
+                               and TLS server name (repeatable)
+  --throttle-kbps <kbps>       Simulate a slow client link per connection (default: 0, unlimited)
+  --added-latency <duration>   Simulate client network delay per request (default: 0)
+  --retries <num>              Retry network errors and 5xx responses (default: 0)
+  --retry-backoff <duration>   Base delay before the first retry (default: 100ms)
+  --retry-max-backoff <duration>
+                               Maximum delay between retries (default: 5s)
+  --retry-jitter <name>        Jitter strategy: none, full, equal or decorrelated (default: full)
+  --retry-budget <pct>         Cap retries at this percentage of requests sent (default: 0, no cap)
+  --retry-latency <name>       Latency of retried requests: total or final attempt (default: total)
+  --fault-rate <pct>           Percentage of requests to make invalid on purpose (default: 0)
+  --fault-kinds <list>         Faults to inject: bad-auth, oversized-body,
+                               bad-content-type (default: all)
+  --record <path>              Record the exact request sequence to a replay file
+  --har-filter <domain>        With --har, replay only requests to this domain and its subdomains
+  --raw-out <path>             Write every request result as NDJSON for --merge
+
+Safety Options:
+  --safety-config <path>       Protected hosts config (default: gopi-safety.json)
+  --protected-hosts <list>     Extra comma-separated protected host patterns
+  --i-know-what-im-doing       Confirm running against protected hosts
 
 User Load Test Options:
   --start-users <num>          Initial number of concurrent users (default: 2)
   --max-users <num>            Maximum number of concurrent users (default: 50)
   --step-users <num>           Users to add per step (default: 5)
   --step-duration <seconds>    Duration of each step (default: 60)
+  --ramp <mode>                How users are added: step, in blocks of --step-users,
+                               or linear, one at a time (default: step)
+  --ramp-duration <duration>   Time a linear ramp takes to reach --max-users
+  --idle-timeout <duration>    Abort when no request succeeds for this long (default: 0, disabled)
+  --resume <run-id>            Continue an interrupted user or data load test after
+                               its last completed step
+  --scenario                   Have each user run the endpoints in order, as one
+                               journey, instead of picking them at random
+  --think-dist <name>          Think time distribution: constant, uniform,
+                               exponential or normal (default: uniform)
+  --think-min <duration>       Uniform minimum think time (default: 100ms)
+  --think-max <duration>       Uniform maximum think time (default: 1s), 0 with
+                               --think-min 0 for no pause between requests
+  --think-mean <duration>      Constant think time, or exponential/normal mean (default: 500ms)
+  --think-stddev <duration>    Normal think time standard deviation (default: 100ms)
 
 Data Load Test Options:
   --initial-data <num>         Initial data size (default: 1000)
@@ -88,25 +405,118 @@ Data Load Test Options:
   --data-multiplier <float>    Data size multiplier per step (default: 5.0)
   --data-steps <num>          Number of data load steps (default: 4)
 
+Report Options:
+  --graph-width <px>           Width of the report graphs (default: 1000)
+  --graph-points <num>         Latest points shown by default, 0 for all (default: 20)
+  --graph-padding <px>         Padding around the report graphs (default: 50)
+  --report-top <num>           Graph only the N worst endpoints, 0 for all (default: 0)
+  --report-changed <pct>       Graph only endpoints that moved more than this vs the baseline (default: 0, all)
+  --latency-window <duration>  Window size for latency percentiles over time, 0 to disable (default: 10s)
+  --report-retention <num>     Keep only the N most recent reports (default: 0, keep all)
+  --report-stdout              Write the report to stdout instead of performance-reports/
+  --report-format <fmt>        Report format: html or json (default: html)
+  --openmetrics-out <path>     Write final metrics as an OpenMetrics file for a Pushgateway
+  --metrics-addr <addr>        Serve live Prometheus metrics on /metrics at this address,
+                               such as :9090, until the test finishes
+  --statsd-addr <host:port>    Send each request's latency and outcome to a StatsD or
+                               Datadog agent as the test runs
+  --markdown <path>            Write a Markdown results table for PR comments, - for stdout
+  --output <fmt>               Results on stdout: text or json, for scripts (default: text)
+  --junit <path>               Write a JUnit XML report, failing degraded or over-budget endpoints
+  --webhook-url <url>          POST a summary of the degraded endpoints to this URL when
+                               a --test-perf run degrades against its baseline
+  --webhook-format <fmt>       Webhook message format: json or slack (default: json)
+  --validate-template          Check the HTML report template renders, then exit
+  --verify-history             Check the history summary matches the run files, then exit
+  --repair-history             With --verify-history, rebuild the summary from the run files
+  --export-csv <path>          Write the endpoint history as CSV, - for stdout, then exit
+
+Health Score Options:
+  --score-sla-p95 <ms>         P95 latency SLA (default: 500)
+  --score-target-rps <num>     Target requests/sec, 0 to ignore (default: 0)
+  --score-weight-success <w>   Weight of the success rate (default: 0.5)
+  --score-weight-latency <w>   Weight of P95 latency vs SLA (default: 0.3)
+  --score-weight-throughput <w> Weight of throughput vs target (default: 0.2)
+
 Examples:
   api-perf-tester -f endpoints.json --test-perf
   api-perf-tester -f endpoints.json --test-load-user --start-users 5 --max-users 100
   api-perf-tester -f endpoints.json --test-load-data --initial-data 5000 --data-steps 6
+  api-perf-tester -f endpoints.json --test-perf --record run.replay.json
+  api-perf-tester --replay run.replay.json
+  api-perf-tester -f endpoints.json --compare-branch https://old.example.com,https://new.example.com
 `)
 	}
 
 	flag.Parse()
 
-	if config.FilePath == "" {
-		return nil, fmt.Errorf("--file or -f flag is required")
+	if config.ValidateTemplate {
+		// Needs no endpoints or test mode
+		return config, nil
 	}
+	if config.RepairHistory && !config.VerifyHistory {
+		return nil, fmt.Errorf("--repair-history requires --verify-history")
+	}
+	if config.VerifyHistory || config.ExportCSV != "" {
+		// Only looks at the history directory
+		return config, nil
+	}
+
+	if config.HARFilter != "" && config.HARFile == "" {
+		return nil, fmt.Errorf("--har-filter requires --har")
+	}
+
+	if config.ReplayFile != "" {
+		if _, err := os.Stat(config.ReplayFile); os.IsNotExist(err) {
+			return nil, fmt.Errorf("replay file %s does not exist", config.ReplayFile)
+		}
+	} else if config.HARFile != "" {
+		if _, err := os.Stat(config.HARFile); os.IsNotExist(err) {
+			return nil, fmt.Errorf("HAR file %s does not exist", config.HARFile)
+		}
+	} else if config.MergeFiles != "" {
+		for _, path := range config.MergePaths() {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				return nil, fmt.Errorf("merge file %s does not exist", path)
+			}
+		}
+	} else if config.Postman != "" {
+		if config.FilePath != "" {
+			return nil, fmt.Errorf("--postman cannot be combined with --file")
+		}
+		for _, path := range []string{config.Postman, config.PostmanEnv} {
+			if _, err := os.Stat(path); path != "" && os.IsNotExist(err) {
+				return nil, fmt.Errorf("file %s does not exist", path)
+			}
+		}
+	} else {
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("--file or -f flag is required")
+		}
 
-	if _, err := os.Stat(config.FilePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file %s does not exist", config.FilePath)
+		if _, err := os.Stat(config.FilePath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %s does not exist", config.FilePath)
+		}
+	}
+	if config.PostmanEnv != "" && config.Postman == "" {
+		return nil, fmt.Errorf("--postman-env requires --postman")
 	}
 
-	if !config.TestPerf && !config.TestLoadUser && !config.TestLoadData {
-		return nil, fmt.Errorf("one test mode flag is required (--test-perf, --test-load-user, or --test-load-data)")
+	if config.BaseURL != "" {
+		if base, err := url.Parse(config.BaseURL); err != nil || base.Scheme == "" || base.Host == "" {
+			return nil, fmt.Errorf("--base-url must be an absolute URL like https://api.example.com")
+		}
+	}
+
+	if !config.TestPerf && !config.TestLoadUser && !config.TestLoadData && config.ReplayFile == "" && config.HARFile == "" && config.CompareBranch == "" && config.MergeFiles == "" {
+		return nil, fmt.Errorf("one test mode flag is required (--test-perf, --test-load-user, --test-load-data, --replay, --har, --compare-branch, or --merge)")
+	}
+
+	if config.MetricsAddr != "" && config.MergeFiles != "" {
+		return nil, fmt.Errorf("--metrics-addr needs a test that sends requests, not --merge")
+	}
+	if config.StatsDAddr != "" && config.MergeFiles != "" {
+		return nil, fmt.Errorf("--statsd-addr needs a test that sends requests, not --merge")
 	}
 
 	// Ensure only one test mode is selected
@@ -120,9 +530,481 @@ Examples:
 	if config.TestLoadData {
 		count++
 	}
+	if config.ReplayFile != "" {
+		count++
+	}
+	if config.HARFile != "" {
+		count++
+	}
+	if config.CompareBranch != "" {
+		count++
+	}
+	if config.MergeFiles != "" {
+		count++
+	}
 	if count > 1 {
 		return nil, fmt.Errorf("only one test mode can be selected at a time")
 	}
 
+	for _, host := range config.HostList() {
+		if base, err := url.Parse(host); err != nil || base.Scheme == "" || base.Host == "" {
+			return nil, fmt.Errorf("--hosts entry %q must be an absolute URL like https://api.example.com", host)
+		}
+	}
+	if config.HostSelection != HostRoundRobin && config.HostSelection != HostRandom {
+		return nil, fmt.Errorf("--host-selection must be round-robin or random")
+	}
+	if config.DataSelection != HostRoundRobin && config.DataSelection != HostRandom {
+		return nil, fmt.Errorf("--data-selection must be round-robin or random")
+	}
+	if config.DataFile != "" {
+		if _, err := os.Stat(config.DataFile); os.IsNotExist(err) {
+			return nil, fmt.Errorf("data file %s does not exist", config.DataFile)
+		}
+	}
+	if config.Hosts != "" && config.CompareBranch != "" {
+		return nil, fmt.Errorf("--hosts cannot be combined with --compare-branch")
+	}
+
+	if config.CompareBranch != "" && len(config.CompareBases()) != 2 {
+		return nil, fmt.Errorf("--compare-branch requires exactly two comma-separated base URLs")
+	}
+
+	if config.MergeFiles != "" && len(config.MergePaths()) == 0 {
+		return nil, fmt.Errorf("--merge requires at least one raw result file")
+	}
+
+	switch config.ThinkDist {
+	case "constant", "uniform", "exponential", "normal":
+	default:
+		return nil, fmt.Errorf("--think-dist must be constant, uniform, exponential or normal")
+	}
+
+	if config.ThinkMin < 0 || config.ThinkMax < config.ThinkMin {
+		return nil, fmt.Errorf("--think-min must be non-negative and not greater than --think-max")
+	}
+
+	if config.ThinkMean < 0 || config.ThinkStdDev < 0 {
+		return nil, fmt.Errorf("--think-mean and --think-stddev cannot be negative")
+	}
+
+	if config.Resume != "" && !config.TestLoadUser && !config.TestLoadData {
+		return nil, fmt.Errorf("--resume requires --test-load-user or --test-load-data")
+	}
+
+	if config.Warmup < 0 {
+		return nil, fmt.Errorf("--warmup cannot be negative")
+	}
+	if config.Warmup > 0 && !config.TestPerf && !config.TestLoadData && config.CompareBranch == "" {
+		return nil, fmt.Errorf("--warmup requires --test-perf, --test-load-data or --compare-branch")
+	}
+
+	if config.MaxDuration < 0 {
+		return nil, fmt.Errorf("--max-duration cannot be negative")
+	}
+	if config.MaxDuration > 0 && !config.TestPerf {
+		return nil, fmt.Errorf("--max-duration requires --test-perf")
+	}
+	if config.AbortErrorRate > 100 {
+		return nil, fmt.Errorf("--abort-on-error-rate cannot exceed 100")
+	}
+	if config.AbortErrorRate >= 0 && !config.TestPerf {
+		return nil, fmt.Errorf("--abort-on-error-rate requires --test-perf")
+	}
+
+	if config.WebhookFormat != WebhookJSON && config.WebhookFormat != WebhookSlack {
+		return nil, fmt.Errorf("--webhook-format must be %s or %s", WebhookJSON, WebhookSlack)
+	}
+	if config.WebhookURL != "" {
+		if hook, err := url.Parse(config.WebhookURL); err != nil || hook.Scheme == "" || hook.Host == "" {
+			return nil, fmt.Errorf("--webhook-url must be an absolute URL like https://hooks.slack.com/services/...")
+		}
+	}
+	if config.Ramp != RampStep && config.Ramp != RampLinear {
+		return nil, fmt.Errorf("--ramp must be %s or %s", RampStep, RampLinear)
+	}
+	if config.Ramp == RampLinear {
+		if !config.TestLoadUser {
+			return nil, fmt.Errorf("--ramp %s requires --test-load-user", RampLinear)
+		}
+		if config.RampDuration <= 0 {
+			return nil, fmt.Errorf("--ramp %s requires a positive --ramp-duration", RampLinear)
+		}
+		if config.MaxUsers < config.StartUsers {
+			return nil, fmt.Errorf("--max-users cannot be less than --start-users")
+		}
+		if config.Resume != "" {
+			return nil, fmt.Errorf("--resume cannot be used with --ramp %s, which has no steps to resume", RampLinear)
+		}
+	} else if config.RampDuration != 0 {
+		return nil, fmt.Errorf("--ramp-duration requires --ramp %s", RampLinear)
+	}
+
+	if config.Scenario && !config.TestLoadUser {
+		return nil, fmt.Errorf("--scenario requires --test-load-user")
+	}
+
+	if strings.ContainsAny(config.Resume, `/\`) {
+		return nil, fmt.Errorf("--resume takes a run ID, not a path")
+	}
+
+	if config.ConnectionCount < 0 {
+		return nil, fmt.Errorf("--connection-count cannot be negative")
+	}
+	if config.MaxIdleConns < 0 {
+		return nil, fmt.Errorf("--max-idle-conns cannot be negative")
+	}
+
+	if config.IdleTimeout < 0 {
+		return nil, fmt.Errorf("--idle-timeout cannot be negative")
+	}
+
+	if config.Rate < 0 {
+		return nil, fmt.Errorf("--rate cannot be negative")
+	}
+
+	if config.RPSTolerance < 0 || config.RPSTolerance > 100 {
+		return nil, fmt.Errorf("--rps-tolerance must be between 0 and 100")
+	}
+
+	if config.AuthBearer != "" && config.AuthBasic != "" {
+		return nil, fmt.Errorf("--auth-bearer and --auth-basic cannot be used together")
+	}
+	if config.AuthBasic != "" && !strings.Contains(config.AuthBasic, ":") {
+		return nil, fmt.Errorf("--auth-basic must be user:pass")
+	}
+
+	if config.Timeout < 0 {
+		return nil, fmt.Errorf("--timeout cannot be negative")
+	}
+	if config.ConnectTimeout < 0 || config.TLSHandshakeTimeout < 0 {
+		return nil, fmt.Errorf("--connect-timeout and --tls-handshake-timeout cannot be negative")
+	}
+
+	if config.ThrottleKBps < 0 || config.AddedLatency < 0 {
+		return nil, fmt.Errorf("--throttle-kbps and --added-latency cannot be negative")
+	}
+
+	if _, err := config.TLSConfig(); err != nil {
+		return nil, err
+	}
+
+	if _, err := config.ResolveOverrides(); err != nil {
+		return nil, err
+	}
+
+	if config.Retries < 0 {
+		return nil, fmt.Errorf("--retries cannot be negative")
+	}
+
+	if config.RetryBackoff < 0 || config.RetryMaxBackoff < 0 {
+		return nil, fmt.Errorf("--retry-backoff and --retry-max-backoff cannot be negative")
+	}
+
+	if config.RetryBudget < 0 {
+		return nil, fmt.Errorf("--retry-budget cannot be negative")
+	}
+
+	switch config.RetryJitter {
+	case "none", "full", "equal", "decorrelated":
+	default:
+		return nil, fmt.Errorf("--retry-jitter must be none, full, equal or decorrelated")
+	}
+
+	if config.RetryLatency != "total" && config.RetryLatency != "final" {
+		return nil, fmt.Errorf("--retry-latency must be total or final")
+	}
+
+	if config.FaultRate < 0 || config.FaultRate > 100 {
+		return nil, fmt.Errorf("--fault-rate must be between 0 and 100")
+	}
+
+	if config.FaultRate > 0 && len(config.FaultKindList()) == 0 {
+		return nil, fmt.Errorf("--fault-kinds must list at least one fault")
+	}
+
+	for _, kind := range config.FaultKindList() {
+		switch kind {
+		case "bad-auth", "oversized-body", "bad-content-type":
+		default:
+			return nil, fmt.Errorf("unknown fault %q in --fault-kinds", kind)
+		}
+	}
+
+	for _, encoding := range config.AcceptEncodingList() {
+		switch encoding {
+		case "gzip", "deflate":
+		default:
+			return nil, fmt.Errorf("unknown encoding %q in --accept-encoding, expected gzip or deflate", encoding)
+		}
+	}
+
+	if config.ErrorLogLimit < 0 {
+		return nil, fmt.Errorf("--error-log-limit cannot be negative")
+	}
+
+	if config.GraphWidth <= 0 {
+		return nil, fmt.Errorf("--graph-width must be greater than 0")
+	}
+
+	if config.GraphPoints < 0 {
+		return nil, fmt.Errorf("--graph-points cannot be negative")
+	}
+
+	if config.GraphPadding <= 0 {
+		return nil, fmt.Errorf("--graph-padding must be greater than 0")
+	}
+
+	if config.ReportTop < 0 {
+		return nil, fmt.Errorf("--report-top cannot be negative")
+	}
+
+	if config.ThresholdPct < 0 {
+		return nil, fmt.Errorf("--threshold cannot be negative")
+	}
+
+	if config.BaselineWindow < 1 {
+		return nil, fmt.Errorf("--baseline-window must be at least 1")
+	}
+	if config.BaselineWindow > 1 && config.Baseline != "" {
+		return nil, fmt.Errorf("--baseline and --baseline-window are mutually exclusive")
+	}
+
+	switch config.LatencyMetric {
+	case "avg", "p50", "p95", "p99":
+	default:
+		return nil, fmt.Errorf("--latency-metric must be avg, p50, p95 or p99")
+	}
+
+	if _, err := config.PercentileList(); err != nil {
+		return nil, err
+	}
+
+	if _, err := config.HistogramBounds(); err != nil {
+		return nil, err
+	}
+
+	switch config.GroupBy {
+	case "method-url", "url", "name", "path":
+	default:
+		return nil, fmt.Errorf("--group-by must be method-url, url, name or path")
+	}
+
+	if config.ReportChanged < 0 {
+		return nil, fmt.Errorf("--report-changed cannot be negative")
+	}
+
+	if config.LatencyWindow < 0 {
+		return nil, fmt.Errorf("--latency-window cannot be negative")
+	}
+
+	if config.ReportRetention < 0 {
+		return nil, fmt.Errorf("--report-retention cannot be negative")
+	}
+
+	if config.ReportFormat != ReportFormatHTML && config.ReportFormat != ReportFormatJSON {
+		return nil, fmt.Errorf("--report-format must be %s or %s", ReportFormatHTML, ReportFormatJSON)
+	}
+
+	if config.Output != OutputText && config.Output != OutputJSON {
+		return nil, fmt.Errorf("--output must be %s or %s", OutputText, OutputJSON)
+	}
+	if config.Output == OutputJSON && (config.ReportStdout || config.MarkdownOut == "-") {
+		return nil, fmt.Errorf("--output json cannot be combined with --report-stdout or --markdown -")
+	}
+
+	if config.ReportStdout && config.MarkdownOut == "-" {
+		return nil, fmt.Errorf("--report-stdout cannot be combined with --markdown -")
+	}
+
+	if config.ReportStdout && config.ReportRetention > 0 {
+		return nil, fmt.Errorf("--report-stdout cannot be combined with --report-retention")
+	}
+
+	if config.ScoreSuccessWeight < 0 || config.ScoreLatencyWeight < 0 || config.ScoreThroughputWeight < 0 {
+		return nil, fmt.Errorf("health score weights cannot be negative")
+	}
+
 	return config, nil
 }
+
+// StdoutReserved reports whether stdout carries a report, in which case logs
+// and the summary move to stderr.
+func (c *Config) StdoutReserved() bool {
+	return c.ReportStdout || c.MarkdownOut == "-" || c.ExportCSV == "-" || c.Output == OutputJSON
+}
+
+// MergePaths returns the raw result files passed to --merge.
+func (c *Config) MergePaths() []string {
+	var paths []string
+	for _, path := range strings.Split(c.MergeFiles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// FaultKindList returns the faults passed to --fault-kinds.
+func (c *Config) FaultKindList() []string {
+	var kinds []string
+	for _, kind := range strings.Split(c.FaultKinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// AcceptEncodingList returns the encodings passed to --accept-encoding.
+func (c *Config) AcceptEncodingList() []string {
+	var encodings []string
+	for _, encoding := range strings.Split(c.AcceptEncoding, ",") {
+		if encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding != "" {
+			encodings = append(encodings, encoding)
+		}
+	}
+	return encodings
+}
+
+// TLSConfig returns the TLS client config for --insecure-skip-verify and
+// --ca-cert, or nil to use the defaults.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if !c.InsecureSkipVerify && c.CACert == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CACert != "" {
+		pem, err := os.ReadFile(c.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("--ca-cert %s contains no PEM certificates", c.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// ResolveOverrides returns the --resolve entries as a map from lowercase
+// host:port to the ip:port to connect to instead.
+func (c *Config) ResolveOverrides() (map[string]string, error) {
+	if len(c.Resolve) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]string, len(c.Resolve))
+	for _, entry := range c.Resolve {
+		host, rest, _ := strings.Cut(entry, ":")
+		port, ip, _ := strings.Cut(rest, ":")
+		ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+		if host == "" || port == "" || net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("--resolve %q must be host:port:ip", entry)
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return nil, fmt.Errorf("--resolve %q has an invalid port", entry)
+		}
+		overrides[strings.ToLower(net.JoinHostPort(host, port))] = net.JoinHostPort(ip, port)
+	}
+	return overrides, nil
+}
+
+// Authorization returns the Authorization header value for --auth-bearer or
+// --auth-basic, or "" if neither was given. Basic credentials are encoded as
+// described in RFC 7617.
+func (c *Config) Authorization() string {
+	switch {
+	case c.AuthBearer != "":
+		return "Bearer " + c.AuthBearer
+	case c.AuthBasic != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.AuthBasic))
+	}
+	return ""
+}
+
+// PercentileList returns the --percentiles in ascending order, without
+// duplicates.
+func (c *Config) PercentileList() ([]float64, error) {
+	var percentiles []float64
+	seen := make(map[float64]bool)
+	for _, field := range strings.Split(c.Percentiles, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(field, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("--percentiles %q must be numbers above 0 and up to 100", field)
+		}
+		if !seen[p] {
+			seen[p] = true
+			percentiles = append(percentiles, p)
+		}
+	}
+	sort.Float64s(percentiles)
+	return percentiles, nil
+}
+
+// HistogramBounds returns the --histogram-buckets in ascending order, without
+// duplicates, or nil for the default buckets.
+func (c *Config) HistogramBounds() ([]time.Duration, error) {
+	var bounds []time.Duration
+	seen := make(map[time.Duration]bool)
+	for _, field := range strings.Split(c.HistogramBuckets, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("--histogram-buckets %q must be durations above 0, such as 250ms", field)
+		}
+		if !seen[d] {
+			seen[d] = true
+			bounds = append(bounds, d)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+	return bounds, nil
+}
+
+// HostList returns the base URLs passed to --hosts.
+func (c *Config) HostList() []string {
+	var hosts []string
+	for _, host := range strings.Split(c.Hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// CompareBases returns the base URLs passed to --compare-branch.
+func (c *Config) CompareBases() []string {
+	var bases []string
+	for _, base := range strings.Split(c.CompareBranch, ",") {
+		if base = strings.TrimSpace(base); base != "" {
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
+
+// stringList is a flag that can be given more than once, collecting every
+// value.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
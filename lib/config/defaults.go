@@ -1,12 +1,53 @@
 package config
 
+import "time"
+
 const (
 	DefaultThreadCount     = 1
-	DefaultConnectionCount = 1
+	DefaultConnectionCount = 0
 	DefaultRequestCount    = 1
 	DefaultThresholdPct    = 10.0
+	DefaultBaselineWindow  = 1
 	DefaultHistoryDir      = "test-history"
 	DefaultReportDir       = "performance-reports"
+	DefaultGraphWidth      = 1000.0
+	DefaultGraphPoints     = 20
+	DefaultGraphPadding    = 50.0
+	DefaultPercentiles     = "50,95,99"
+	DefaultRPSTolerance    = 10.0
+	DefaultErrorLogLimit   = 10
+	DefaultRetryBackoff    = 100 * time.Millisecond
+	DefaultRetryMaxBackoff = 5 * time.Second
+	DefaultLatencyWindow   = 10 * time.Second
+	DefaultFaultKinds      = "bad-auth,oversized-body,bad-content-type"
+
+	DefaultTimeout             = 30 // Seconds
+	DefaultConnectTimeout      = 10 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+
+	ReportFormatHTML = "html"
+	ReportFormatJSON = "json"
+
+	OutputText = "text"
+	OutputJSON = "json"
+
+	HostRoundRobin = "round-robin"
+	HostRandom     = "random"
+
+	RampStep   = "step"
+	RampLinear = "linear"
+
+	WebhookJSON  = "json"
+	WebhookSlack = "slack"
+
+	DefaultScoreSLAP95           = 500
+	DefaultScoreSuccessWeight    = 0.5
+	DefaultScoreLatencyWeight    = 0.3
+	DefaultScoreThroughputWeight = 0.2
+
+	DefaultSafetyConfigFile     = "gopi-safety.json"
+	DefaultSafetyMaxConcurrency = 5
+	DefaultSafetyMaxRequests    = 1000
 )
 
 type Defaults struct {
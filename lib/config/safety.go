@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// SafetyConfig guards hosts that should not receive accidental load, such as
+// production. Any endpoint whose host matches a protected pattern requires an
+// explicit confirmation and runs with capped concurrency and request totals.
+type SafetyConfig struct {
+	ProtectedHosts []string `json:"protectedHosts"`
+	MaxConcurrency int      `json:"maxConcurrency"`
+	MaxRequests    int      `json:"maxRequests"`
+}
+
+// LoadSafetyConfig reads the safety config at path. A missing file is not an
+// error and yields a config with no protected hosts and the default limits,
+// DefaultSafetyMaxConcurrency and DefaultSafetyMaxRequests, which settings
+// left out of the file also keep.
+func LoadSafetyConfig(path string) (*SafetyConfig, error) {
+	safety := &SafetyConfig{
+		MaxConcurrency: DefaultSafetyMaxConcurrency,
+		MaxRequests:    DefaultSafetyMaxRequests,
+	}
+
+	if path == "" {
+		return safety, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return safety, nil
+		}
+		return nil, fmt.Errorf("failed to read safety config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, safety); err != nil {
+		return nil, fmt.Errorf("failed to parse safety config: %w", err)
+	}
+
+	return safety, nil
+}
+
+// IsProtected reports whether host matches one of the protected patterns.
+// Patterns use shell glob syntax, e.g. "*.prod.example.com".
+func (s *SafetyConfig) IsProtected(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range s.ProtectedHosts {
+		if matched, _ := path.Match(strings.ToLower(pattern), host); matched {
+			return true
+		}
+	}
+	return false
+}
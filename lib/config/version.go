@@ -0,0 +1,5 @@
+package config
+
+// Version is the tool version, set at build time with
+// -ldflags "-X percipio.com/gopi/lib/config.Version=...".
+var Version = "dev"
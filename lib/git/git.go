@@ -8,14 +8,21 @@ import (
 	"time"
 
 	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/util"
 )
 
+// dirtySuffix marks the short hash of a working tree with uncommitted
+// changes, which the commit alone doesn't describe.
+const dirtySuffix = "-dirty"
+
 type CommitInfo struct {
 	Hash      string
-	ShortHash string
+	ShortHash string // Ends in dirtySuffix when Dirty
 	Timestamp time.Time
 	RepoName  string
 	RefName   string
+	Branch    string // Checked out branch, "HEAD" when detached
+	Dirty     bool   // Whether the working tree has uncommitted changes
 }
 
 func GetCommitInfo(useGit bool) (*CommitInfo, error) {
@@ -43,13 +50,30 @@ func GetCommitInfo(useGit bool) (*CommitInfo, error) {
 		}
 	}
 
-	return &CommitInfo{
-		Hash:      strings.TrimSpace(hash),
-		ShortHash: strings.TrimSpace(hash)[:8],
+	hash = strings.TrimSpace(hash)
+	branch, err := execGitCommand("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		logger.Warn("Failed to get branch: %v", err)
+	}
+	info := &CommitInfo{
+		Hash:      hash,
+		ShortHash: util.ShortHash(hash, 8),
 		RepoName:  parseRepoName(remoteURL),
 		RefName:   strings.TrimSpace(remoteURL),
 		Timestamp: timestamp,
-	}, nil
+		Branch:    strings.TrimSpace(branch),
+	}
+
+	// Untracked files are left out, as they include the history and reports
+	// the runs themselves write
+	status, err := execGitCommand("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		logger.Warn("Failed to check for uncommitted changes: %v", err)
+	} else if strings.TrimSpace(status) != "" {
+		info.Dirty = true
+		info.ShortHash += dirtySuffix
+	}
+	return info, nil
 }
 
 func execGitCommand(args ...string) (string, error) {
@@ -71,7 +95,7 @@ func generateTimestampHash() *CommitInfo {
 
 	return &CommitInfo{
 		Hash:      fullHash,
-		ShortHash: fullHash[:8],
+		ShortHash: util.ShortHash(fullHash, 8),
 		Timestamp: now,
 	}
 }
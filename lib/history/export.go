@@ -0,0 +1,90 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// csvHeader names the columns ExportCSV writes.
+var csvHeader = []string{
+	"commitHash", "commitTime", "endpoint",
+	"avgLatencyMs", "p50LatencyMs", "p95LatencyMs", "p99LatencyMs",
+	"rps", "errorRate",
+	"avgBytesIn", "avgBytesOut", "bytesInPerSec",
+	"dirty",
+}
+
+// ExportCSV writes the endpoint history in the summary as CSV, one row per
+// endpoint per run sorted by commit time, for spreadsheets. It only reads, so
+// a missing summary gives just the header and a corrupt one an error.
+func (s *Store) ExportCSV(w io.Writer) error {
+	var summary Summary
+	data, err := os.ReadFile(filepath.Join(s.baseDir, summaryFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read summary: %w", err)
+	default:
+		if err := json.Unmarshal(data, &summary); err != nil {
+			return fmt.Errorf("failed to read summary: %w", err)
+		}
+	}
+
+	type row struct {
+		endpoint string
+		trend    TrendReport
+	}
+	endpoints := make([]string, 0, len(summary.EndpointHistory))
+	for endpoint := range summary.EndpointHistory {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	var rows []row
+	for _, endpoint := range endpoints {
+		for _, trend := range summary.EndpointHistory[endpoint] {
+			rows = append(rows, row{endpoint, trend})
+		}
+	}
+	// Stable, so runs on the same commit keep their order
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].trend.CommitTime.Before(rows[j].trend.CommitTime)
+	})
+
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{
+			r.trend.CommitHash,
+			r.trend.CommitTime.Format(time.RFC3339),
+			r.endpoint,
+			formatCSVFloat(r.trend.AvgLatencyMS),
+			formatCSVFloat(r.trend.P50LatencyMS),
+			formatCSVFloat(r.trend.P95LatencyMS),
+			formatCSVFloat(r.trend.P99LatencyMS),
+			formatCSVFloat(r.trend.RPS),
+			formatCSVFloat(r.trend.ErrorRateTrend),
+			formatCSVFloat(r.trend.AvgBytesIn),
+			formatCSVFloat(r.trend.AvgBytesOut),
+			formatCSVFloat(r.trend.BytesInPerSec),
+			strconv.FormatBool(r.trend.Dirty),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
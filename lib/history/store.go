@@ -11,6 +11,7 @@ import (
 	"percipio.com/gopi/lib/git"
 	"percipio.com/gopi/lib/logger"
 	"percipio.com/gopi/lib/stats"
+	"percipio.com/gopi/lib/util"
 )
 
 const (
@@ -21,10 +22,22 @@ const (
 	dataLoadHistoryDir = "test-history/data-load"
 )
 
+// Latency statistics that degradation detection can compare.
+const (
+	LatencyMetricAvg = "avg"
+	LatencyMetricP50 = "p50"
+	LatencyMetricP95 = "p95"
+	LatencyMetricP99 = "p99"
+)
+
 type Store struct {
-	baseDir      string
-	thresholdPct float64
-	gitInfo      GitMetadata
+	baseDir            string
+	thresholdPct       float64
+	endpointThresholds map[string]float64 // Per-endpoint overrides of thresholdPct
+	latencyMetric      string
+	baselineID         string // Run to compare against instead of the latest
+	baselineWindow     int    // Recent runs averaged into the baseline
+	gitInfo            GitMetadata
 }
 
 func NewStore(baseDir string, thresholdPct float64, useGit bool) (*Store, error) {
@@ -40,6 +53,11 @@ func NewStore(baseDir string, thresholdPct float64, useGit bool) (*Store, error)
 				CommitHash: commitInfo.Hash,
 				ShortHash:  commitInfo.ShortHash,
 				Timestamp:  commitInfo.Timestamp,
+				Branch:     commitInfo.Branch,
+				Dirty:      commitInfo.Dirty,
+			}
+			if gitInfo.Dirty {
+				logger.Warn("Working tree has uncommitted changes, runs are recorded as %s", gitInfo.ShortHash)
 			}
 		}
 	} else {
@@ -58,12 +76,73 @@ func NewStore(baseDir string, thresholdPct float64, useGit bool) (*Store, error)
 	}
 
 	return &Store{
-		baseDir:      baseDir,
-		thresholdPct: thresholdPct,
-		gitInfo:      gitInfo,
+		baseDir:            baseDir,
+		thresholdPct:       thresholdPct,
+		endpointThresholds: make(map[string]float64),
+		latencyMetric:      LatencyMetricAvg,
+		baselineWindow:     1,
+		gitInfo:            gitInfo,
 	}, nil
 }
 
+// SetEndpointThreshold overrides the degradation threshold for one endpoint,
+// identified by its key in stats.Statistics.EndpointStats.
+func (s *Store) SetEndpointThreshold(endpoint string, thresholdPct float64) {
+	s.endpointThresholds[endpoint] = thresholdPct
+}
+
+// SetLatencyMetric chooses the latency statistic, one of the LatencyMetric
+// constants, that is compared against the baseline. The default is the
+// average.
+func (s *Store) SetLatencyMetric(metric string) {
+	s.latencyMetric = metric
+}
+
+// SetBaseline makes new runs compare against the saved run runID instead of
+// the latest one.
+func (s *Store) SetBaseline(runID string) {
+	s.baselineID = runID
+}
+
+// SetBaselineWindow makes new runs compare against the average of the last n
+// runs rather than just the latest, so one noisy run doesn't skew the
+// comparison while a sustained regression still shows. The default is 1.
+func (s *Store) SetBaselineWindow(n int) {
+	s.baselineWindow = max(n, 1)
+}
+
+// latencyOf returns the latency statistic of es selected by SetLatencyMetric.
+func (s *Store) latencyOf(es *stats.EndpointStatistics) time.Duration {
+	switch s.latencyMetric {
+	case LatencyMetricP50:
+		return es.P50Latency
+	case LatencyMetricP95:
+		return es.P95Latency
+	case LatencyMetricP99:
+		return es.P99Latency
+	default:
+		return es.AverageDuration
+	}
+}
+
+// thresholdFor returns the degradation threshold that applies to endpoint.
+func (s *Store) thresholdFor(endpoint string) float64 {
+	if pct, ok := s.endpointThresholds[endpoint]; ok {
+		return pct
+	}
+	return s.thresholdPct
+}
+
+// BaseDir returns the directory the history is kept in.
+func (s *Store) BaseDir() string {
+	return s.baseDir
+}
+
+// GitInfo returns the commit metadata runs are recorded against.
+func (s *Store) GitInfo() GitMetadata {
+	return s.gitInfo
+}
+
 func createTimestampBasedMetadata() GitMetadata {
 	now := time.Now()
 	timestamp := now.Format("20060102-150405")
@@ -82,16 +161,36 @@ func (s *Store) SaveResults(stats *stats.Statistics) (*TestHistory, error) {
 	}
 
 	history := &TestHistory{
-		RunID:        time.Now().Format("20060102-150405"),
-		Timestamp:    time.Now(),
-		Statistics:   stats,
-		Endpoints:    make(map[string]*Comparison),
-		ThresholdPct: s.thresholdPct,
-		GitInfo:      s.gitInfo,
+		RunID:         time.Now().Format("20060102-150405"),
+		Timestamp:     time.Now(),
+		Statistics:    stats,
+		Endpoints:     make(map[string]*Comparison),
+		ThresholdPct:  s.thresholdPct,
+		LatencyMetric: s.latencyMetric,
+		GitInfo:       s.gitInfo,
+	}
+
+	var previous *TestHistory
+	switch {
+	case s.baselineID != "":
+		var err error
+		if previous, err = s.LoadByRunID(s.baselineID); err != nil {
+			return nil, err
+		}
+	case s.baselineWindow > 1:
+		if rolling, err := s.loadRollingBaseline(); err == nil {
+			previous = rolling
+		}
+	default:
+		if latest, err := s.LoadLatest(); err == nil {
+			previous = latest
+		}
 	}
-
-	previous, err := s.LoadLatest()
-	if err == nil && previous != nil {
+	if previous != nil {
+		if previous.GitInfo.Dirty {
+			logger.Warn("Baseline run %s was recorded with uncommitted changes on top of %s, so it may not match any commit",
+				previous.RunID, previous.GitInfo.CommitHash)
+		}
 		history.BaselineID = previous.RunID
 		history.Degradation = s.compareWithBaseline(history, previous)
 	}
@@ -102,36 +201,24 @@ func (s *Store) SaveResults(stats *stats.Statistics) (*TestHistory, error) {
 		return nil, err
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := util.WriteFileAtomic(filename, data); err != nil {
 		return nil, err
 	}
 
-	summary := &Summary{
-		EndpointHistory: make(map[string][]TrendReport),
-		Trends:          make(map[string]TrendReport),
+	summary, err := s.loadSummary()
+	if err != nil {
+		return nil, err
 	}
 
-	data, err = os.ReadFile(filepath.Join(s.baseDir, summaryFile))
-	if err == nil {
-		if err := json.Unmarshal(data, summary); err != nil {
-			return nil, err
-		}
-	}
+	logger.Info("Updating performance summary for run %s", history.RunID)
+	summary.LastRun = history.Timestamp
+	summary.RunCount++
+	summary.History = append(summary.History, history.RunID)
+	summary.Degradation = history.Degradation
+	summary.HealthScore = history.Statistics.HealthScore
 
 	for endpoint, stats := range history.Statistics.EndpointStats {
-		errorRate := float64(stats.FailedRequests) / float64(stats.TotalRequests) * 100
-		trend := TrendReport{
-			CommitHash:     s.gitInfo.CommitHash,
-			CommitTime:     s.gitInfo.Timestamp,
-			IterationMS:    float64(stats.AverageDuration.Milliseconds()),
-			TotalRequests:  stats.TotalRequests,
-			AvgLatencyMS:   float64(stats.AverageDuration.Milliseconds()),
-			P50LatencyMS:   float64(stats.P50Latency.Milliseconds()),
-			P95LatencyMS:   float64(stats.P95Latency.Milliseconds()),
-			P99LatencyMS:   float64(stats.P99Latency.Milliseconds()),
-			RPS:            stats.RequestsPerSecond,
-			ErrorRateTrend: errorRate,
-		}
+		trend := newTrendReport(s.gitInfo, stats)
 
 		logger.Info("Saved trend for endpoint %s: avg=%.2f ms, p50=%.2f ms, p95=%.2f ms, p99=%.2f ms, reqs=%d\n",
 			endpoint, trend.AvgLatencyMS, trend.P50LatencyMS, trend.P95LatencyMS, trend.P99LatencyMS, trend.TotalRequests)
@@ -151,13 +238,42 @@ func (s *Store) SaveResults(stats *stats.Statistics) (*TestHistory, error) {
 		return nil, err
 	}
 
-	if err := s.updateSummary(history); err != nil {
-		logger.Error("Failed to update summary: %v", err)
-		// Continue with the rest of the process but maybe
-		// we should return an error here?
-	}
+	return history, util.WriteFileAtomic(filepath.Join(s.baseDir, summaryFile), data)
+}
 
-	return history, os.WriteFile(filepath.Join(s.baseDir, summaryFile), data, 0644)
+// newTrendReport returns the summary point for an endpoint's statistics in a
+// run recorded against gitInfo.
+func newTrendReport(gitInfo GitMetadata, stats *stats.EndpointStatistics) TrendReport {
+	trend := TrendReport{
+		CommitHash:      gitInfo.CommitHash,
+		CommitTime:      gitInfo.Timestamp,
+		Dirty:           gitInfo.Dirty,
+		IterationMS:     float64(stats.AverageDuration.Milliseconds()),
+		TotalRequests:   stats.TotalRequests,
+		AvgLatencyMS:    float64(stats.AverageDuration.Milliseconds()),
+		P50LatencyMS:    float64(stats.P50Latency.Milliseconds()),
+		P95LatencyMS:    float64(stats.P95Latency.Milliseconds()),
+		P99LatencyMS:    float64(stats.P99Latency.Milliseconds()),
+		StdDevLatencyMS: float64(stats.StdDevDuration.Microseconds()) / 1000,
+		RPS:             stats.RequestsPerSecond,
+	}
+	// An endpoint whose requests were all injected faults has none to rate
+	if stats.TotalRequests > 0 {
+		trend.ErrorRateTrend = float64(stats.FailedRequests) / float64(stats.TotalRequests) * 100
+		trend.AvgBytesIn = float64(stats.BytesIn) / float64(stats.TotalRequests)
+		trend.AvgBytesOut = float64(stats.BytesOut) / float64(stats.TotalRequests)
+		trend.BytesInPerSec = stats.BytesInPerSecond
+	}
+	if len(stats.Percentiles) > 0 {
+		trend.PercentilesMS = make(map[string]float64, len(stats.Percentiles))
+		for _, pl := range stats.Percentiles {
+			trend.PercentilesMS[pl.Label()] = float64(pl.Latency.Microseconds()) / 1000
+		}
+	}
+	for _, e := range stats.UniqueErrors() {
+		trend.Errors = append(trend.Errors, ErrorCount{Message: e.Message, Count: e.Count})
+	}
+	return trend
 }
 
 func (s *Store) LoadLatest() (*TestHistory, error) {
@@ -193,6 +309,61 @@ func (s *Store) LoadLatest() (*TestHistory, error) {
 	return &history, nil
 }
 
+// LoadByRunID returns the saved run runID, as named by its RunID.
+func (s *Store) LoadByRunID(runID string) (*TestHistory, error) {
+	if runID == "" || runID != filepath.Base(runID) || runID+".json" == summaryFile {
+		return nil, fmt.Errorf("invalid run ID %q", runID)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.baseDir, runID+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no run %s in %s", runID, s.baseDir)
+		}
+		return nil, err
+	}
+
+	var history TestHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %w", runID, err)
+	}
+
+	return &history, nil
+}
+
+// loadRollingBaseline returns a baseline with each endpoint's statistics
+// averaged over the last baselineWindow runs, or nil if there are none. Its
+// RunID names the runs it covers.
+func (s *Store) loadRollingBaseline() (*TestHistory, error) {
+	runs, _, err := s.loadRuns()
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	if len(runs) > s.baselineWindow {
+		runs = runs[len(runs)-s.baselineWindow:]
+	}
+	if len(runs) == 1 {
+		return runs[0], nil
+	}
+
+	byEndpoint := make(map[string][]*stats.EndpointStatistics)
+	for _, run := range runs {
+		for endpoint, es := range run.Statistics.EndpointStats {
+			byEndpoint[endpoint] = append(byEndpoint[endpoint], es)
+		}
+	}
+
+	first, last := runs[0].RunID, runs[len(runs)-1].RunID
+	baseline := &TestHistory{
+		RunID:      fmt.Sprintf("mean of %d runs %s..%s", len(runs), first, last),
+		Statistics: &stats.Statistics{EndpointStats: make(map[string]*stats.EndpointStatistics, len(byEndpoint))},
+	}
+	for endpoint, runStats := range byEndpoint {
+		baseline.Statistics.EndpointStats[endpoint] = stats.AverageEndpointStats(runStats)
+	}
+	return baseline, nil
+}
+
 func (s *Store) compareWithBaseline(current, baseline *TestHistory) bool {
 	hasDegradation := false
 
@@ -204,14 +375,16 @@ func (s *Store) compareWithBaseline(current, baseline *TestHistory) bool {
 			}
 
 			changes := DegradationReport{
-				LatencyIncrease:     percentageIncrease(currentStats.AverageDuration.Seconds(), baselineStats.AverageDuration.Seconds()),
+				LatencyIncrease:     percentageIncrease(s.latencyOf(currentStats).Seconds(), s.latencyOf(baselineStats).Seconds()),
 				ErrorRateIncrease:   percentageIncrease(float64(currentStats.FailedRequests), float64(baselineStats.FailedRequests)),
 				ThroughputDecrease:  percentageDecrease(currentStats.RequestsPerSecond, baselineStats.RequestsPerSecond),
 				SuccessRateDecrease: percentageDecrease(successRate(currentStats), successRate(baselineStats)),
 			}
 
 			comparison.Changes = changes
-			comparison.Degradation = s.isDegraded(changes)
+			comparison.ThresholdPct = s.thresholdFor(endpoint)
+			comparison.Degradation = isDegraded(changes, comparison.ThresholdPct)
+			comparison.Distribution = stats.KSTest(currentStats, baselineStats)
 			current.Endpoints[endpoint] = comparison
 
 			if comparison.Degradation {
@@ -223,11 +396,11 @@ func (s *Store) compareWithBaseline(current, baseline *TestHistory) bool {
 	return hasDegradation
 }
 
-func (s *Store) isDegraded(changes DegradationReport) bool {
-	return changes.LatencyIncrease > s.thresholdPct ||
-		changes.ErrorRateIncrease > s.thresholdPct ||
-		changes.ThroughputDecrease > s.thresholdPct ||
-		changes.SuccessRateDecrease > s.thresholdPct
+func isDegraded(changes DegradationReport, thresholdPct float64) bool {
+	return changes.LatencyIncrease > thresholdPct ||
+		changes.ErrorRateIncrease > thresholdPct ||
+		changes.ThroughputDecrease > thresholdPct ||
+		changes.SuccessRateDecrease > thresholdPct
 }
 
 func successRate(stats *stats.EndpointStatistics) float64 {
@@ -252,126 +425,178 @@ type Summary struct {
 	LastRun         time.Time                `json:"lastRun"`
 	RunCount        int                      `json:"runCount"`
 	Degradation     bool                     `json:"degradation"`
+	HealthScore     float64                  `json:"healthScore"`
 	History         []string                 `json:"history"`
 	Trends          map[string]TrendReport   `json:"trends"`
 	EndpointHistory map[string][]TrendReport `json:"endpointHistory"`
 }
 
-func (s *Store) updateSummary(current *TestHistory) error {
-	logger.Info("Updating performance summary for run %s", current.RunID)
-	summaryPath := filepath.Join(s.baseDir, summaryFile)
-	var summary Summary
+func (s *Store) GetSummary() (*Summary, error) {
+	return s.loadSummary()
+}
 
-	data, err := os.ReadFile(summaryPath)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to read summary: %w", err)
+func (s *Store) SaveLoadTestResults(stats *stats.LoadTestStats, testType string) (*LoadTestHistory, error) {
+	historyDir, err := loadTestDir(testType)
+	if err != nil {
+		return nil, err
 	}
 
-	if err == nil {
-		if err := json.Unmarshal(data, &summary); err != nil {
-			return fmt.Errorf("failed to parse summary: %w", err)
-		}
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return nil, err
 	}
 
-	summary.LastRun = current.Timestamp
-	summary.RunCount++
-	summary.History = append(summary.History, current.RunID)
-	summary.Degradation = current.Degradation
-
-	if summary.EndpointHistory == nil {
-		summary.EndpointHistory = make(map[string][]TrendReport)
-	}
-	if summary.Trends == nil {
-		summary.Trends = make(map[string]TrendReport)
-	}
-
-	for endpoint, comparison := range current.Endpoints {
-		trend := TrendReport{
-			CommitHash:    s.gitInfo.CommitHash,
-			CommitTime:    s.gitInfo.Timestamp,
-			IterationMS:   float64(comparison.Current.AverageDuration.Milliseconds()),
-			TotalRequests: comparison.Current.TotalRequests,
-			AvgLatencyMS:  float64(comparison.Current.AverageDuration.Milliseconds()),
-			RPS:           comparison.Current.RequestsPerSecond,
-			P50LatencyMS:  float64(comparison.Current.P50Latency.Milliseconds()),
-			P95LatencyMS:  float64(comparison.Current.P95Latency.Milliseconds()),
-			P99LatencyMS:  float64(comparison.Current.P99Latency.Milliseconds()),
-		}
-
-		logger.Debug("Adding history point: endpoint=%s, hash=%s, ms=%.2f\n",
-			endpoint, trend.CommitHash[:8], trend.AvgLatencyMS)
-
-		if _, exists := summary.EndpointHistory[endpoint]; !exists {
-			summary.EndpointHistory[endpoint] = make([]TrendReport, 0)
-		}
-		summary.EndpointHistory[endpoint] = append(summary.EndpointHistory[endpoint], trend)
-
-		summary.Trends[endpoint] = trend
+	history := &LoadTestHistory{
+		RunID:      time.Now().Format("20060102-150405"),
+		Timestamp:  time.Now(),
+		TestType:   testType,
+		Statistics: stats,
+		GitInfo:    s.gitInfo,
 	}
 
-	data, err = json.MarshalIndent(summary, "", "  ")
+	previous, err := s.LoadLatestLoadTest(testType)
 	if err != nil {
-		return err
+		logger.Warn("Failed to load the %s baseline: %v", testType, err)
+	} else if previous != nil {
+		history.BaselineID = previous.RunID
+		history.Comparison = s.CompareLoadTests(history, previous)
 	}
 
-	for endpoint, history := range summary.EndpointHistory {
-		logger.Info("Endpoint %s has %d history points\n", endpoint, len(history))
+	filename := filepath.Join(historyDir, history.RunID+".json")
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return nil, err
 	}
 
-	return os.WriteFile(summaryPath, data, 0644)
+	return history, os.WriteFile(filename, data, 0644)
 }
 
-func (s *Store) GetSummary() (*Summary, error) {
-	summaryPath := filepath.Join(s.baseDir, summaryFile)
-	var summary Summary
+// LoadLatestLoadTest returns the most recent saved run of the testType load
+// test, or nil if there is none.
+func (s *Store) LoadLatestLoadTest(testType string) (*LoadTestHistory, error) {
+	historyDir, err := loadTestDir(testType)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(summaryPath)
+	entries, err := os.ReadDir(historyDir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Summary{
-				Trends: make(map[string]TrendReport),
-			}, nil
+			return nil, nil
 		}
 		return nil, err
 	}
 
-	if err := json.Unmarshal(data, &summary); err != nil {
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			files = append(files, entry.Name())
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(files)
+	latest := files[len(files)-1]
+
+	data, err := os.ReadFile(filepath.Join(historyDir, latest))
+	if err != nil {
 		return nil, err
 	}
 
-	return &summary, nil
+	var history LoadTestHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+
+	return &history, nil
 }
 
-func (s *Store) SaveLoadTestResults(stats *stats.LoadTestStats, testType string) (*LoadTestHistory, error) {
-	var historyDir string
+// CompareLoadTests compares a load test run with its baseline step by step.
+// Steps are matched by user count or data size, so steps only one of the
+// runs reached are left out. A step is degraded when its latency, error rate,
+// throughput or success rate moved by more than the store's threshold, and
+// the run is degraded when any step is or it reached a lower load than the
+// baseline before aborting.
+func (s *Store) CompareLoadTests(current, baseline *LoadTestHistory) *LoadTestComparison {
+	comparison := &LoadTestComparison{
+		ThresholdPct:    s.thresholdPct,
+		MaxLoad:         maxLoad(current.Statistics),
+		BaselineMaxLoad: maxLoad(baseline.Statistics),
+	}
+	if current.Statistics == nil || baseline.Statistics == nil {
+		return comparison
+	}
+
+	previousSteps := make(map[int]stats.StepStatistics)
+	for _, step := range baseline.Statistics.Steps {
+		previousSteps[stepLoad(step)] = step
+	}
+
+	for _, step := range current.Statistics.Steps {
+		previous, ok := previousSteps[stepLoad(step)]
+		if !ok {
+			continue
+		}
+
+		changes := DegradationReport{
+			LatencyIncrease:     percentageIncrease(float64(step.AverageLatency), float64(previous.AverageLatency)),
+			ErrorRateIncrease:   percentageIncrease(step.ErrorRate, previous.ErrorRate),
+			ThroughputDecrease:  percentageDecrease(step.RequestsPerSecond, previous.RequestsPerSecond),
+			SuccessRateDecrease: percentageDecrease(step.SuccessRate, previous.SuccessRate),
+		}
+		degraded := isDegraded(changes, s.thresholdPct)
+		comparison.Steps = append(comparison.Steps, StepComparison{
+			Load:        stepLoad(step),
+			Current:     step,
+			Previous:    previous,
+			Changes:     changes,
+			Degradation: degraded,
+		})
+		comparison.Degradation = comparison.Degradation || degraded
+	}
+
+	if comparison.MaxLoad < comparison.BaselineMaxLoad {
+		comparison.Degradation = true
+	}
+
+	return comparison
+}
+
+// loadTestDir returns the directory the runs of the testType load test are
+// saved in.
+func loadTestDir(testType string) (string, error) {
 	switch testType {
 	case TestTypeLoadUser:
-		historyDir = userLoadHistoryDir
+		return userLoadHistoryDir, nil
 	case TestTypeLoadData:
-		historyDir = dataLoadHistoryDir
+		return dataLoadHistoryDir, nil
 	default:
-		return nil, fmt.Errorf("invalid test type: %s", testType)
+		return "", fmt.Errorf("invalid test type: %s", testType)
 	}
+}
 
-	if err := os.MkdirAll(historyDir, 0755); err != nil {
-		return nil, err
+// stepLoad returns the load a step was run at: its user count for a user
+// load test, its data size for a data load test.
+func stepLoad(step stats.StepStatistics) int {
+	if step.UserCount > 0 {
+		return step.UserCount
 	}
+	return step.DataSize
+}
 
-	history := &LoadTestHistory{
-		RunID:      time.Now().Format("20060102-150405"),
-		Timestamp:  time.Now(),
-		TestType:   testType,
-		Statistics: stats,
-		GitInfo:    s.gitInfo,
+// maxLoad returns the highest load a run completed a step at without it being
+// aborted.
+func maxLoad(loadStats *stats.LoadTestStats) int {
+	if loadStats == nil {
+		return 0
 	}
-
-	filename := filepath.Join(historyDir, history.RunID+".json")
-	data, err := json.MarshalIndent(history, "", "  ")
-	if err != nil {
-		return nil, err
+	max := 0
+	for _, step := range loadStats.Steps {
+		if step.AbortedAfter == 0 && stepLoad(step) > max {
+			max = stepLoad(step)
+		}
 	}
-
-	return history, os.WriteFile(filename, data, 0644)
+	return max
 }
-
-// Add more methods for loading and comparing load test results...
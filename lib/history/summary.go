@@ -0,0 +1,51 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"percipio.com/gopi/lib/logger"
+)
+
+// loadSummary reads the summary file. A missing file yields an empty summary,
+// and so does a corrupt one: it is moved aside as a backup so the current
+// run's results can still be recorded rather than lost.
+func (s *Store) loadSummary() (*Summary, error) {
+	summaryPath := filepath.Join(s.baseDir, summaryFile)
+	summary := &Summary{
+		EndpointHistory: make(map[string][]TrendReport),
+		Trends:          make(map[string]TrendReport),
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if os.IsNotExist(err) {
+		return summary, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary: %w", err)
+	}
+
+	if err := json.Unmarshal(data, summary); err != nil {
+		backupPath := fmt.Sprintf("%s.corrupt-%s", summaryPath, time.Now().Format("20060102-150405"))
+		if renameErr := os.Rename(summaryPath, backupPath); renameErr != nil {
+			return nil, fmt.Errorf("failed to back up corrupt summary: %w", renameErr)
+		}
+		logger.Warn("Summary %s is corrupt (%v); moved it to %s and starting a fresh summary",
+			summaryPath, err, backupPath)
+		return &Summary{
+			EndpointHistory: make(map[string][]TrendReport),
+			Trends:          make(map[string]TrendReport),
+		}, nil
+	}
+
+	if summary.EndpointHistory == nil {
+		summary.EndpointHistory = make(map[string][]TrendReport)
+	}
+	if summary.Trends == nil {
+		summary.Trends = make(map[string]TrendReport)
+	}
+	return summary, nil
+}
@@ -7,14 +7,15 @@ import (
 )
 
 type TestHistory struct {
-	RunID        string                 `json:"runId"`
-	Timestamp    time.Time              `json:"timestamp"`
-	Statistics   *stats.Statistics      `json:"statistics"`
-	Endpoints    map[string]*Comparison `json:"endpoints"`
-	BaselineID   string                 `json:"baselineId,omitempty"`
-	Degradation  bool                   `json:"degradation"`
-	ThresholdPct float64                `json:"thresholdPct"`
-	GitInfo      GitMetadata            `json:"gitInfo"`
+	RunID         string                 `json:"runId"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Statistics    *stats.Statistics      `json:"statistics"`
+	Endpoints     map[string]*Comparison `json:"endpoints"`
+	BaselineID    string                 `json:"baselineId,omitempty"`
+	Degradation   bool                   `json:"degradation"`
+	ThresholdPct  float64                `json:"thresholdPct"`
+	LatencyMetric string                 `json:"latencyMetric,omitempty"` // Latency statistic compared against the baseline
+	GitInfo       GitMetadata            `json:"gitInfo"`
 }
 
 type GitMetadata struct {
@@ -23,13 +24,16 @@ type GitMetadata struct {
 	Branch        string    `json:"branch"`
 	ShortHash     string    `json:"shortHash"`
 	Timestamp     time.Time `json:"timestamp"`
+	Dirty         bool      `json:"dirty,omitempty"` // Recorded with uncommitted changes
 }
 
 type Comparison struct {
-	Current     *stats.EndpointStatistics `json:"current"`
-	Previous    *stats.EndpointStatistics `json:"previous,omitempty"`
-	Degradation bool                      `json:"degradation"`
-	Changes     DegradationReport         `json:"changes"`
+	Current      *stats.EndpointStatistics `json:"current"`
+	Previous     *stats.EndpointStatistics `json:"previous,omitempty"`
+	Degradation  bool                      `json:"degradation"`
+	Changes      DegradationReport         `json:"changes"`
+	Distribution *stats.KSResult           `json:"distribution,omitempty"`
+	ThresholdPct float64                   `json:"thresholdPct"` // Threshold the endpoint was judged against
 }
 
 type DegradationReport struct {
@@ -41,22 +45,38 @@ type DegradationReport struct {
 
 // TrendReport represents performance metrics for an endpoint at a specific point in time
 type TrendReport struct {
-	CommitHash       string    `json:"commitHash"`
-	CommitTime       time.Time `json:"commitTime"`
-	IterationMS      float64   `json:"iterationMs"`
-	TotalRequests    int       `json:"totalRequests"`
-	AvgLatencyMS     float64   `json:"avgLatencyMs"`
-	P50LatencyMS     float64   `json:"p50LatencyMs"`
-	P95LatencyMS     float64   `json:"p95LatencyMs"`
-	P99LatencyMS     float64   `json:"p99LatencyMs"`
-	RPS              float64   `json:"rps"`
-	ErrorRateTrend   float64   `json:"errorRateTrend"`
-	TrendPercent     float64   `json:"trendPercent"`
-	BaselineHash     string    `json:"baselineHash,omitempty"`
-	LatencyTrend     float64   `json:"latencyTrend"`
-	ThroughputTrend  float64   `json:"throughputTrend"`
-	SuccessRateTrend float64   `json:"successRateTrend"`
-	MedianLatencyMS  float64   `json:"medianLatencyMs"`
+	CommitHash       string       `json:"commitHash"`
+	CommitTime       time.Time    `json:"commitTime"`
+	Dirty            bool         `json:"dirty,omitempty"` // Recorded with uncommitted changes
+	IterationMS      float64      `json:"iterationMs"`
+	TotalRequests    int          `json:"totalRequests"`
+	AvgLatencyMS     float64      `json:"avgLatencyMs"`
+	P50LatencyMS     float64      `json:"p50LatencyMs"`
+	P95LatencyMS     float64      `json:"p95LatencyMs"`
+	P99LatencyMS     float64      `json:"p99LatencyMs"`
+	RPS              float64      `json:"rps"`
+	ErrorRateTrend   float64      `json:"errorRateTrend"`
+	TrendPercent     float64      `json:"trendPercent"`
+	BaselineHash     string       `json:"baselineHash,omitempty"`
+	LatencyTrend     float64      `json:"latencyTrend"`
+	ThroughputTrend  float64      `json:"throughputTrend"`
+	SuccessRateTrend float64      `json:"successRateTrend"`
+	MedianLatencyMS  float64      `json:"medianLatencyMs"`
+	StdDevLatencyMS  float64      `json:"stdDevLatencyMs"`
+	AvgBytesIn       float64      `json:"avgBytesIn,omitempty"`    // Response body bytes per request
+	AvgBytesOut      float64      `json:"avgBytesOut,omitempty"`   // Request body bytes per request
+	BytesInPerSec    float64      `json:"bytesInPerSec,omitempty"` // Response bandwidth
+	Errors           []ErrorCount `json:"errors,omitempty"`
+
+	// PercentilesMS are the latency percentiles requested for the run, by
+	// label such as "P99.9"
+	PercentilesMS map[string]float64 `json:"percentilesMs,omitempty"`
+}
+
+// ErrorCount is a distinct error an endpoint failed with in a run.
+type ErrorCount struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
 }
 
 // Stats holds formatted statistics for display
@@ -83,6 +103,28 @@ type LoadTestHistory struct {
 	BaselineID string               `json:"baselineId,omitempty"`
 	GitInfo    GitMetadata          `json:"gitInfo"`
 	Steps      []LoadTestStep       `json:"steps"`
+
+	Comparison *LoadTestComparison `json:"comparison,omitempty"` // Against the baseline run, if there was one
+}
+
+// LoadTestComparison compares a load test run with the previous run of the
+// same type, see Store.CompareLoadTests.
+type LoadTestComparison struct {
+	ThresholdPct    float64          `json:"thresholdPct"`
+	Steps           []StepComparison `json:"steps"`
+	MaxLoad         int              `json:"maxLoad"`         // Highest users or data size completed without aborting
+	BaselineMaxLoad int              `json:"baselineMaxLoad"` // The same for the baseline
+	Degradation     bool             `json:"degradation"`
+}
+
+// StepComparison compares one step of a load test with the baseline's step
+// at the same load.
+type StepComparison struct {
+	Load        int                  `json:"load"` // User count or data size
+	Current     stats.StepStatistics `json:"current"`
+	Previous    stats.StepStatistics `json:"previous"`
+	Changes     DegradationReport    `json:"changes"`
+	Degradation bool                 `json:"degradation"`
 }
 
 type LoadTestStep struct {
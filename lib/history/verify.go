@@ -0,0 +1,191 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"percipio.com/gopi/lib/util"
+)
+
+// IntegrityReport describes how well the summary matches the run files it is
+// built from.
+type IntegrityReport struct {
+	RunFiles    int      // Readable run files in the history directory
+	SummaryRuns int      // Runs listed in the summary
+	Problems    []string // Inconsistencies found, empty if the history is intact
+}
+
+// OK reports whether no inconsistencies were found.
+func (r *IntegrityReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *IntegrityReport) addProblem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// VerifyIntegrity cross-checks the summary's run list and endpoint history
+// against the run files in the history directory. It only reads, so it is
+// safe to run on a history that is in use.
+func (s *Store) VerifyIntegrity() (*IntegrityReport, error) {
+	runs, unreadable, err := s.loadRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IntegrityReport{RunFiles: len(runs)}
+	for _, name := range unreadable {
+		report.addProblem("run file %s is unreadable", name)
+	}
+
+	var summary Summary
+	data, err := os.ReadFile(filepath.Join(s.baseDir, summaryFile))
+	switch {
+	case os.IsNotExist(err):
+		if len(runs) > 0 {
+			report.addProblem("%s is missing", summaryFile)
+		}
+		return report, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read summary: %w", err)
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		report.addProblem("%s is corrupt: %v", summaryFile, err)
+		return report, nil
+	}
+	report.SummaryRuns = len(summary.History)
+
+	// Runs the summary lists against the run files
+	files := make(map[string]bool, len(runs))
+	for _, run := range runs {
+		files[run.RunID] = true
+	}
+	listed := make(map[string]bool, len(summary.History))
+	for _, runID := range summary.History {
+		if listed[runID] {
+			report.addProblem("run %s is listed more than once", runID)
+			continue
+		}
+		listed[runID] = true
+		if !files[runID] {
+			report.addProblem("run %s is listed but its file is gone", runID)
+		}
+	}
+	for _, run := range runs {
+		if !listed[run.RunID] {
+			report.addProblem("run file %s.json is not listed in the summary", run.RunID)
+		}
+	}
+	if summary.RunCount != len(summary.History) {
+		report.addProblem("run count is %d but %d runs are listed", summary.RunCount, len(summary.History))
+	}
+
+	// Endpoint history points against the runs that measured each endpoint
+	expected := make(map[string]int)
+	for _, run := range runs {
+		for endpoint := range run.Statistics.EndpointStats {
+			expected[endpoint]++
+		}
+	}
+	endpoints := make(map[string]bool)
+	for endpoint := range expected {
+		endpoints[endpoint] = true
+	}
+	for endpoint := range summary.EndpointHistory {
+		endpoints[endpoint] = true
+	}
+	for _, endpoint := range sortedKeys(endpoints) {
+		points := len(summary.EndpointHistory[endpoint])
+		if points != expected[endpoint] {
+			report.addProblem("%s has %d history points but %d run files measured it", endpoint, points, expected[endpoint])
+		}
+		if _, exists := summary.Trends[endpoint]; !exists && points > 0 {
+			report.addProblem("%s has history points but no latest trend", endpoint)
+		}
+	}
+
+	return report, nil
+}
+
+// RebuildSummary replaces the summary with one rebuilt from the run files,
+// in the order the runs were recorded. Unreadable run files are left out.
+func (s *Store) RebuildSummary() (*Summary, error) {
+	runs, _, err := s.loadRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		History:         make([]string, 0, len(runs)),
+		Trends:          make(map[string]TrendReport),
+		EndpointHistory: make(map[string][]TrendReport),
+	}
+	for _, run := range runs {
+		summary.LastRun = run.Timestamp
+		summary.RunCount++
+		summary.History = append(summary.History, run.RunID)
+		summary.Degradation = run.Degradation
+		summary.HealthScore = run.Statistics.HealthScore
+
+		for endpoint, stats := range run.Statistics.EndpointStats {
+			trend := newTrendReport(run.GitInfo, stats)
+			summary.EndpointHistory[endpoint] = append(summary.EndpointHistory[endpoint], trend)
+			summary.Trends[endpoint] = trend
+		}
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := util.WriteFileAtomic(filepath.Join(s.baseDir, summaryFile), data); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// loadRuns reads every run file in the history directory, oldest first. It
+// returns the names of files that couldn't be parsed separately.
+func (s *Store) loadRuns() ([]*TestHistory, []string, error) {
+	entries, err := os.ReadDir(s.baseDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var runs []*TestHistory
+	var unreadable []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" || entry.Name() == summaryFile {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.baseDir, entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		var run TestHistory
+		if err := json.Unmarshal(data, &run); err != nil || run.RunID == "" || run.Statistics == nil {
+			unreadable = append(unreadable, entry.Name())
+			continue
+		}
+		runs = append(runs, &run)
+	}
+
+	// Run IDs are timestamps, so lexical order is chronological
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].RunID < runs[j].RunID
+	})
+	return runs, unreadable, nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,14 +1,21 @@
 package logger
 
 import (
+	"io"
 	"log"
 	"os"
 )
 
 var logger = log.New(os.Stdout, "", log.LstdFlags)
 
-// debugMode is set via -ldflags at build time
-var debugMode = "true"
+// debugMode is set via -ldflags at build time. Builds that don't set it,
+// such as go install, log at info level
+var debugMode = "false"
+
+// SetOutput redirects all log output to w
+func SetOutput(w io.Writer) {
+	logger.SetOutput(w)
+}
 
 // Debug logs debug messages only when debug mode is enabled
 func Debug(format string, v ...interface{}) {
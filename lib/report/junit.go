@@ -0,0 +1,128 @@
+// Package report writes run results in formats CI systems read.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"percipio.com/gopi/lib/history"
+	"percipio.com/gopi/lib/stats"
+)
+
+// JUnit failure types, telling why an endpoint failed
+const (
+	failureDegradation = "degradation" // Regressed against the baseline
+	failureBudget      = "budget"      // P95 latency over its latency budget
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes a run as a JUnit XML test suite with a test case per
+// endpoint, for CI systems to show in their test UI. An endpoint fails if it
+// degraded against the baseline in current or its P95 latency is over its
+// budget in budgets, keyed like statistics.EndpointStats. current may be nil.
+func WriteJUnit(w io.Writer, statistics *stats.Statistics, current *history.TestHistory, budgets map[string]time.Duration) error {
+	suite := junitSuite{
+		Name: "gopi",
+		Time: seconds(statistics.TotalDuration),
+	}
+	if current != nil {
+		suite.Name = "gopi " + current.RunID
+		suite.Timestamp = current.Timestamp.Format("2006-01-02T15:04:05")
+	}
+
+	budgetChecks := make(map[string]stats.BudgetCheck)
+	for _, check := range statistics.CheckBudgets(budgets) {
+		budgetChecks[check.Endpoint] = check
+	}
+
+	keys := make([]string, 0, len(statistics.EndpointStats))
+	for key := range statistics.EndpointStats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		es := statistics.EndpointStats[key]
+		tc := junitCase{
+			Name:      key,
+			Classname: "gopi.endpoints",
+			Time:      seconds(es.TotalDuration),
+			SystemOut: fmt.Sprintf("requests=%d success=%d failed=%d avg=%v p50=%v p95=%v p99=%v rps=%.2f",
+				es.TotalRequests, es.SuccessRequests, es.FailedRequests,
+				es.AverageDuration, es.P50Latency, es.P95Latency, es.P99Latency, es.RequestsPerSecond),
+		}
+
+		var types, messages []string
+		if current != nil {
+			if comparison := current.Endpoints[key]; comparison != nil && comparison.Degradation {
+				types = append(types, failureDegradation)
+				messages = append(messages, fmt.Sprintf(
+					"degraded against baseline %s: latency (%s) %+.2f%%, error rate %+.2f%%, throughput %+.2f%%, success rate %+.2f%% (threshold %.2f%%)",
+					current.BaselineID, current.LatencyMetric, comparison.Changes.LatencyIncrease,
+					comparison.Changes.ErrorRateIncrease, -comparison.Changes.ThroughputDecrease,
+					-comparison.Changes.SuccessRateDecrease, comparison.ThresholdPct))
+			}
+		}
+		if check, ok := budgetChecks[key]; ok && !check.Met {
+			types = append(types, failureBudget)
+			messages = append(messages, fmt.Sprintf("P95 latency %v over its budget of %v", check.P95, check.Budget))
+		}
+		if len(messages) > 0 {
+			tc.Failure = &junitFailure{
+				Message: strings.Join(messages, "; "),
+				Type:    strings.Join(types, ","),
+				Text:    strings.Join(messages, "\n"),
+			}
+			suite.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
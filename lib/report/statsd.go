@@ -0,0 +1,135 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/runner"
+)
+
+const (
+	// statsdPacketSize keeps packets within a typical network MTU, as the
+	// Datadog agent recommends for UDP
+	statsdPacketSize = 1432
+	// statsdFlushInterval bounds how long metrics wait for a packet to fill
+	statsdFlushInterval = time.Second
+)
+
+// statsdTagEscaper replaces the characters that separate tags and metric
+// fields in the DogStatsD format.
+var statsdTagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// StatsD sends the latency and outcome of every request to a StatsD agent,
+// such as the Datadog agent, over UDP as the run goes. Metrics are batched
+// into packets sent when full or every statsdFlushInterval, so a busy run
+// doesn't send a packet per request. Each request is sent as:
+//
+//	gopi.request.duration: latency timing in ms
+//	gopi.request.count:    counter of 1
+//	gopi.request.errors:   counter of 1, for failed requests
+//
+// tagged, in the DogStatsD format, with its endpoint, by name or else method
+// and URL, and its status, 0 when there was no response.
+type StatsD struct {
+	conn net.Conn
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	failed bool // Whether a send has failed, so it is only logged once
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewStatsD starts a StatsD client sending to addr, a host:port. Call Close
+// to send the last metrics and stop it.
+func NewStatsD(addr string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", addr, err)
+	}
+
+	s := &StatsD{
+		conn: conn,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go s.flushLoop()
+	return s, nil
+}
+
+// Observe queues the metrics of a completed request. It is safe for
+// concurrent use, to be passed to Runner.SetOnResult.
+func (s *StatsD) Observe(result runner.Result) {
+	endpoint := result.Name
+	if endpoint == "" {
+		endpoint = result.Method + " " + result.URL
+	}
+	tags := fmt.Sprintf("|#endpoint:%s,status:%d", statsdTagEscaper.Replace(endpoint), result.StatusCode)
+
+	lines := []string{
+		fmt.Sprintf("gopi.request.duration:%g|ms%s", float64(result.Duration.Microseconds())/1000, tags),
+		"gopi.request.count:1|c" + tags,
+	}
+	if result.Error != nil {
+		lines = append(lines, "gopi.request.errors:1|c"+tags)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range lines {
+		if s.buf.Len() > 0 && s.buf.Len()+1+len(line) > statsdPacketSize {
+			s.flush()
+		}
+		if s.buf.Len() > 0 {
+			s.buf.WriteByte('\n')
+		}
+		s.buf.WriteString(line)
+	}
+}
+
+// Close sends the metrics still queued and stops the client.
+func (s *StatsD) Close() error {
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	s.flush()
+	s.mu.Unlock()
+	return s.conn.Close()
+}
+
+func (s *StatsD) flushLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(statsdFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.flush()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// flush sends the queued metrics as one packet. s.mu must be held.
+func (s *StatsD) flush() {
+	if s.buf.Len() == 0 {
+		return
+	}
+	// Metrics are best effort, so a missing agent must not fail the run
+	if _, err := s.conn.Write(s.buf.Bytes()); err != nil && !s.failed {
+		s.failed = true
+		logger.Warn("Failed to send metrics to StatsD, further failures are not logged: %v", err)
+	}
+	s.buf.Reset()
+}
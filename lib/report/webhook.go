@@ -0,0 +1,90 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"percipio.com/gopi/lib/history"
+)
+
+// DegradationNotice summarizes a run that degraded against its baseline, for
+// posting to a webhook.
+type DegradationNotice struct {
+	RunID         string             `json:"runId"`
+	BaselineID    string             `json:"baselineId"`
+	CommitHash    string             `json:"commitHash"`
+	ShortHash     string             `json:"shortHash,omitempty"`
+	Branch        string             `json:"branch,omitempty"`
+	LatencyMetric string             `json:"latencyMetric"`
+	Endpoints     []DegradedEndpoint `json:"endpoints"`
+}
+
+// DegradedEndpoint is an endpoint that degraded, with its changes against the
+// baseline in percent.
+type DegradedEndpoint struct {
+	Endpoint            string  `json:"endpoint"`
+	LatencyIncrease     float64 `json:"latencyIncreasePct"`
+	ErrorRateIncrease   float64 `json:"errorRateIncreasePct"`
+	ThroughputDecrease  float64 `json:"throughputDecreasePct"`
+	SuccessRateDecrease float64 `json:"successRateDecreasePct"`
+	ThresholdPct        float64 `json:"thresholdPct"`
+}
+
+// NewDegradationNotice summarizes the endpoints of current that degraded,
+// sorted by name.
+func NewDegradationNotice(current *history.TestHistory) *DegradationNotice {
+	notice := &DegradationNotice{
+		RunID:         current.RunID,
+		BaselineID:    current.BaselineID,
+		CommitHash:    current.GitInfo.CommitHash,
+		ShortHash:     current.GitInfo.ShortHash,
+		Branch:        current.GitInfo.Branch,
+		LatencyMetric: current.LatencyMetric,
+		Endpoints:     []DegradedEndpoint{},
+	}
+	for endpoint, comparison := range current.Endpoints {
+		if !comparison.Degradation {
+			continue
+		}
+		notice.Endpoints = append(notice.Endpoints, DegradedEndpoint{
+			Endpoint:            endpoint,
+			LatencyIncrease:     comparison.Changes.LatencyIncrease,
+			ErrorRateIncrease:   comparison.Changes.ErrorRateIncrease,
+			ThroughputDecrease:  comparison.Changes.ThroughputDecrease,
+			SuccessRateDecrease: comparison.Changes.SuccessRateDecrease,
+			ThresholdPct:        comparison.ThresholdPct,
+		})
+	}
+	sort.Slice(notice.Endpoints, func(i, j int) bool {
+		return notice.Endpoints[i].Endpoint < notice.Endpoints[j].Endpoint
+	})
+	return notice
+}
+
+// SlackMessage encodes the notice as a Slack incoming webhook message, a line
+// per endpoint.
+func (n *DegradationNotice) SlackMessage() ([]byte, error) {
+	return json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: n.slackText()})
+}
+
+func (n *DegradationNotice) slackText() string {
+	var b strings.Builder
+	commit := n.ShortHash
+	if commit == "" {
+		commit = n.CommitHash
+	}
+	fmt.Fprintf(&b, ":warning: *Performance degradation detected* at commit `%s`", commit)
+	if n.Branch != "" {
+		fmt.Fprintf(&b, " on `%s`", n.Branch)
+	}
+	fmt.Fprintf(&b, " against baseline `%s`", n.BaselineID)
+	for _, e := range n.Endpoints {
+		fmt.Fprintf(&b, "\n• `%s`: latency (%s) %+.2f%%, error rate %+.2f%%, throughput %+.2f%%",
+			e.Endpoint, n.LatencyMetric, e.LatencyIncrease, e.ErrorRateIncrease, -e.ThroughputDecrease)
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+)
+
+// The error rate is checked over windows of at least abortWindow and
+// abortMinResults results, so a single early failure doesn't abort a run.
+const (
+	abortWindow     = time.Second
+	abortMinResults = 10
+)
+
+// errorRateWatch tracks the error rate of a run's results window by window,
+// to abort a run against a broken target early. A nil watch never trips.
+type errorRateWatch struct {
+	threshold float64
+	start     time.Time
+	total     int
+	failed    int
+}
+
+// newErrorRateWatch watches for an error rate above threshold percent,
+// returning nil when threshold is negative.
+func newErrorRateWatch(threshold float64) *errorRateWatch {
+	if threshold < 0 {
+		return nil
+	}
+	return &errorRateWatch{threshold: threshold, start: time.Now()}
+}
+
+// add counts result, returning an error once a window's error rate exceeds
+// the threshold. Failed requests and 5xx responses count as errors, while
+// injected faults are left out.
+func (w *errorRateWatch) add(result Result) error {
+	if w == nil || result.Fault != "" {
+		return nil
+	}
+	w.total++
+	if result.Error != nil || result.StatusCode >= 500 {
+		w.failed++
+	}
+
+	elapsed := time.Since(w.start)
+	if elapsed < abortWindow || w.total < abortMinResults {
+		return nil
+	}
+	rate := float64(w.failed) / float64(w.total) * 100
+	if rate > w.threshold {
+		return fmt.Errorf("error rate %.2f%% over the last %v exceeded %.2f%%",
+			rate, elapsed.Round(time.Millisecond), w.threshold)
+	}
+	w.start = time.Now()
+	w.total, w.failed = 0, 0
+	return nil
+}
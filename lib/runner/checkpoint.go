@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"percipio.com/gopi/lib/util"
+)
+
+// checkpointFile is the on-disk form of the steps a load test has completed.
+type checkpointFile struct {
+	TestType string           `json:"testType"`
+	Steps    []checkpointStep `json:"steps"`
+}
+
+type checkpointStep struct {
+	UserCount  int            `json:"userCount,omitempty"`
+	DataSize   int            `json:"dataSize,omitempty"`
+	StepNumber int            `json:"stepNumber"`
+	Journeys   map[string]int `json:"journeys,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Results    []rawResult    `json:"results"`
+
+	ScenarioSteps []ScenarioStepStats `json:"scenarioSteps,omitempty"`
+}
+
+// SaveCheckpoint records the completed steps of a testType load test at path,
+// so that an interrupted run can be resumed with LoadCheckpoint. The file is
+// replaced atomically, so an interruption mid-write keeps the previous
+// checkpoint intact.
+func SaveCheckpoint(path, testType string, steps []LoadTestResult) error {
+	checkpoint := checkpointFile{TestType: testType}
+	for _, step := range steps {
+		saved := checkpointStep{
+			UserCount:  step.UserCount,
+			DataSize:   step.DataSize,
+			StepNumber: step.StepNumber,
+			Journeys:   step.Journeys,
+			Timestamp:  step.Timestamp,
+
+			ScenarioSteps: step.ScenarioSteps,
+		}
+		for _, result := range step.Results {
+			saved.Results = append(saved.Results, newRawResult(result))
+		}
+		checkpoint.Steps = append(checkpoint.Steps, saved)
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, data)
+}
+
+// LoadCheckpoint reads the steps saved by SaveCheckpoint, checking that they
+// belong to a testType load test.
+func LoadCheckpoint(path, testType string) ([]LoadTestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint checkpointFile
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	if checkpoint.TestType != testType {
+		return nil, fmt.Errorf("checkpoint %s is for a %s test, not %s", path, checkpoint.TestType, testType)
+	}
+
+	steps := make([]LoadTestResult, 0, len(checkpoint.Steps))
+	for _, saved := range checkpoint.Steps {
+		step := LoadTestResult{
+			UserCount:  saved.UserCount,
+			DataSize:   saved.DataSize,
+			StepNumber: saved.StepNumber,
+			Journeys:   saved.Journeys,
+			Timestamp:  saved.Timestamp,
+
+			ScenarioSteps: saved.ScenarioSteps,
+		}
+		for _, raw := range saved.Results {
+			step.Results = append(step.Results, raw.result())
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
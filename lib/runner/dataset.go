@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// placeholder matches a ${name} placeholder in a task's URL, headers or body,
+// also with its braces escaped as joining a URL onto a base URL leaves them.
+var placeholder = regexp.MustCompile(`\$(?:\{|%7B)([\w.-]+)(?:\}|%7D)`)
+
+// DataSet parameterizes requests so they don't all hit the same cached
+// resource: each request takes a row, in turn or at random, and has the
+// ${column} placeholders in its URL, headers and body replaced with the
+// row's values.
+type DataSet struct {
+	columns []string
+	rows    [][]string
+	random  bool
+	next    atomic.Uint64
+}
+
+// LoadDataSet reads a CSV file whose header row names the columns, picking
+// its rows at random if random is set and round-robin otherwise.
+func LoadDataSet(path string, random bool) (*DataSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data file: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("data file needs a header row and at least one row of values")
+	}
+
+	columns := records[0]
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if columns[i] == "" {
+			return nil, fmt.Errorf("data file column %d has no name", i+1)
+		}
+	}
+	return &DataSet{columns: columns, rows: records[1:], random: random}, nil
+}
+
+// Placeholders returns the names of the ${name} placeholders in s, so that
+// mistyped names can be reported before the run.
+func Placeholders(s string) []string {
+	var names []string
+	for _, match := range placeholder.FindAllStringSubmatch(s, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// HasColumn reports whether the data set has a column called name.
+func (d *DataSet) HasColumn(name string) bool {
+	if d == nil {
+		return false
+	}
+	for _, column := range d.columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// apply fills the placeholders of task with the next row.
+func (d *DataSet) apply(task Task) Task {
+	if d == nil || len(d.rows) == 0 {
+		return task
+	}
+
+	var row []string
+	if d.random {
+		row = d.rows[rand.Intn(len(d.rows))]
+	} else {
+		row = d.rows[(d.next.Add(1)-1)%uint64(len(d.rows))]
+	}
+	values := make(map[string]string, len(d.columns))
+	for i, column := range d.columns {
+		if i < len(row) {
+			values[column] = row[i]
+		} else {
+			values[column] = ""
+		}
+	}
+	return fill(task, values)
+}
+
+// fill replaces the placeholders of task named in values. The task's URL is
+// left as written, so the results are still grouped by it, and the filled in
+// URL is sent through task.target.
+func fill(task Task, values map[string]string) Task {
+	if len(values) == 0 {
+		return task
+	}
+	pairs := make([]string, 0, 4*len(values))
+	for name, value := range values {
+		pairs = append(pairs, "${"+name+"}", value, "$%7B"+name+"%7D", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	targetURL := task.URL
+	if task.target.URL != "" {
+		targetURL = task.target.URL
+	}
+	task.target.URL = replacer.Replace(targetURL)
+	if len(task.Headers) > 0 {
+		headers := make(map[string]string, len(task.Headers))
+		for k, v := range task.Headers {
+			headers[replacer.Replace(k)] = replacer.Replace(v)
+		}
+		task.Headers = headers
+	}
+	if len(task.Body) > 0 {
+		task.Body = []byte(replacer.Replace(string(task.Body)))
+	}
+	return task
+}
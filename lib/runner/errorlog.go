@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"percipio.com/gopi/lib/logger"
+)
+
+// errorLogSampler logs only the first few failures of each endpoint so a
+// fully-down endpoint doesn't flood the output. Suppressed failures are still
+// counted and reported once the run is done.
+type errorLogSampler struct {
+	mu     sync.Mutex
+	limit  int
+	counts map[string]int
+}
+
+func newErrorLogSampler(limit int) *errorLogSampler {
+	return &errorLogSampler{
+		limit:  limit,
+		counts: make(map[string]int),
+	}
+}
+
+// Log logs the failure of result unless its endpoint has already logged the
+// limit. A limit of 0 logs every failure.
+func (s *errorLogSampler) Log(result Result) {
+	key := fmt.Sprintf("%s %s", result.Method, result.URL)
+
+	s.mu.Lock()
+	s.counts[key]++
+	count := s.counts[key]
+	s.mu.Unlock()
+
+	if s.limit > 0 && count > s.limit {
+		return
+	}
+
+	logger.Error("Request to %s failed: %v", key, result.Error)
+	if count == s.limit {
+		logger.Warn("Suppressing further failure logs for %s", key)
+	}
+}
+
+// Flush reports how many failures were left out of the log per endpoint.
+func (s *errorLogSampler) Flush() {
+	if s.limit <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.counts))
+	for key := range s.counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if suppressed := s.counts[key] - s.limit; suppressed > 0 {
+			logger.Error("Request to %s failed %d more times (not logged)", key, suppressed)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// rawResult is the on-disk form of a Result in a raw export. Request headers
+// and bodies are left out since they aren't needed to recompute statistics.
+type rawResult struct {
+	Name             string        `json:"name,omitempty"`
+	URL              string        `json:"url"`
+	Method           string        `json:"method"`
+	StatusCode       int           `json:"statusCode"`
+	Proto            string        `json:"proto,omitempty"`
+	Duration         time.Duration `json:"duration"`
+	TTFB             time.Duration `json:"ttfb,omitempty"`
+	ConnID           string        `json:"connId,omitempty"`
+	ConnReused       bool          `json:"connReused,omitempty"`
+	ConnWait         time.Duration `json:"connWait,omitempty"`
+	Phases           *Phases       `json:"phases,omitempty"`
+	BodyHash         string        `json:"bodyHash,omitempty"`
+	BodySize         int           `json:"bodySize,omitempty"`
+	BytesIn          int64         `json:"bytesIn,omitempty"`
+	BytesOut         int64         `json:"bytesOut,omitempty"`
+	CompressedSize   int           `json:"compressedSize,omitempty"`
+	UncompressedSize int           `json:"uncompressedSize,omitempty"`
+	Error            string        `json:"error,omitempty"`
+	FailedRule       string        `json:"failedRule,omitempty"`
+	TargetRPS        float64       `json:"targetRps,omitempty"`
+	Retries          int           `json:"retries,omitempty"`
+	Polls            int           `json:"polls,omitempty"`
+	Fault            string        `json:"fault,omitempty"`
+	Host             string        `json:"host,omitempty"`
+	Warmup           bool          `json:"warmup,omitempty"`
+	ThreadID         int           `json:"threadId"`
+	StartTime        time.Time     `json:"startTime"`
+	EndTime          time.Time     `json:"endTime"`
+}
+
+func newRawResult(result Result) rawResult {
+	raw := rawResult{
+		Name:             result.Name,
+		URL:              result.URL,
+		Method:           result.Method,
+		StatusCode:       result.StatusCode,
+		Proto:            result.Proto,
+		Duration:         result.Duration,
+		TTFB:             result.TTFB,
+		ConnID:           result.ConnID,
+		ConnReused:       result.ConnReused,
+		ConnWait:         result.ConnWait,
+		BodyHash:         result.BodyHash,
+		BodySize:         result.BodySize,
+		BytesIn:          result.BytesIn,
+		BytesOut:         result.BytesOut,
+		CompressedSize:   result.CompressedSize,
+		UncompressedSize: result.UncompressedSize,
+		FailedRule:       result.FailedRule,
+		TargetRPS:        result.TargetRPS,
+		Retries:          result.Retries,
+		Polls:            result.Polls,
+		Fault:            result.Fault,
+		Host:             result.Host,
+		Warmup:           result.Warmup,
+		ThreadID:         result.ThreadID,
+		StartTime:        result.StartTime,
+		EndTime:          result.EndTime,
+	}
+	if result.Phases != (Phases{}) {
+		raw.Phases = &result.Phases
+	}
+	if result.Error != nil {
+		raw.Error = result.Error.Error()
+	}
+	return raw
+}
+
+func (raw rawResult) result() Result {
+	result := Result{
+		Name:             raw.Name,
+		URL:              raw.URL,
+		Method:           raw.Method,
+		StatusCode:       raw.StatusCode,
+		Proto:            raw.Proto,
+		Duration:         raw.Duration,
+		TTFB:             raw.TTFB,
+		ConnID:           raw.ConnID,
+		ConnReused:       raw.ConnReused,
+		ConnWait:         raw.ConnWait,
+		BodyHash:         raw.BodyHash,
+		BodySize:         raw.BodySize,
+		BytesIn:          raw.BytesIn,
+		BytesOut:         raw.BytesOut,
+		CompressedSize:   raw.CompressedSize,
+		UncompressedSize: raw.UncompressedSize,
+		FailedRule:       raw.FailedRule,
+		TargetRPS:        raw.TargetRPS,
+		Retries:          raw.Retries,
+		Polls:            raw.Polls,
+		Fault:            raw.Fault,
+		Host:             raw.Host,
+		Warmup:           raw.Warmup,
+		ThreadID:         raw.ThreadID,
+		StartTime:        raw.StartTime,
+		EndTime:          raw.EndTime,
+	}
+	if raw.Phases != nil {
+		result.Phases = *raw.Phases
+	}
+	if raw.Error != "" {
+		result.Error = errors.New(raw.Error)
+	}
+	return result
+}
+
+// SaveRawResults writes every result of a run to path as newline-delimited
+// JSON, so runs from several machines can later be merged.
+func SaveRawResults(path string, results []Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		if err := enc.Encode(newRawResult(result)); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// LoadRawResults reads the results of a raw export written by SaveRawResults.
+func LoadRawResults(path string) ([]Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open raw results: %w", err)
+	}
+	defer f.Close()
+
+	var results []Result
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var raw rawResult
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+
+		results = append(results, raw.result())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results in %s", path)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"percipio.com/gopi/lib/validate"
+)
+
+// Extract captures a value from a scenario step's response so that the later
+// steps of the same journey can use it as a ${Name} placeholder, such as the
+// ID of a resource the step created.
+type Extract struct {
+	Name     string
+	JSONPath string         // Path of the value in a JSON body, such as "$.id"
+	Regex    *regexp.Regexp // Or a pattern for the body, capturing its first group or else the whole match
+}
+
+// extractValues applies extracts to a response body, failing if any of them
+// finds no value.
+func extractValues(extracts []Extract, body []byte) (map[string]string, error) {
+	var doc interface{}
+	decoded := false
+	values := make(map[string]string, len(extracts))
+	for _, e := range extracts {
+		if e.Regex != nil {
+			match := e.Regex.FindSubmatch(body)
+			if match == nil {
+				return nil, fmt.Errorf("extract %s: no match for %s", e.Name, e.Regex)
+			}
+			if len(match) > 1 {
+				values[e.Name] = string(match[1])
+			} else {
+				values[e.Name] = string(match[0])
+			}
+			continue
+		}
+
+		if !decoded {
+			var err error
+			if doc, err = validate.DecodeJSON(body); err != nil {
+				return nil, fmt.Errorf("extract %s: response is not valid JSON: %w", e.Name, err)
+			}
+			decoded = true
+		}
+		value, err := validate.Lookup(doc, e.JSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("extract %s: %w", e.Name, err)
+		}
+		switch v := value.(type) {
+		case nil:
+			return nil, fmt.Errorf("extract %s: %s is null", e.Name, e.JSONPath)
+		case string:
+			values[e.Name] = v
+		case json.Number:
+			values[e.Name] = v.String()
+		case bool:
+			values[e.Name] = strconv.FormatBool(v)
+		default:
+			raw, _ := json.Marshal(v)
+			values[e.Name] = string(raw)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// harSkippedHeaders are request headers a HAR capture records but the
+// client sets itself when the request is sent again.
+var harSkippedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Transfer-Encoding": true,
+}
+
+// harFile is the part of a HAR capture that describes its requests.
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Request         struct {
+		Method   string         `json:"method"`
+		URL      string         `json:"url"`
+		Headers  []harNameValue `json:"headers"`
+		PostData *struct {
+			MimeType string         `json:"mimeType"`
+			Text     string         `json:"text"`
+			Params   []harNameValue `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LoadHAR reads the requests of a HAR capture, such as one saved from a
+// browser's developer tools, as a recording to replay with their original
+// order and timing. If domain is set only requests to that host or its
+// subdomains are kept, timed from the first of them.
+func LoadHAR(path, domain string) ([]RequestRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+
+	entries := make([]harEntry, 0, len(har.Log.Entries))
+	for _, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			// Such as data: URLs and WebSocket upgrades
+			continue
+		}
+		if domain != "" && !matchesDomain(u.Hostname(), domain) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 && domain != "" {
+		return nil, fmt.Errorf("no HTTP requests to %s in HAR file", domain)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no HTTP requests to replay in HAR file")
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	records := make([]RequestRecord, 0, len(entries))
+	for _, entry := range entries {
+		request := entry.Request
+		record := RequestRecord{
+			Offset: entry.StartedDateTime.Sub(entries[0].StartedDateTime),
+			URL:    request.URL,
+			Method: request.Method,
+		}
+		for _, header := range request.Headers {
+			// HTTP/2 captures list pseudo-headers such as :authority
+			name := http.CanonicalHeaderKey(header.Name)
+			if strings.HasPrefix(name, ":") || harSkippedHeaders[name] {
+				continue
+			}
+			if record.Headers == nil {
+				record.Headers = make(map[string]string)
+			}
+			value := header.Value
+			if previous, ok := record.Headers[name]; ok {
+				// Repeated headers, such as the cookies of an HTTP/2 capture
+				separator := ", "
+				if name == "Cookie" {
+					separator = "; "
+				}
+				value = previous + separator + value
+			}
+			record.Headers[name] = value
+		}
+		if postData := request.PostData; postData != nil {
+			if postData.Text != "" {
+				record.Body = []byte(postData.Text)
+			} else if len(postData.Params) > 0 {
+				form := url.Values{}
+				for _, param := range postData.Params {
+					form.Add(param.Name, param.Value)
+				}
+				record.Body = []byte(form.Encode())
+			}
+			if _, ok := record.Headers["Content-Type"]; !ok && postData.MimeType != "" && record.Body != nil {
+				if record.Headers == nil {
+					record.Headers = make(map[string]string)
+				}
+				record.Headers["Content-Type"] = postData.MimeType
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host, domain string) bool {
+	host = strings.ToLower(host)
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
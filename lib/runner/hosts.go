@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// Target is one of the hosts an endpoint's requests are spread across.
+type Target struct {
+	Host string // Base URL of the host, recorded in Result.Host
+	URL  string // Endpoint URL on this host
+}
+
+// HostPool spreads the requests of a task across several hosts, such as the
+// instances behind a load balancer, in turn or at random.
+type HostPool struct {
+	targets []Target
+	random  bool
+	next    atomic.Uint64
+}
+
+// NewHostPool returns a pool over targets, picking them at random if random
+// is set and round-robin otherwise.
+func NewHostPool(targets []Target, random bool) *HostPool {
+	return &HostPool{targets: targets, random: random}
+}
+
+// pick returns the target for the next request, or the zero Target when the
+// pool is nil or empty and the task's own URL applies.
+func (p *HostPool) pick() Target {
+	if p == nil || len(p.targets) == 0 {
+		return Target{}
+	}
+	if p.random {
+		return p.targets[rand.Intn(len(p.targets))]
+	}
+	return p.targets[(p.next.Add(1)-1)%uint64(len(p.targets))]
+}
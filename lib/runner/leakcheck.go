@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"runtime"
+	"time"
+
+	"percipio.com/gopi/lib/logger"
+)
+
+// leakCheckInterval is how often the goroutine count is re-sampled while
+// waiting for goroutines to wind down at the end of a run.
+const leakCheckInterval = 50 * time.Millisecond
+
+// CloseIdleConnections closes idle keep-alive connections so their reader and
+// writer goroutines don't show up as leaks.
+func (r *Runner) CloseIdleConnections() {
+	r.client.CloseIdleConnections()
+}
+
+// CheckGoroutineLeaks waits up to grace for the goroutine count to return to
+// baseline, logging a warning with the surplus if it never does. It returns
+// the number of goroutines still running above the baseline.
+func CheckGoroutineLeaks(baseline int, grace time.Duration) int {
+	deadline := time.Now().Add(grace)
+	for {
+		leaked := runtime.NumGoroutine() - baseline
+		if leaked <= 0 {
+			logger.Debug("Goroutine count returned to baseline (%d)", baseline)
+			return 0
+		}
+		if time.Now().After(deadline) {
+			logger.Warn("Possible goroutine leak: %d goroutines still running above the baseline of %d after the run", leaked, baseline)
+			return leaked
+		}
+		time.Sleep(leakCheckInterval)
+	}
+}
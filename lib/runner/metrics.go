@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"percipio.com/gopi/lib/util"
+)
+
+// metricsBuckets are the upper bounds in seconds of the live latency
+// histogram, Prometheus' default buckets.
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics keeps live Prometheus metrics of the requests runners send, for
+// scraping while a long run is in progress, see SetMetrics. It serves them
+// over HTTP in the Prometheus text format. Requests are labeled by endpoint,
+// its name or else method and URL, and status, 0 when there was no response.
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[metricKey]int64
+	errors    map[metricKey]int64
+	latencies map[string]*metricsHistogram
+	inFlight  atomic.Int64
+}
+
+type metricKey struct {
+	endpoint string
+	status   int
+}
+
+// metricsHistogram counts the latencies of an endpoint's successful requests
+// in metricsBuckets.
+type metricsHistogram struct {
+	buckets []int64 // Not cumulative, the last one is +Inf
+	sum     float64
+	count   int64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[metricKey]int64),
+		errors:    make(map[metricKey]int64),
+		latencies: make(map[string]*metricsHistogram),
+	}
+}
+
+// SetMetrics makes the runner record every request it sends in metrics. Nil
+// stops the recording.
+func (r *Runner) SetMetrics(metrics *Metrics) {
+	r.metrics = metrics
+}
+
+// start records a request going out.
+func (m *Metrics) start() {
+	if m == nil {
+		return
+	}
+	m.inFlight.Add(1)
+}
+
+// done records the result of a request recorded by start, including its
+// retries. Requests cut short by the end of the run are only taken out of
+// flight.
+func (m *Metrics) done(ctx context.Context, result Result) {
+	if m == nil {
+		return
+	}
+	m.inFlight.Add(-1)
+	if cancelled(ctx, result) {
+		return
+	}
+
+	endpoint := result.Name
+	if endpoint == "" {
+		endpoint = result.Method + " " + result.URL
+	}
+	key := metricKey{endpoint: endpoint, status: result.StatusCode}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[key]++
+	if result.Error != nil {
+		m.errors[key]++
+		return
+	}
+
+	h := m.latencies[endpoint]
+	if h == nil {
+		h = &metricsHistogram{buckets: make([]int64, len(metricsBuckets)+1)}
+		m.latencies[endpoint] = h
+	}
+	seconds := result.Duration.Seconds()
+	h.buckets[sort.SearchFloat64s(metricsBuckets, seconds)]++
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(bw, "# HELP gopi_requests_total Requests sent, by endpoint and response status.")
+	fmt.Fprintln(bw, "# TYPE gopi_requests_total counter")
+	for _, key := range sortedKeys(m.requests) {
+		fmt.Fprintf(bw, "gopi_requests_total{%s} %d\n", key.labels(), m.requests[key])
+	}
+
+	fmt.Fprintln(bw, "# HELP gopi_request_errors_total Requests that failed or didn't pass validation, by endpoint and response status.")
+	fmt.Fprintln(bw, "# TYPE gopi_request_errors_total counter")
+	for _, key := range sortedKeys(m.errors) {
+		fmt.Fprintf(bw, "gopi_request_errors_total{%s} %d\n", key.labels(), m.errors[key])
+	}
+
+	fmt.Fprintln(bw, "# HELP gopi_request_duration_seconds Latency of successful requests, by endpoint.")
+	fmt.Fprintln(bw, "# TYPE gopi_request_duration_seconds histogram")
+	endpoints := make([]string, 0, len(m.latencies))
+	for endpoint := range m.latencies {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		h := m.latencies[endpoint]
+		label := fmt.Sprintf(`endpoint="%s"`, util.EscapeLabelValue(endpoint))
+		var cumulative int64
+		for i, count := range h.buckets {
+			cumulative += count
+			le := "+Inf"
+			if i < len(metricsBuckets) {
+				le = strconv.FormatFloat(metricsBuckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(bw, "gopi_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", label, le, cumulative)
+		}
+		fmt.Fprintf(bw, "gopi_request_duration_seconds_sum{%s} %g\n", label, h.sum)
+		fmt.Fprintf(bw, "gopi_request_duration_seconds_count{%s} %d\n", label, h.count)
+	}
+
+	fmt.Fprintln(bw, "# HELP gopi_requests_in_flight Requests sent and not yet completed.")
+	fmt.Fprintln(bw, "# TYPE gopi_requests_in_flight gauge")
+	fmt.Fprintf(bw, "gopi_requests_in_flight %d\n", m.inFlight.Load())
+}
+
+func (k metricKey) labels() string {
+	return fmt.Sprintf(`endpoint="%s",status="%d"`, util.EscapeLabelValue(k.endpoint), k.status)
+}
+
+func sortedKeys(counts map[metricKey]int64) []metricKey {
+	keys := make([]metricKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].status < keys[j].status
+	})
+	return keys
+}
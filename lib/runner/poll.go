@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"percipio.com/gopi/lib/validate"
+)
+
+// headerSource prefixes a Poll.URLFrom that names a response header.
+const headerSource = "header:"
+
+// Poll makes a task asynchronous, for APIs that accept a request with 202 and
+// a status URL to poll until the work is done. The status URL is taken from
+// the submit response and polled every Interval until a response passes
+// Until, and the submit and polling together are measured as one request.
+type Poll struct {
+	URLFrom  string          // Where the status URL is: "header:Location" or a JSON path such as "$.statusUrl"
+	Interval time.Duration   // Pause before each poll
+	Timeout  time.Duration   // Fail if not done this long after the submit started, 0 for no limit
+	Until    []validate.Rule // Checks a status response must pass for the work to be done
+	FailIf   []validate.Rule // Checks that, if all passed, mean the work failed
+}
+
+// statusURL extracts the URL to poll from the submit response, resolving it
+// against the URL the submit was sent to.
+func (p *Poll) statusURL(submitURL string, resp validate.Response) (string, error) {
+	var location string
+	if header, ok := strings.CutPrefix(p.URLFrom, headerSource); ok {
+		location = resp.Header.Get(header)
+	} else {
+		var doc interface{}
+		if err := json.Unmarshal(resp.Body, &doc); err != nil {
+			return "", fmt.Errorf("submit response is not valid JSON: %w", err)
+		}
+		value, err := validate.Lookup(doc, p.URLFrom)
+		if err != nil {
+			return "", err
+		}
+		location, _ = value.(string)
+	}
+	if location == "" {
+		return "", fmt.Errorf("submit response has no status URL in %s", p.URLFrom)
+	}
+
+	base, err := url.Parse(submitURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid status URL %q: %w", location, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// pollUntilDone polls the status URL of a submitted task until the work is
+// done, then extends result to cover the whole exchange. Polling failures are
+// recorded as the result's error.
+func (r *Runner) pollUntilDone(ctx context.Context, client *http.Client, task Task, submitURL string, submitted validate.Response, result *Result) {
+	defer func() {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+	}()
+
+	statusURL, err := task.Poll.statusURL(submitURL, submitted)
+	if err != nil {
+		result.Error = err
+		return
+	}
+
+	for {
+		if !sleep(ctx, task.Poll.Interval) {
+			result.Error = fmt.Errorf("cancelled after %d polls: %w", result.Polls, ctx.Err())
+			return
+		}
+		if task.Poll.Timeout > 0 && time.Since(result.StartTime) > task.Poll.Timeout {
+			result.Error = fmt.Errorf("not done after %v and %d polls", task.Poll.Timeout, result.Polls)
+			return
+		}
+		if !r.reserveRequest() {
+			result.Error = fmt.Errorf("request limit reached after %d polls", result.Polls)
+			return
+		}
+
+		resp, err := r.pollOnce(ctx, client, task, statusURL)
+		result.Polls++
+		if err != nil {
+			result.Error = fmt.Errorf("poll %d: %w", result.Polls, err)
+			return
+		}
+		if len(task.Poll.FailIf) > 0 && validate.Validate(task.Poll.FailIf, resp) == nil {
+			result.Error = fmt.Errorf("work failed after %d polls", result.Polls)
+			return
+		}
+		if validate.Validate(task.Poll.Until, resp) == nil {
+			return
+		}
+	}
+}
+
+// pollOnce fetches the status URL with the task's headers.
+func (r *Runner) pollOnce(ctx context.Context, client *http.Client, task Task, statusURL string) (validate.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL(statusURL), nil)
+	if err != nil {
+		return validate.Response{}, err
+	}
+	r.addHeaders(req, task)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return validate.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return validate.Response{}, fmt.Errorf("failed to read status response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return validate.Response{}, fmt.Errorf("status response %s", resp.Status)
+	}
+	return validate.Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Duration:   time.Since(start),
+	}, nil
+}
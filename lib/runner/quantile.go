@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"percipio.com/gopi/lib/util"
+)
+
+const defaultReservoirSize = 1024
+
+// latencyReservoir estimates latency percentiles over a stream of samples
+// while holding at most a fixed number of them, using reservoir sampling.
+type latencyReservoir struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	seen    int
+	size    int
+}
+
+func newLatencyReservoir(size int) *latencyReservoir {
+	return &latencyReservoir{
+		samples: make([]time.Duration, 0, size),
+		size:    size,
+	}
+}
+
+func (lr *latencyReservoir) Add(d time.Duration) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	lr.seen++
+	if len(lr.samples) < lr.size {
+		lr.samples = append(lr.samples, d)
+		return
+	}
+	if i := rand.Intn(lr.seen); i < lr.size {
+		lr.samples[i] = d
+	}
+}
+
+// Percentiles returns the estimated value for each of the given percentiles
+// (0-100), or zeros if no samples have been added yet. It computes them with
+// util.Percentile, like the final statistics, so live and final percentiles
+// agree.
+func (lr *latencyReservoir) Percentiles(ps ...float64) []time.Duration {
+	lr.mu.Lock()
+	sorted := make([]time.Duration, len(lr.samples))
+	copy(sorted, lr.samples)
+	lr.mu.Unlock()
+
+	values := make([]time.Duration, len(ps))
+	if len(sorted) == 0 {
+		return values
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	for i, p := range ps {
+		values[i] = util.Percentile(sorted, p)
+	}
+	return values
+}
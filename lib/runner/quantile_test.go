@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLatencyReservoirPercentiles checks the live percentiles against values
+// computed by hand with the R-7 method, the same as the final statistics.
+func TestLatencyReservoirPercentiles(t *testing.T) {
+	lr := newLatencyReservoir(defaultReservoirSize)
+	if got := lr.Percentiles(50); got[0] != 0 {
+		t.Errorf("empty reservoir P50 = %v, want 0", got[0])
+	}
+
+	// Added out of order, as Percentiles sorts its own copy
+	for _, v := range []time.Duration{30, 10, 40, 20} {
+		lr.Add(v * time.Millisecond)
+	}
+	got := lr.Percentiles(0, 50, 90, 100)
+	want := []time.Duration{10 * time.Millisecond, 25 * time.Millisecond, 37 * time.Millisecond, 40 * time.Millisecond}
+	for i, p := range []float64{0, 50, 90, 100} {
+		if got[i] != want[i] {
+			t.Errorf("P%v = %v, want %v", p, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"percipio.com/gopi/lib/logger"
+)
+
+// Bounds on the time buckets a linear ramp's results are split into, so that
+// each bucket has enough requests to be meaningful and the report stays short.
+const (
+	minRampBucket  = time.Second
+	maxRampBuckets = 60
+)
+
+// rampBucket collects the results of one stretch of a linear ramp.
+type rampBucket struct {
+	mu       sync.Mutex
+	results  []Result
+	capacity int
+	journeys map[string]int
+	steps    *stepTracker
+}
+
+func newRampBucket(capacity int) *rampBucket {
+	return &rampBucket{
+		capacity: capacity,
+		journeys: make(map[string]int),
+		steps:    newStepTracker(),
+	}
+}
+
+func (b *rampBucket) add(result Result) {
+	b.steps.add(result)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.results) < b.capacity {
+		b.results = append(b.results, result)
+	}
+}
+
+func (b *rampBucket) addJourney(scenario string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.journeys[scenario]++
+}
+
+// rampBuckets returns how many buckets a ramp of the given duration adding
+// users one at a time is split into: one per user count reached, unless that
+// makes buckets shorter than minRampBucket or more than maxRampBuckets.
+func rampBuckets(duration time.Duration, levels int) int {
+	return max(min(levels, maxRampBuckets, int(duration/minRampBucket)), 1)
+}
+
+// runLinearRamp grows the number of concurrent users from StartUsers to
+// MaxUsers one at a time, evenly over RampDuration, with no pause between
+// them. Results are collected in consecutive time buckets, returned as steps
+// whose UserCount is the number of users running at the end of the bucket,
+// so latency can be followed against concurrency as it rises. Ending ctx or
+// the idle timeout stops the ramp, and the bucket it stopped in is the last.
+func (r *Runner) runLinearRamp(ctx context.Context, config UserLoadConfig) []LoadTestResult {
+	levels := config.MaxUsers - config.StartUsers + 1
+	spawnEvery := config.RampDuration / time.Duration(levels)
+	bucketCount := rampBuckets(config.RampDuration, levels)
+	bucketLength := config.RampDuration / time.Duration(bucketCount)
+
+	logger.Info("Ramping linearly from %d to %d users over %v, reporting every %v",
+		config.StartUsers, config.MaxUsers, config.RampDuration, bucketLength.Round(time.Millisecond))
+
+	start := time.Now()
+	rampCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lastSuccess atomic.Int64
+	lastSuccess.Store(start.UnixNano())
+	var abortedAfter atomic.Int64
+	if config.IdleTimeout > 0 {
+		go watchIdle(rampCtx, config.IdleTimeout, &lastSuccess, func() {
+			abortedAfter.Store(int64(time.Since(start)))
+			cancel()
+		})
+	}
+
+	var users atomic.Int32
+	var totalRequests atomic.Int32
+	var current atomic.Pointer[rampBucket]
+	current.Store(newRampBucket(config.StartUsers * r.stepCount()))
+
+	record := func(result Result) {
+		if cancelled(ctx, result) {
+			return
+		}
+		if result.Error == nil && result.Fault == "" {
+			lastSuccess.Store(result.EndTime.UnixNano())
+		}
+		totalRequests.Add(1)
+		current.Load().add(result)
+	}
+	journey := func(scenario string) {
+		current.Load().addJourney(scenario)
+	}
+
+	// The spawner stays in wg until it is done adding users, so that the
+	// wait below can't start before the last of them is added
+	var wg sync.WaitGroup
+	spawn := func() {
+		userID := int(users.Add(1)) - 1
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runUser(ctx, rampCtx, userID, config.ThinkTime, record, journey)
+		}()
+	}
+	for i := 0; i < config.StartUsers; i++ {
+		spawn()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for level := 1; level < levels; level++ {
+			if !sleep(rampCtx, time.Until(start.Add(time.Duration(level)*spawnEvery))) {
+				return
+			}
+			spawn()
+		}
+	}()
+
+	var results []LoadTestResult
+	var buckets []*rampBucket
+	for bucketNumber := 0; bucketNumber < bucketCount; bucketNumber++ {
+		finished := sleep(rampCtx, time.Until(start.Add(time.Duration(bucketNumber+1)*bucketLength)))
+
+		// Results that arrive from now on belong to the next bucket
+		userCount := int(users.Load())
+		bucket := current.Load()
+		if finished && bucketNumber < bucketCount-1 {
+			current.Store(newRampBucket(userCount * r.stepCount()))
+		}
+		buckets = append(buckets, bucket)
+		results = append(results, LoadTestResult{
+			UserCount:  userCount,
+			Timestamp:  time.Now(),
+			StepNumber: bucketNumber,
+		})
+		logger.Info("Ramp %d/%d: %d concurrent users | Total reqs: %d | Elapsed: %.0fs",
+			bucketNumber+1, bucketCount, userCount, totalRequests.Load(), time.Since(start).Seconds())
+		if !finished {
+			break
+		}
+	}
+
+	aborted := time.Duration(abortedAfter.Load())
+	switch {
+	case aborted > 0:
+		logger.Warn("Ramp aborted after %v: no successful response for %v, target appears unresponsive",
+			aborted.Round(time.Second), config.IdleTimeout)
+	case ctx.Err() != nil:
+		logger.Warn("Ramp interrupted after %v", time.Since(start).Round(time.Second))
+	default:
+		logger.Info("Ramp completed, collecting results...")
+	}
+
+	// Let in-flight requests and journeys finish before reading the buckets
+	cancel()
+	wg.Wait()
+
+	for i, bucket := range buckets {
+		results[i].Results = bucket.results
+		results[i].Journeys = bucket.journeys
+		results[i].ScenarioSteps = bucket.steps.results()
+	}
+	results[len(results)-1].AbortedAfter = aborted
+	return results
+}
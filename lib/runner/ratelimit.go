@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter paces calls to Wait so they happen at a fixed aggregate rate.
+// A nil limiter doesn't limit at all.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+// Wait blocks until the next request slot is available, returning false if
+// ctx ended first.
+func (l *rateLimiter) Wait(ctx context.Context) bool {
+	if l == nil {
+		return ctx.Err() == nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(slot))
+}
@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"percipio.com/gopi/lib/logger"
+)
+
+// RequestRecord captures a request exactly as it was issued during a run,
+// along with when it was issued relative to the start of the run.
+type RequestRecord struct {
+	Offset  time.Duration     `json:"offset"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+}
+
+// NewRecording builds the ordered request sequence for a set of results. Each
+// request is recorded with the URL it was sent to, so a recording made with a
+// data set or several hosts replays without them.
+func NewRecording(results []Result) []RequestRecord {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	records := make([]RequestRecord, 0, len(sorted))
+	for _, result := range sorted {
+		var offset time.Duration
+		if len(records) > 0 {
+			offset = result.StartTime.Sub(sorted[0].StartTime)
+		}
+		url := result.TargetURL
+		if url == "" {
+			url = result.URL
+		}
+		records = append(records, RequestRecord{
+			Offset:  offset,
+			URL:     url,
+			Method:  result.Method,
+			Headers: result.Headers,
+			Body:    result.Body,
+		})
+	}
+	return records
+}
+
+func SaveRecording(path string, records []RequestRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func LoadRecording(path string) ([]RequestRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %w", err)
+	}
+
+	var records []RequestRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse replay file: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no requests recorded in replay file")
+	}
+
+	return records, nil
+}
+
+// Replay re-issues a recorded request sequence, sending each request at the
+// same offset from the start of the run as it was originally sent. Requests
+// are sent as recorded, without injected faults, data set values or retries.
+// At most the runner's worker count of requests are in flight at once, so a
+// request due while all of them are busy is sent late, when one completes.
+// Ending ctx stops the replay and cancels the requests in flight.
+func (r *Runner) Replay(ctx context.Context, records []RequestRecord) []Result {
+	logger.Info("Replaying %d recorded requests, at most %d at a time", len(records), r.workerCount)
+
+	results := make([]Result, len(records))
+	errorLog := newErrorLogSampler(r.errorLogMax)
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, max(r.workerCount, 1))
+	start := time.Now()
+
+	for i, record := range records {
+		if !r.reserveRequest() {
+			logger.Warn("Request limit of %d reached, stopping replay", r.requestLimit)
+			results = results[:i]
+			break
+		}
+
+		if !sleep(ctx, record.Offset-time.Since(start)) || !acquire(ctx, inFlight) {
+			logger.Warn("Replay interrupted after %d requests", i)
+			results = results[:i]
+			break
+		}
+
+		task := Task{
+			URL:     record.URL,
+			Method:  record.Method,
+			Headers: record.Headers,
+			Body:    record.Body,
+		}
+
+		wg.Add(1)
+		go func(i int, task Task) {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+			results[i] = r.replayRequest(ctx, task, i)
+			if results[i].Error != nil && !cancelled(ctx, results[i]) {
+				errorLog.Log(results[i])
+			}
+		}(i, task)
+	}
+
+	wg.Wait()
+	if ctx.Err() != nil {
+		completed := results[:0]
+		for _, result := range results {
+			if !cancelled(ctx, result) {
+				completed = append(completed, result)
+			}
+		}
+		results = completed
+	}
+	errorLog.Flush()
+	logger.Info("Replay completed in %v", time.Since(start))
+	return results
+}
+
+// replayRequest sends a recorded request once, as it was recorded.
+func (r *Runner) replayRequest(ctx context.Context, task Task, userID int) Result {
+	r.metrics.start()
+	result := r.sendRequest(ctx, r.client, task, userID)
+	r.finishRequest(ctx, result)
+	return result
+}
+
+// acquire takes a slot of sem, returning false if ctx ends first.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplayBoundsInFlight(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// All due at once, as a burst
+	records := make([]RequestRecord, 8)
+	for i := range records {
+		records[i] = RequestRecord{URL: server.URL, Method: http.MethodGet}
+	}
+
+	r := NewRunner(2, 1)
+	results := r.Replay(context.Background(), records)
+	r.CloseIdleConnections()
+
+	if len(results) != len(records) {
+		t.Fatalf("replayed %d requests, want %d", len(results), len(records))
+	}
+	for i, result := range results {
+		if result.Error != nil {
+			t.Errorf("request %d: %v", i, result.Error)
+		}
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("%d requests in flight at once, want at most the 2 workers", got)
+	}
+}
+
+func TestReplaySendsRecordedRequestOnce(t *testing.T) {
+	var hits atomic.Int32
+	var path atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		path.Store(req.URL.Path)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	r := NewRunner(1, 1)
+	r.SetRetry(RetryConfig{MaxRetries: 3})
+	r.SetDataSet(&DataSet{columns: []string{"id"}, rows: [][]string{{"2"}}})
+	records := []RequestRecord{{URL: server.URL + "/users/${id}", Method: http.MethodGet}}
+	results := r.Replay(context.Background(), records)
+	r.CloseIdleConnections()
+
+	if len(results) != 1 || results[0].Retries != 0 {
+		t.Fatalf("got %d results, want 1 without retries", len(results))
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+	if got := path.Load(); got != "/users/${id}" {
+		t.Errorf("sent to %v, want /users/${id} as recorded", got)
+	}
+}
+
+func TestNewRecordingUsesTargetURL(t *testing.T) {
+	start := time.Now()
+	records := NewRecording([]Result{
+		{URL: "http://a/users/${id}", TargetURL: "http://b/users/7", Method: http.MethodGet, StartTime: start.Add(time.Second)},
+		{URL: "http://a/health", Method: http.MethodGet, StartTime: start},
+	})
+
+	want := []RequestRecord{
+		{URL: "http://a/health", Method: http.MethodGet},
+		{Offset: time.Second, URL: "http://b/users/7", Method: http.MethodGet},
+	}
+	for i, record := range records {
+		if record.URL != want[i].URL || record.Offset != want[i].Offset {
+			t.Errorf("record %d = %s at %v, want %s at %v", i, record.URL, record.Offset, want[i].URL, want[i].Offset)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	JitterNone         = "none"
+	JitterFull         = "full"
+	JitterEqual        = "equal"
+	JitterDecorrelated = "decorrelated"
+)
+
+// RetryConfig controls how failed requests are retried. Backoff grows
+// exponentially from Backoff up to MaxBackoff, with Jitter spreading the
+// delays out so workers retrying at the same time don't hit the server in
+// synchronized bursts.
+type RetryConfig struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Jitter     string
+	BudgetPct  float64 // Retries allowed as a percentage of requests sent, 0 for no budget
+	// FinalLatency records only the last attempt's latency for a retried
+	// request, rather than the time from the first attempt to the last
+	FinalLatency bool
+}
+
+// delay returns how long to wait before the given retry attempt (starting at
+// 1). prev is the delay used before the previous attempt, which the
+// decorrelated strategy builds on.
+func (c RetryConfig) delay(attempt int, prev time.Duration) time.Duration {
+	if c.Backoff <= 0 {
+		return 0
+	}
+	ceiling := c.MaxBackoff
+	if ceiling < c.Backoff {
+		ceiling = c.Backoff
+	}
+
+	if c.Jitter == JitterDecorrelated {
+		// sleep = min(cap, random_between(base, prev * 3))
+		upper := max(prev*3, c.Backoff)
+		return min(ceiling, c.Backoff+randomDuration(upper-c.Backoff))
+	}
+
+	backoff := c.Backoff
+	for i := 1; i < attempt && backoff < ceiling; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, ceiling)
+
+	switch c.Jitter {
+	case JitterFull:
+		return randomDuration(backoff)
+	case JitterEqual:
+		return backoff/2 + randomDuration(backoff/2)
+	default:
+		return backoff
+	}
+}
+
+// RetryBudgetUsage reports how much of the retry budget a runner used.
+type RetryBudgetUsage struct {
+	Pct      float64 // Configured budget, 0 if there was none
+	Requests int     // Requests sent, not counting retries
+	Retries  int     // Retries the budget allowed
+	Denied   int     // Retries skipped because the budget was used up
+}
+
+// retryBudget caps retries at a percentage of the requests sent across the
+// run, so retries can't multiply the load on a struggling service. Every
+// request earns pct/100 of a retry and every retry spends one. A nil budget
+// allows every retry.
+type retryBudget struct {
+	pct float64
+
+	mu    sync.Mutex
+	usage RetryBudgetUsage
+}
+
+func newRetryBudget(pct float64) *retryBudget {
+	if pct <= 0 {
+		return nil
+	}
+	return &retryBudget{pct: pct, usage: RetryBudgetUsage{Pct: pct}}
+}
+
+// addRequest records a request sent for the first time.
+func (b *retryBudget) addRequest() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.usage.Requests++
+	b.mu.Unlock()
+}
+
+// tryRetry spends one retry from the budget, returning false if it has run
+// out.
+func (b *retryBudget) tryRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if float64(b.usage.Retries+1) > float64(b.usage.Requests)*b.pct/100 {
+		b.usage.Denied++
+		return false
+	}
+	b.usage.Retries++
+	return true
+}
+
+func (b *retryBudget) snapshot() RetryBudgetUsage {
+	if b == nil {
+		return RetryBudgetUsage{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usage
+}
+
+// shouldRetry reports whether result failed in a way that may succeed on a
+// retry: a network error or a 5xx response. Client errors are not retried.
+func shouldRetry(result Result) bool {
+	if result.StatusCode >= 500 {
+		return true
+	}
+	return result.Error != nil && result.StatusCode == 0
+}
+
+func randomDuration(upper time.Duration) time.Duration {
+	if upper <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(upper)))
+}
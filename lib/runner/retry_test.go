@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelayBounds(t *testing.T) {
+	const (
+		base       = 100 * time.Millisecond
+		ceiling    = time.Second
+		iterations = 1000
+	)
+	// backoff is the exponential delay before attempt, capped at ceiling
+	backoff := func(attempt int) time.Duration {
+		return min(base<<(attempt-1), ceiling)
+	}
+
+	tests := []struct {
+		jitter string
+		// bounds returns the inclusive range the delay before attempt, after
+		// a previous delay of prev, must fall in
+		bounds func(attempt int, prev time.Duration) (time.Duration, time.Duration)
+	}{
+		{JitterNone, func(attempt int, _ time.Duration) (time.Duration, time.Duration) {
+			return backoff(attempt), backoff(attempt)
+		}},
+		{JitterFull, func(attempt int, _ time.Duration) (time.Duration, time.Duration) {
+			return 0, backoff(attempt)
+		}},
+		{JitterEqual, func(attempt int, _ time.Duration) (time.Duration, time.Duration) {
+			return backoff(attempt) / 2, backoff(attempt)
+		}},
+		{JitterDecorrelated, func(_ int, prev time.Duration) (time.Duration, time.Duration) {
+			return base, min(ceiling, max(prev*3, base))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jitter, func(t *testing.T) {
+			config := RetryConfig{Backoff: base, MaxBackoff: ceiling, Jitter: tt.jitter}
+			for i := 0; i < iterations; i++ {
+				var prev time.Duration
+				// Enough attempts for the backoff to reach the ceiling
+				for attempt := 1; attempt <= 6; attempt++ {
+					lower, upper := tt.bounds(attempt, prev)
+					got := config.delay(attempt, prev)
+					if got < lower || got > upper {
+						t.Fatalf("attempt %d after %v: delay %v outside [%v, %v]", attempt, prev, got, lower, upper)
+					}
+					prev = got
+				}
+			}
+		})
+	}
+}
+
+func TestRetryDelayWithoutBackoff(t *testing.T) {
+	for _, jitter := range []string{JitterNone, JitterFull, JitterEqual, JitterDecorrelated} {
+		config := RetryConfig{MaxBackoff: time.Second, Jitter: jitter}
+		if got := config.delay(1, 0); got != 0 {
+			t.Errorf("%s: delay without a backoff = %v, want 0", jitter, got)
+		}
+	}
+}
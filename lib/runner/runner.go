@@ -1,45 +1,114 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"math/rand"
-
 	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/validate"
 )
 
 type Runner struct {
-	client       *http.Client
-	tasks        []Task
-	workerCount  int
-	requestCount int
+	client         *http.Client
+	transport      *http.Transport
+	tasks          []Task
+	workerCount    int
+	connLimit      int
+	idleConns      int
+	requestCount   int
+	requestLimit   int64
+	requestsSent   atomic.Int64
+	rate           float64
+	errorLogMax    int
+	retry          RetryConfig
+	retryBudget    *retryBudget
+	faults         FaultConfig
+	scenarios      []Scenario
+	offeredRPS     float64
+	abortRate      float64
+	warmup         int
+	aborted        error
+	resolve        map[string]string
+	throttle       Throttle
+	hashBodies     bool
+	acceptEncoding string
+	metrics        *Metrics
+	onResult       func(Result)
+	auth           string
+	noRedirects    bool
+	tlsConfig      *tls.Config
+	http2          bool
+	data           *DataSet
+
+	timeout             time.Duration
+	connectTimeout      time.Duration
+	tlsHandshakeTimeout time.Duration
 }
 
+// defaultTimeout bounds each request until SetTimeout changes it.
+const defaultTimeout = 30 * time.Second
+
+// idleConnTimeout is how long the workers' pool keeps an unused connection
+// open, as Go's default transport does.
+const idleConnTimeout = 90 * time.Second
+
+// ErrTimeout marks requests that ran out of time, connecting or waiting for
+// the response, so they can be told apart from refused or reset connections.
+var ErrTimeout = errors.New("request timed out")
+
 func NewRunner(threadCount, requestCount int) *Runner {
 	transport := &http.Transport{
 		MaxIdleConns:        threadCount,
 		MaxIdleConnsPerHost: threadCount,
+		IdleConnTimeout:     idleConnTimeout,
+		DialContext:         newDialContext(0, nil, Throttle{}),
 	}
 
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   time.Second * 30,
+		Timeout:   defaultTimeout,
 	}
 
 	return &Runner{
 		client:       client,
+		transport:    transport,
 		workerCount:  threadCount,
 		requestCount: requestCount,
+		abortRate:    -1,
+		timeout:      defaultTimeout,
 	}
 }
 
-func (r *Runner) Run() []Result {
+// Run sends requestCount requests to each task, split by weight, and returns
+// their results. Once ctx ends no further requests are dispatched, requests
+// in flight are cancelled and their failures dropped, and the results so far
+// are returned. The same happens when the run is aborted, see Aborted.
+func (r *Runner) Run(ctx context.Context) []Result {
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	r.aborted = nil
+
 	logger.Info("Starting benchmark with %d threads and %d requests per endpoint", r.workerCount, r.requestCount)
+	if r.connLimit > 0 {
+		logger.Info("Threads share at most %d connections per host", r.connLimit)
+	}
+	if r.idleConns > 0 {
+		logger.Info("Threads keep at most %d idle connections for reuse", r.idleConns)
+	}
 	logger.Info("Total endpoints to test: %d", len(r.tasks))
+	counts := r.requestCounts()
 
 	taskChan := make(chan Task)
 	resultChan := make(chan Result)
@@ -48,7 +117,7 @@ func (r *Runner) Run() []Result {
 	logger.Info("Launching %d worker goroutines", r.workerCount)
 	for i := 0; i < r.workerCount; i++ {
 		wg.Add(1)
-		go r.worker(i, taskChan, resultChan, &wg)
+		go r.worker(ctx, i, taskChan, resultChan, &wg)
 	}
 
 	go func() {
@@ -56,113 +125,382 @@ func (r *Runner) Run() []Result {
 		close(resultChan)
 	}()
 
+	limiter := newRateLimiter(r.rate)
 	go func() {
+		defer close(taskChan)
+
+		var dispatched int
+		var first time.Time
+		defer func() {
+			r.offeredRPS = 0
+			if span := time.Since(first); dispatched > 1 && span > 0 {
+				r.offeredRPS = float64(dispatched-1) / span.Seconds()
+			}
+		}()
+
+		send := func(task Task) bool {
+			if !r.reserveRequest() {
+				logger.Warn("Request limit of %d reached, not sending further requests", r.requestLimit)
+				return false
+			}
+			if !limiter.Wait(ctx) {
+				return false
+			}
+			select {
+			case taskChan <- task:
+			case <-ctx.Done():
+				return false
+			}
+			if dispatched == 0 {
+				first = time.Now()
+			}
+			dispatched++
+			return true
+		}
+
+		// Warmup requests go first, so the measured ones find the target
+		// warmed up
 		for _, task := range r.tasks {
-			for i := 0; i < r.requestCount; i++ {
-				taskChan <- task
+			task.warmup = true
+			for i := 0; i < r.warmup; i++ {
+				if !send(task) {
+					return
+				}
+			}
+		}
+		for t, task := range r.tasks {
+			for i := 0; i < counts[t]; i++ {
+				if !send(task) {
+					return
+				}
 			}
 		}
-		close(taskChan)
 	}()
 
-	totalRequests := len(r.tasks) * r.requestCount
-	completedRequests := 0
+	totalRequests := r.PlannedRequests()
+	var completedRequests, errorCount atomic.Int64
 	var results []Result
+	start := time.Now()
 
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	// Stopping the ticker doesn't close its channel, so the progress
+	// goroutine needs its own signal to exit
+	done := make(chan struct{})
+	defer close(done)
+
 	go func() {
-		for range ticker.C {
-			progress := float64(completedRequests) / float64(totalRequests) * 100
-			logger.Info("Progress: %.1f%% (%d/%d requests completed)\r",
-				progress, completedRequests, totalRequests)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				completed := completedRequests.Load()
+				progress := float64(completed) / float64(totalRequests) * 100
+				logger.Info("Progress: %.1f%% (%d/%d requests completed) | RPS: %.1f | Errors: %d",
+					progress, completed, totalRequests, float64(completed)/time.Since(start).Seconds(), errorCount.Load())
+			}
 		}
 	}()
 
+	errorLog := newErrorLogSampler(r.errorLogMax)
+	errorRate := newErrorRateWatch(r.abortRate)
 	for result := range resultChan {
 		results = append(results, result)
-		completedRequests++
+		completedRequests.Add(1)
 
 		if result.Error != nil {
-			logger.Error("Request to %s failed: %v", result.URL, result.Error)
+			errorCount.Add(1)
+			errorLog.Log(result)
+		}
+		if err := errorRate.add(result); err != nil && r.aborted == nil {
+			logger.Error("Aborting run: %v", err)
+			r.aborted = err
+			abort()
 		}
 	}
+	errorLog.Flush()
 
 	logger.Info("\nBenchmark completed. Total requests processed: %d", len(results))
 	return results
 }
 
-func (r *Runner) worker(id int, tasks <-chan Task, results chan<- Result, wg *sync.WaitGroup) {
+func (r *Runner) worker(ctx context.Context, id int, tasks <-chan Task, results chan<- Result, wg *sync.WaitGroup) {
 	defer wg.Done()
-	logger.Info("Worker %d started", id)
+	logger.Debug("Worker %d started", id)
 
 	for task := range tasks {
-		start := time.Now()
-		req, err := http.NewRequest(task.Method, task.URL, nil)
-		if err != nil {
-			logger.Error("Worker %d: Error making request to %s: %v", id, task.URL, err)
-			results <- Result{
-				URL:    task.URL,
-				Method: task.Method,
-				Error:  err,
-			}
+		result := r.executeRequest(ctx, r.client, task, id)
+		if cancelled(ctx, result) {
 			continue
 		}
-
-		for k, v := range task.Headers {
-			req.Header.Add(k, v)
+		result.TargetRPS = r.rate
+		// Failures are logged by Run, which samples them per endpoint, and
+		// successes only when debugging, as at volume the progress line is
+		// all that can be followed
+		if result.Error == nil {
+			logger.Debug("Worker %d: %s %s - Status: %d, Duration: %v",
+				id, task.Method, task.URL, result.StatusCode, result.Duration)
 		}
+		results <- result
+	}
 
-		resp, err := r.client.Do(req)
-		duration := time.Since(start)
+	logger.Debug("Worker %d finished", id)
+}
 
-		if err != nil {
-			logger.Error("Worker %d: Request to %s failed: %v", id, task.URL, err)
-			results <- Result{
-				URL:      task.URL,
-				Method:   task.Method,
-				Duration: duration,
-				Error:    err,
-			}
-			continue
-		}
+func (r *Runner) AddTask(task Task) {
+	r.tasks = append(r.tasks, task)
+}
 
-		logger.Info("Worker %d: %s %s - Status: %d, Duration: %v",
-			id, task.Method, task.URL, resp.StatusCode, duration)
+// SetRequestLimit caps the total number of requests the runner will send
+// across all runs. A limit of 0 means unlimited.
+func (r *Runner) SetRequestLimit(limit int) {
+	r.requestLimit = int64(limit)
+}
 
-		results <- Result{
-			URL:        task.URL,
-			Method:     task.Method,
-			StatusCode: resp.StatusCode,
-			Duration:   duration,
-		}
-		resp.Body.Close()
+// SetRate caps how many requests per second Run dispatches across all
+// workers. A rate of 0 dispatches as fast as the workers allow.
+func (r *Runner) SetRate(rps float64) {
+	r.rate = rps
+}
+
+// SetErrorLogLimit caps how many failures are logged per endpoint. Further
+// failures are only counted. A limit of 0 logs every failure.
+func (r *Runner) SetErrorLogLimit(limit int) {
+	r.errorLogMax = limit
+}
+
+// OfferedRPS returns the rate at which the last Run handed requests to its
+// workers. Unlike the achieved RPS of the responses, this measures whether
+// the generator itself kept up with the configured rate. It is 0 when fewer
+// than two requests were sent.
+func (r *Runner) OfferedRPS() float64 {
+	return r.offeredRPS
+}
+
+// SetAbortOnErrorRate makes Run stop early once the error rate of the
+// responses over a recent window exceeds pct percent. A negative pct never
+// aborts.
+func (r *Runner) SetAbortOnErrorRate(pct float64) {
+	r.abortRate = pct
+}
+
+// Aborted returns why the last Run was stopped early by
+// SetAbortOnErrorRate, or nil if it wasn't.
+func (r *Runner) Aborted() error {
+	return r.aborted
+}
+
+// SetWarmup makes Run send n warmup requests to each task before the
+// measured ones. Their results are marked Warmup so statistics leave them out.
+func (r *Runner) SetWarmup(n int) {
+	r.warmup = n
+}
+
+// PlannedRequests returns how many requests Run sends, warmups included, when
+// it isn't stopped early or capped by the request limit.
+func (r *Runner) PlannedRequests() int {
+	return len(r.tasks) * (r.requestCount + r.warmup)
+}
+
+// SetTimeout bounds each request from sending it to reading the response, in
+// every test mode. 0 means no limit.
+func (r *Runner) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+	r.client.Timeout = timeout
+}
+
+// SetConnectTimeouts bounds how long establishing a connection may take,
+// separately from the overall request timeout: connect covers the TCP (or
+// Unix socket) dial and tlsHandshake the TLS handshake. 0 means no limit of
+// its own.
+func (r *Runner) SetConnectTimeouts(connect, tlsHandshake time.Duration) {
+	r.connectTimeout = connect
+	r.tlsHandshakeTimeout = tlsHandshake
+	r.transport.DialContext = newDialContext(connect, r.resolve, r.throttle)
+	r.transport.TLSHandshakeTimeout = tlsHandshake
+}
+
+// SetTLSConfig sets the TLS client config of every connection, such as extra
+// trusted CAs. nil uses the defaults.
+func (r *Runner) SetTLSConfig(tlsConfig *tls.Config) {
+	r.tlsConfig = tlsConfig
+	r.transport.TLSClientConfig = tlsConfig
+}
+
+// SetHTTP2 makes connections to HTTPS endpoints negotiate HTTP/2, which the
+// custom dialer otherwise prevents. Requests are then multiplexed over fewer
+// connections, so compare connection reuse between runs with the same setting.
+func (r *Runner) SetHTTP2(enabled bool) {
+	r.http2 = enabled
+	r.transport.ForceAttemptHTTP2 = enabled
+}
+
+// SetFollowRedirects controls whether redirects are followed. When they
+// aren't, a redirect is measured and recorded with its own 3xx status rather
+// than that of the response it leads to.
+func (r *Runner) SetFollowRedirects(follow bool) {
+	r.noRedirects = !follow
+	r.client.CheckRedirect = r.checkRedirect()
+}
+
+// checkRedirect returns the CheckRedirect policy for the runner's clients.
+func (r *Runner) checkRedirect() func(*http.Request, []*http.Request) error {
+	if !r.noRedirects {
+		return nil
+	}
+	return func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
 	}
+}
 
-	logger.Info("Worker %d finished", id)
+// SetAuthorization sends auth as the Authorization header of every request
+// that doesn't set its own. Unlike task headers it isn't kept in results or
+// recordings, so credentials passed this way don't end up in files.
+func (r *Runner) SetAuthorization(auth string) {
+	r.auth = auth
 }
 
-func (r *Runner) AddTask(task Task) {
-	r.tasks = append(r.tasks, task)
+// SetDataSet fills the ${name} placeholders of every request from data, nil
+// to send the tasks as written.
+func (r *Runner) SetDataSet(data *DataSet) {
+	r.data = data
+}
+
+// SetResolve overrides host resolution like curl's --resolve: requests to a
+// host:port key in overrides connect to the ip:port it maps to, while keeping
+// their original Host header and TLS server name.
+func (r *Runner) SetResolve(overrides map[string]string) {
+	r.resolve = overrides
+	r.transport.DialContext = newDialContext(r.connectTimeout, overrides, r.throttle)
+}
+
+// SetThrottle simulates a slow client network on every connection. The delay
+// it adds is part of the measured latencies.
+func (r *Runner) SetThrottle(throttle Throttle) {
+	r.throttle = throttle
+	r.transport.DialContext = newDialContext(r.connectTimeout, r.resolve, throttle)
+}
+
+// SetConnectionLimit bounds how many connections the workers share per host,
+// like a client with a fixed-size connection pool. Requests beyond the limit
+// wait for a free connection, which shows up in Result.ConnWait. A limit of 0
+// lets every worker keep its own connection.
+func (r *Runner) SetConnectionLimit(limit int) {
+	r.connLimit = limit
+	r.sizePool()
+}
+
+// SetIdleConnections bounds how many unused connections the workers keep open
+// for reuse, independently of the number of workers and the connection
+// limit. Requests that find no idle connection open a new one, so a pool
+// smaller than the concurrency shows up as connection churn. A size of 0
+// keeps one idle connection per worker, or per connection when limited.
+func (r *Runner) SetIdleConnections(size int) {
+	r.idleConns = size
+	r.sizePool()
+}
+
+// sizePool applies the connection limit and idle pool size to the transport.
+func (r *Runner) sizePool() {
+	r.transport.MaxConnsPerHost = r.connLimit
+	switch {
+	case r.idleConns > 0:
+		r.transport.MaxIdleConns = r.idleConns
+		r.transport.MaxIdleConnsPerHost = r.idleConns
+	case r.connLimit > 0:
+		r.transport.MaxIdleConns = r.workerCount
+		r.transport.MaxIdleConnsPerHost = r.connLimit
+	default:
+		r.transport.MaxIdleConns = r.workerCount
+		r.transport.MaxIdleConnsPerHost = r.workerCount
+	}
+}
+
+// SetConsistencyCheck makes the runner read every response body and record
+// its hash and size, so that responses to identical requests can be compared.
+func (r *Runner) SetConsistencyCheck(enabled bool) {
+	r.hashBodies = enabled
 }
 
-func (r *Runner) RunUserLoadTest(config UserLoadConfig) []LoadTestResult {
-	var results []LoadTestResult
+// SetFaults configures fault injection. Injected requests are tagged with the
+// fault in their Result.
+func (r *Runner) SetFaults(faults FaultConfig) {
+	r.faults = faults
+}
+
+// SetOnResult makes the runner call fn with the result of every request as
+// it completes, including retries, from the goroutine that sent it. Requests
+// cut short by the end of the run are left out.
+func (r *Runner) SetOnResult(fn func(result Result)) {
+	r.onResult = fn
+}
+
+// SetRetry configures retries of failed requests. Every retry counts towards
+// the request limit and, if retry.BudgetPct is set, the retry budget.
+func (r *Runner) SetRetry(retry RetryConfig) {
+	r.retry = retry
+	r.retryBudget = newRetryBudget(retry.BudgetPct)
+}
+
+// RetryBudgetUsage returns how much of the retry budget has been used. It is
+// zero when there is no budget.
+func (r *Runner) RetryBudgetUsage() RetryBudgetUsage {
+	return r.retryBudget.snapshot()
+}
+
+// reserveRequest claims one request from the request limit, returning false
+// once the limit has been reached.
+func (r *Runner) reserveRequest() bool {
+	if r.requestLimit <= 0 {
+		return true
+	}
+	return r.requestsSent.Add(1) <= r.requestLimit
+}
+
+// RunUserLoadTest runs steps of growing numbers of concurrent users, or a
+// linear ramp if config.RampDuration is set. Ending ctx interrupts the current
+// step, whose results so far are returned as its last, and cancels the
+// requests in flight.
+func (r *Runner) RunUserLoadTest(ctx context.Context, config UserLoadConfig) []LoadTestResult {
+	if config.RampDuration > 0 {
+		return r.runLinearRamp(ctx, config)
+	}
+
+	results := append([]LoadTestResult(nil), config.Completed...)
 	currentUsers := config.StartUsers
 	totalSteps := (config.MaxUsers-config.StartUsers)/config.StepUsers + 1
 
 	logger.Info("Starting load test with %d steps", totalSteps)
+	if len(results) > 0 {
+		currentUsers = results[len(results)-1].UserCount + config.StepUsers
+	}
 
-	for stepNumber := 0; currentUsers <= config.MaxUsers; stepNumber++ {
+	for stepNumber := len(results); currentUsers <= config.MaxUsers; stepNumber++ {
 		logger.Info("\nStep %d/%d: Testing with %d concurrent users",
 			stepNumber+1, totalSteps, currentUsers)
 
-		ctx, cancel := context.WithTimeout(context.Background(), config.DurationPerStep)
-		resultChan := make(chan Result, currentUsers*len(r.tasks))
+		stepStart := time.Now()
+		stepCtx, cancel := context.WithTimeout(ctx, config.DurationPerStep)
+		resultChan := make(chan Result, currentUsers*r.stepCount())
 		var activeUsers atomic.Int32
 		var totalRequests atomic.Int32
 		var wg sync.WaitGroup
+		latencies := newLatencyReservoir(defaultReservoirSize)
+		var journeysMu sync.Mutex
+		journeys := make(map[string]int)
+		scenarioSteps := newStepTracker()
+		var lastSuccess atomic.Int64
+		lastSuccess.Store(stepStart.UnixNano())
+		var abortedAfter atomic.Int64
+
+		if config.IdleTimeout > 0 {
+			go watchIdle(stepCtx, config.IdleTimeout, &lastSuccess, func() {
+				abortedAfter.Store(int64(time.Since(stepStart)))
+				cancel()
+			})
+		}
 
 		// Progress monitoring
 		go func() {
@@ -172,16 +510,17 @@ func (r *Runner) RunUserLoadTest(config UserLoadConfig) []LoadTestResult {
 
 			for {
 				select {
-				case <-ctx.Done():
+				case <-stepCtx.Done():
 					return
 				case <-ticker.C:
 					reqs := totalRequests.Load()
 					active := activeUsers.Load()
 					elapsed := time.Since(start).Seconds()
 					rps := float64(reqs) / elapsed
+					percentiles := latencies.Percentiles(50, 95)
 
-					logger.Info("Progress - Active: %d users | Total reqs: %d | RPS: %.2f | Elapsed: %.0fs",
-						active, reqs, rps, elapsed)
+					logger.Info("Progress - Active: %d users | Total reqs: %d | RPS: %.2f | P50: %v | P95: %v | Elapsed: %.0fs",
+						active, reqs, rps, percentiles[0].Round(10*time.Microsecond), percentiles[1].Round(10*time.Microsecond), elapsed)
 				}
 			}
 		}()
@@ -192,45 +531,59 @@ func (r *Runner) RunUserLoadTest(config UserLoadConfig) []LoadTestResult {
 			go func(userID int) {
 				defer wg.Done()
 
-				client := &http.Client{
-					Transport: &http.Transport{
-						MaxIdleConns:        1,
-						MaxIdleConnsPerHost: 1,
-						IdleConnTimeout:     30 * time.Second,
-					},
-					Timeout: 10 * time.Second,
-				}
-
 				activeUsers.Add(1)
 				defer activeUsers.Add(-1)
 
-				// Stagger start
-				time.Sleep(time.Duration(userID*100) * time.Millisecond)
+				record := func(result Result) {
+					if cancelled(ctx, result) {
+						return
+					}
+					scenarioSteps.add(result)
+					if result.Error == nil {
+						latencies.Add(result.Duration)
+						if result.Fault == "" {
+							lastSuccess.Store(result.EndTime.UnixNano())
+						}
+					}
 
-				for {
 					select {
-					case <-ctx.Done():
-						return
+					case resultChan <- result:
+						totalRequests.Add(1)
 					default:
-						task := r.tasks[rand.Intn(len(r.tasks))]
-						result := r.executeRequest(client, task, userID)
+					}
+				}
 
-						select {
-						case resultChan <- result:
-							totalRequests.Add(1)
-						default:
-						}
+				journey := func(scenario string) {
+					journeysMu.Lock()
+					journeys[scenario]++
+					journeysMu.Unlock()
+				}
 
-						// Randomized think time between 100ms and 1s
-						time.Sleep(time.Duration(100+rand.Intn(900)) * time.Millisecond)
-					}
+				// Stagger start
+				if !sleep(stepCtx, time.Duration(userID*100)*time.Millisecond) {
+					return
 				}
+				r.runUser(ctx, stepCtx, userID, config.ThinkTime, record, journey)
 			}(i)
 		}
 
 		// Wait for step duration
-		<-ctx.Done()
-		logger.Info("Step %d completed, collecting results...", stepNumber+1)
+		<-stepCtx.Done()
+		aborted := time.Duration(abortedAfter.Load())
+		switch {
+		case aborted > 0:
+			logger.Warn("Step %d aborted after %v: no successful response for %v, target appears unresponsive",
+				stepNumber+1, aborted.Round(time.Second), config.IdleTimeout)
+		case ctx.Err() != nil:
+			logger.Warn("Step %d interrupted after %v", stepNumber+1, time.Since(stepStart).Round(time.Second))
+		default:
+			logger.Info("Step %d completed, collecting results...", stepNumber+1)
+		}
+
+		// Let in-flight requests and journeys finish before closing the
+		// channel they report to
+		cancel()
+		wg.Wait()
 
 		// Collect results
 		close(resultChan)
@@ -240,19 +593,31 @@ func (r *Runner) RunUserLoadTest(config UserLoadConfig) []LoadTestResult {
 		}
 
 		results = append(results, LoadTestResult{
-			UserCount:  currentUsers,
-			Results:    stepResults,
-			Timestamp:  time.Now(),
-			StepNumber: stepNumber,
+			UserCount:    currentUsers,
+			Results:      stepResults,
+			Journeys:     journeys,
+			Timestamp:    time.Now(),
+			StepNumber:   stepNumber,
+			AbortedAfter: aborted,
+
+			ScenarioSteps: scenarioSteps.results(),
 		})
 
-		cancel()
-		wg.Wait()
+		// An unfinished step isn't checkpointed, so that a resumed run
+		// repeats it
+		if aborted > 0 || ctx.Err() != nil {
+			break
+		}
+		if config.OnStep != nil {
+			config.OnStep(results)
+		}
 
 		// Prepare for next step
 		if currentUsers < config.MaxUsers {
 			logger.Info("Cooling down before next step (5 seconds)...")
-			time.Sleep(5 * time.Second)
+			if !sleep(ctx, 5*time.Second) {
+				break
+			}
 			currentUsers += config.StepUsers
 		} else {
 			break
@@ -262,31 +627,127 @@ func (r *Runner) RunUserLoadTest(config UserLoadConfig) []LoadTestResult {
 	return results
 }
 
-func (r *Runner) RunDataLoadTest(config DataLoadConfig) []LoadTestResult {
-	var results []LoadTestResult
+// runUser plays one virtual user, with a client of its own, until stop ends
+// or the request limit is reached. Requests are sent with ctx, each result is
+// passed to record and journey is called for every scenario journey the user
+// completes.
+func (r *Runner) runUser(ctx, stop context.Context, userID int, think ThinkTime, record func(Result), journey func(scenario string)) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:        1,
+			MaxIdleConnsPerHost: 1,
+			IdleConnTimeout:     30 * time.Second,
+			DialContext:         newDialContext(r.connectTimeout, r.resolve, r.throttle),
+			TLSHandshakeTimeout: r.tlsHandshakeTimeout,
+			TLSClientConfig:     r.tlsConfig.Clone(),
+			ForceAttemptHTTP2:   r.http2,
+		},
+		Timeout:       r.timeout,
+		CheckRedirect: r.checkRedirect(),
+	}
+	defer client.CloseIdleConnections()
+
+	for stop.Err() == nil {
+		if len(r.scenarios) > 0 {
+			scenario := r.pickScenario()
+			completed, more := r.runScenario(ctx, stop, client, scenario, userID, think, record)
+			if !more {
+				return
+			}
+			if completed {
+				journey(scenario.Name)
+			}
+			continue
+		}
+
+		if !r.reserveRequest() {
+			return
+		}
+		record(r.executeRequest(ctx, client, r.pickTask(), userID))
+
+		sleep(stop, think.Next())
+	}
+}
+
+// sleep pauses for d, returning false if ctx ended first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// cancelled reports whether result failed because ctx ended, rather than
+// because of the target, so it shouldn't be counted.
+func cancelled(ctx context.Context, result Result) bool {
+	return result.Error != nil && ctx.Err() != nil
+}
+
+// watchIdle calls abort once no successful response has been recorded in
+// lastSuccess (Unix nanoseconds) for idleTimeout, unless ctx ends first.
+func watchIdle(ctx context.Context, idleTimeout time.Duration, lastSuccess *atomic.Int64, abort func()) {
+	ticker := time.NewTicker(max(min(idleTimeout/4, time.Second), time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, lastSuccess.Load())) >= idleTimeout {
+				abort()
+				return
+			}
+		}
+	}
+}
+
+// RunDataLoadTest runs standard tests at growing data sizes. Ending ctx
+// interrupts the current step, whose results so far are returned as its last.
+func (r *Runner) RunDataLoadTest(ctx context.Context, config DataLoadConfig) []LoadTestResult {
+	results := append([]LoadTestResult(nil), config.Completed...)
 	currentSize := config.InitialDataSize
+	if len(results) > 0 {
+		currentSize = int(float64(results[len(results)-1].DataSize) * config.DataSizeMultiplier)
+	}
 
-	for step := 0; step < config.StepsCount && currentSize <= config.MaxDataSize; step++ {
+	for step := len(results); step < config.StepsCount && currentSize <= config.MaxDataSize; step++ {
 		logger.Info("Testing with data size: %d records...", currentSize)
 
 		// Adjust request count based on data size
 		originalRequestCount := r.requestCount
 		r.requestCount = calculateRequestCount(currentSize)
 
-		testResults := r.Run()
+		testResults := r.Run(ctx)
+		// Reset request count
+		r.requestCount = originalRequestCount
 
 		results = append(results, LoadTestResult{
-			DataSize:  currentSize,
-			Results:   testResults,
-			Timestamp: time.Now(),
+			DataSize:   currentSize,
+			Results:    testResults,
+			Timestamp:  time.Now(),
+			StepNumber: step,
 		})
-
-		// Reset request count
-		r.requestCount = originalRequestCount
+		if ctx.Err() != nil {
+			logger.Warn("Data load test interrupted at data size %d", currentSize)
+			break
+		}
+		if config.OnStep != nil {
+			config.OnStep(results)
+		}
 
 		logger.Info("Simulating data growth...")
 		currentSize = int(float64(currentSize) * config.DataSizeMultiplier)
-		time.Sleep(2 * time.Second) // Cool down period
+		if !sleep(ctx, 2*time.Second) { // Cool down period
+			break
+		}
 	}
 
 	return results
@@ -305,50 +766,223 @@ func calculateRequestCount(dataSize int) int {
 	return 10
 }
 
-func (r *Runner) executeRequest(client *http.Client, task Task, userID int) Result {
+// executeRequest sends the task, retrying network errors and 5xx responses
+// according to the retry config. The result is that of the final attempt,
+// with its duration covering every attempt and the backoff between them
+// unless the config asks for the final attempt's alone.
+func (r *Runner) executeRequest(ctx context.Context, client *http.Client, task Task, userID int) Result {
+	task.fault = r.faults.pick()
+	task.target = task.Hosts.pick()
+	// Extracted values take precedence over data columns of the same name
+	task = fill(task, task.vars)
+	task = r.data.apply(task)
+	r.metrics.start()
+	result := r.sendRequest(ctx, client, task, userID)
+	r.retryBudget.addRequest()
+
+	var delay time.Duration
+	for attempt := 1; attempt <= r.retry.MaxRetries && shouldRetry(result); attempt++ {
+		if !r.retryBudget.tryRetry() || !r.reserveRequest() {
+			break
+		}
+		delay = r.retry.delay(attempt, delay)
+		if !sleep(ctx, delay) {
+			break
+		}
+
+		retried := r.sendRequest(ctx, client, task, userID)
+		if !r.retry.FinalLatency {
+			retried.StartTime = result.StartTime
+			retried.Duration = retried.EndTime.Sub(result.StartTime)
+		}
+		retried.Retries = attempt
+		result = retried
+	}
+
+	r.finishRequest(ctx, result)
+	return result
+}
+
+// finishRequest reports a completed request to the metrics and the result
+// callback.
+func (r *Runner) finishRequest(ctx context.Context, result Result) {
+	r.metrics.done(ctx, result)
+	if r.onResult != nil && !cancelled(ctx, result) {
+		r.onResult(result)
+	}
+}
+
+func (r *Runner) sendRequest(ctx context.Context, client *http.Client, task Task, userID int) Result {
 	start := time.Now()
+	result := Result{
+		Name:      task.Name,
+		URL:       task.URL,
+		Method:    task.Method,
+		Headers:   task.Headers,
+		Body:      task.Body,
+		Host:      task.target.Host,
+		Warmup:    task.warmup,
+		ThreadID:  userID,
+		StartTime: start,
+	}
 
-	req, err := http.NewRequest(task.Method, task.URL, nil)
+	targetURL := task.URL
+	if task.target.URL != "" {
+		targetURL = task.target.URL
+	}
+	result.TargetURL = targetURL
+	var reqBody io.Reader
+	if len(task.Body) > 0 {
+		reqBody = bytes.NewReader(task.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, task.Method, requestURL(targetURL), reqBody)
 	if err != nil {
-		return Result{
-			URL:       task.URL,
-			Method:    task.Method,
-			Error:     err,
-			ThreadID:  userID,
-			StartTime: start,
-			EndTime:   time.Now(),
+		result.Error = err
+		result.EndTime = time.Now()
+		return result
+	}
+
+	r.addHeaders(req, task)
+	if reqBody != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if encoding := req.Header.Get("Content-Encoding"); reqBody != nil && encoding != "" {
+		encoded, err := encodeBody(task.Body, encoding)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to encode request body: %w", err)
+			result.EndTime = time.Now()
+			return result
+		}
+		req.Body = io.NopCloser(bytes.NewReader(encoded))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(encoded)), nil
 		}
+		req.ContentLength = int64(len(encoded))
+	}
+	if r.acceptEncoding != "" && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", r.acceptEncoding)
 	}
 
-	// Add headers
-	for k, v := range task.Headers {
-		req.Header.Add(k, v)
+	if task.fault != "" {
+		injectFault(req, task.fault)
+		result.Fault = task.fault
+	}
+
+	if req.ContentLength > 0 {
+		result.BytesOut = req.ContentLength
 	}
 
+	trace := &requestTrace{}
+	req = trace.attach(req)
+
 	// Execute request
 	resp, err := client.Do(req)
 	now := time.Now()
+	result.Duration = now.Sub(start)
+	result.EndTime = now
 
 	if err != nil {
-		return Result{
-			URL:       task.URL,
-			Method:    task.Method,
-			Error:     err,
-			Duration:  now.Sub(start),
-			ThreadID:  userID,
-			StartTime: start,
-			EndTime:   now,
-		}
+		result.Error = timeoutError(err)
+		return result
 	}
 	defer resp.Body.Close()
 
-	return Result{
-		URL:        task.URL,
-		Method:     task.Method,
+	result.StatusCode = resp.StatusCode
+	result.Proto = resp.Proto
+	result.TTFB = sinceStart(start, trace.firstByte)
+	result.ConnID = trace.connID
+	result.ConnReused = trace.connReused
+	result.ConnWait = trace.gotConn.Sub(trace.getConn)
+	result.Phases = trace.phases()
+
+	var body []byte
+	if r.acceptEncoding != "" {
+		// The body is read and decoded within the measured time, so that
+		// the latency includes decompression
+		received, err := io.ReadAll(resp.Body)
+		if err == nil {
+			body, err = decodeBody(received, resp.Header.Get("Content-Encoding"))
+		}
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(start)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to read response body: %w", err)
+			return result
+		}
+		result.BytesIn = int64(len(received))
+		result.CompressedSize = len(received)
+		result.UncompressedSize = len(body)
+	} else if r.hashBodies || validate.NeedsBody(task.Rules) || task.Poll != nil || len(task.Extract) > 0 {
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			result.Error = fmt.Errorf("failed to read response body: %w", err)
+			return result
+		}
+		result.BytesIn = int64(len(body))
+	} else {
+		// Drained rather than dropped, to size it and so that the
+		// connection can be reused
+		if result.BytesIn, err = io.Copy(io.Discard, resp.Body); err != nil {
+			result.Error = fmt.Errorf("failed to read response body: %w", err)
+			return result
+		}
+	}
+	if r.hashBodies {
+		result.BodyHash = hashBody(body)
+		result.BodySize = len(body)
+	}
+
+	response := validate.Response{
 		StatusCode: resp.StatusCode,
-		Duration:   now.Sub(start),
-		ThreadID:   userID,
-		StartTime:  start,
-		EndTime:    now,
+		Header:     resp.Header,
+		Body:       body,
+		Duration:   result.Duration,
+	}
+	if len(task.Rules) > 0 {
+		if err := validate.Validate(task.Rules, response); err != nil {
+			result.Error = err
+			result.FailedRule = validate.FailedRule(err)
+		}
+	}
+	if len(task.Extract) > 0 && result.Error == nil {
+		result.extracted, result.Error = extractValues(task.Extract, body)
+	}
+
+	if task.Poll != nil && result.Error == nil {
+		r.pollUntilDone(ctx, client, task, targetURL, response, &result)
+	}
+
+	return result
+}
+
+// hashBody returns a cheap, non-cryptographic hash of a response body that is
+// enough to tell different bodies apart.
+func hashBody(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// addHeaders adds the task's headers to req, and the runner's Authorization
+// header if the task doesn't set one.
+func (r *Runner) addHeaders(req *http.Request, task Task) {
+	for k, v := range task.Headers {
+		req.Header.Add(k, v)
+	}
+	if r.auth != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", r.auth)
+	}
+}
+
+// timeoutError wraps err in ErrTimeout if the request ran out of time. The
+// request itself is dropped from the message as the endpoint is known.
+func timeoutError(err error) error {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return err
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
 	}
+	return fmt.Errorf("%w: %w", ErrTimeout, err)
 }
@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scenario is a named user journey, such as browse -> add to cart ->
+// checkout: an ordered sequence of requests a virtual user makes, pausing
+// between them. During user load tests each user repeatedly picks a scenario
+// at random in proportion to its Weight and plays it from start to end.
+type Scenario struct {
+	Name   string
+	Weight int
+	Steps  []ScenarioStep
+}
+
+// ScenarioStep is one request of a scenario and the pause that follows it.
+type ScenarioStep struct {
+	Task      Task
+	ThinkTime time.Duration // Pause after the step, 0 to draw it from the user's ThinkTime
+}
+
+// AddScenario adds a scenario for user load tests. Once any scenario is
+// added, users play scenarios instead of picking single tasks.
+func (r *Runner) AddScenario(scenario Scenario) {
+	r.scenarios = append(r.scenarios, scenario)
+}
+
+// pickScenario chooses a scenario at random, weighted by Weight.
+func (r *Runner) pickScenario() *Scenario {
+	total := 0
+	for _, s := range r.scenarios {
+		total += s.Weight
+	}
+	if total <= 0 {
+		return &r.scenarios[rand.Intn(len(r.scenarios))]
+	}
+
+	n := rand.Intn(total)
+	for i := range r.scenarios {
+		if n < r.scenarios[i].Weight {
+			return &r.scenarios[i]
+		}
+		n -= r.scenarios[i].Weight
+	}
+	return &r.scenarios[len(r.scenarios)-1]
+}
+
+// runScenario plays scenario once for a user, passing each step's result to
+// record. Steps run one after another, each seeing the values extracted by
+// the steps before it in this journey only. Requests are sent with ctx, while
+// stepCtx ending stops the journey between requests. It returns whether the
+// journey completed, and false for more if the user should stop because the
+// step ended or the request limit was reached. A journey is abandoned when a step
+// fails to extract a value, as the steps after it would use it.
+func (r *Runner) runScenario(ctx, stepCtx context.Context, client *http.Client, scenario *Scenario, userID int, think ThinkTime, record func(Result)) (completed, more bool) {
+	var vars map[string]string
+	for i, step := range scenario.Steps {
+		if stepCtx.Err() != nil || !r.reserveRequest() {
+			return false, false
+		}
+
+		task := step.Task
+		task.vars = vars
+		result := r.executeRequest(ctx, client, task, userID)
+		result.Scenario = scenario.Name
+		result.Step = i + 1
+		record(result)
+
+		if len(task.Extract) > 0 {
+			if result.Error != nil {
+				return false, true
+			}
+			if vars == nil {
+				vars = make(map[string]string, len(result.extracted))
+			}
+			for name, value := range result.extracted {
+				vars[name] = value
+			}
+		}
+
+		pause := step.ThinkTime
+		if pause == 0 {
+			pause = think.Next()
+		}
+		if !sleep(stepCtx, pause) {
+			return false, false
+		}
+	}
+	return true, true
+}
+
+// stepCount returns how many distinct requests the runner can send, counting
+// every scenario step.
+func (r *Runner) stepCount() int {
+	count := len(r.tasks)
+	for _, s := range r.scenarios {
+		count += len(s.Steps)
+	}
+	return count
+}
+
+// ScenarioStepStats summarizes one step of a scenario over a load test step,
+// so the slow step of a journey stands out. Unlike Results, it counts every
+// request the step made.
+type ScenarioStepStats struct {
+	Scenario        string        `json:"scenario"`
+	Step            int           `json:"step"` // Position in the scenario, from 1
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	Requests        int           `json:"requests"`
+	FailedRequests  int           `json:"failedRequests"`
+	AverageDuration time.Duration `json:"averageDuration"`
+	P50Latency      time.Duration `json:"p50Latency"`
+	P95Latency      time.Duration `json:"p95Latency"`
+}
+
+// stepKey identifies a step of a scenario.
+type stepKey struct {
+	scenario string
+	step     int
+}
+
+// stepTracker tallies the results of scenario steps as users record them.
+type stepTracker struct {
+	mu        sync.Mutex
+	steps     map[stepKey]*ScenarioStepStats
+	total     map[stepKey]time.Duration
+	latencies map[stepKey]*latencyReservoir
+}
+
+func newStepTracker() *stepTracker {
+	return &stepTracker{
+		steps:     make(map[stepKey]*ScenarioStepStats),
+		total:     make(map[stepKey]time.Duration),
+		latencies: make(map[stepKey]*latencyReservoir),
+	}
+}
+
+// add counts result if it was part of a scenario.
+func (t *stepTracker) add(result Result) {
+	if result.Scenario == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := stepKey{result.Scenario, result.Step}
+	stats, exists := t.steps[key]
+	if !exists {
+		stats = &ScenarioStepStats{
+			Scenario: result.Scenario,
+			Step:     result.Step,
+			Method:   result.Method,
+			URL:      result.URL,
+		}
+		t.steps[key] = stats
+		t.latencies[key] = newLatencyReservoir(defaultReservoirSize)
+	}
+	stats.Requests++
+	if result.Error != nil {
+		stats.FailedRequests++
+		return
+	}
+	t.total[key] += result.Duration
+	t.latencies[key].Add(result.Duration)
+}
+
+// results returns the stats of every step seen, ordered by scenario name and
+// then step, or nil if no scenario was played.
+func (t *stepTracker) results() []ScenarioStepStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.steps) == 0 {
+		return nil
+	}
+
+	list := make([]ScenarioStepStats, 0, len(t.steps))
+	for key, stats := range t.steps {
+		if succeeded := stats.Requests - stats.FailedRequests; succeeded > 0 {
+			stats.AverageDuration = t.total[key] / time.Duration(succeeded)
+			percentiles := t.latencies[key].Percentiles(50, 95)
+			stats.P50Latency, stats.P95Latency = percentiles[0], percentiles[1]
+		}
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Scenario != list[j].Scenario {
+			return list[i].Scenario < list[j].Scenario
+		}
+		return list[i].Step < list[j].Step
+	})
+	return list
+}
@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	ThinkConstant    = "constant"
+	ThinkUniform     = "uniform"
+	ThinkExponential = "exponential"
+	ThinkNormal      = "normal"
+)
+
+// ThinkTime describes the pause each virtual user takes between requests.
+// The zero value keeps the original behavior of a uniform 100ms-1s pause.
+type ThinkTime struct {
+	Distribution string
+	Min          time.Duration // Lower bound for uniform
+	Max          time.Duration // Upper bound for uniform
+	Mean         time.Duration // Value for constant, mean for exponential and normal
+	StdDev       time.Duration // Standard deviation for normal
+}
+
+// Next draws the next think time from the distribution.
+func (t ThinkTime) Next() time.Duration {
+	switch t.Distribution {
+	case ThinkConstant:
+		return t.Mean
+	case ThinkExponential:
+		// Exponential pauses give Poisson arrivals, producing bursty traffic
+		return time.Duration(rand.ExpFloat64() * float64(t.Mean))
+	case ThinkNormal:
+		return max(0, time.Duration(rand.NormFloat64()*float64(t.StdDev))+t.Mean)
+	case ThinkUniform:
+		return uniformDuration(t.Min, t.Max)
+	default:
+		return uniformDuration(100*time.Millisecond, time.Second)
+	}
+}
+
+func uniformDuration(lower, upper time.Duration) time.Duration {
+	if upper <= lower {
+		return lower
+	}
+	return lower + time.Duration(rand.Int63n(int64(upper-lower)))
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Throttle simulates a slow client network on every connection a runner
+// dials, so its effect is included in the measured latencies.
+type Throttle struct {
+	KBps    int           // Bandwidth limit per direction in kilobits per second, 0 for unlimited
+	Latency time.Duration // Delay added before each request is sent, 0 for none
+}
+
+// Active reports whether the throttle changes anything.
+func (t Throttle) Active() bool {
+	return t.KBps > 0 || t.Latency > 0
+}
+
+// wrap returns conn slowed down by the throttle, or conn itself if the
+// throttle is inactive.
+func (t Throttle) wrap(conn net.Conn) net.Conn {
+	if !t.Active() {
+		return conn
+	}
+	bytesPerSec := float64(t.KBps) * 1000 / 8
+	return &throttledConn{
+		Conn:    conn,
+		latency: t.Latency,
+		reads:   newBandwidth(bytesPerSec),
+		writes:  newBandwidth(bytesPerSec),
+	}
+}
+
+// throttledConn limits the bandwidth of each direction of a connection and
+// delays the first write of every exchange, adding Latency to each request.
+type throttledConn struct {
+	net.Conn
+	latency time.Duration
+	reads   *bandwidth
+	writes  *bandwidth
+
+	// awaitingReply is set by a write and cleared once data is read back, so
+	// a request body written after its headers isn't delayed again
+	awaitingReply atomic.Bool
+}
+
+func (c *throttledConn) Read(p []byte) (int, error) {
+	if c.reads != nil {
+		p = p[:min(len(p), c.reads.chunk)]
+	}
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.awaitingReply.Store(false)
+	}
+	c.reads.wait(n)
+	return n, err
+}
+
+func (c *throttledConn) Write(p []byte) (int, error) {
+	if !c.awaitingReply.Swap(true) {
+		time.Sleep(c.latency)
+	}
+
+	if c.writes == nil {
+		return c.Conn.Write(p)
+	}
+
+	written := 0
+	for written < len(p) {
+		n, err := c.Conn.Write(p[written:min(len(p), written+c.writes.chunk)])
+		written += n
+		c.writes.wait(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// bandwidth paces transfers in one direction to a fixed rate. It is nil when
+// the rate is unlimited.
+type bandwidth struct {
+	bytesPerSec float64
+	chunk       int // Largest transfer at once, so pacing stays smooth
+
+	mu   sync.Mutex
+	next time.Time // When the bytes transferred so far are paid for
+}
+
+func newBandwidth(bytesPerSec float64) *bandwidth {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &bandwidth{
+		bytesPerSec: bytesPerSec,
+		chunk:       max(int(bytesPerSec/10), 1),
+	}
+}
+
+// wait blocks until n more bytes fit within the rate.
+func (b *bandwidth) wait(n int) {
+	if b == nil || n <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	b.next = b.next.Add(time.Duration(float64(n) / b.bytesPerSec * float64(time.Second)))
+	until := b.next
+	b.mu.Unlock()
+
+	time.Sleep(time.Until(until))
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// Phases splits a request's latency into where the time went. Phases that
+// didn't happen, such as DNS and connecting on a reused connection, are zero.
+type Phases struct {
+	DNS     time.Duration `json:"dns,omitempty"`
+	Connect time.Duration `json:"connect,omitempty"`
+	TLS     time.Duration `json:"tls,omitempty"`
+	Server  time.Duration `json:"server,omitempty"` // From the request being written to the first response byte
+}
+
+// requestTrace collects connection and timing details for a single request
+// from httptrace hooks.
+type requestTrace struct {
+	getConn    time.Time
+	gotConn    time.Time
+	firstByte  time.Time
+	connID     string
+	connReused bool
+
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	wroteRequest              time.Time
+}
+
+func (t *requestTrace) attach(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.getConn = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.gotConn = time.Now()
+			t.connReused = info.Reused
+			if info.Conn != nil {
+				t.connID = info.Conn.LocalAddr().String() + "->" + info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.firstByte = time.Now()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.dnsDone = time.Now()
+		},
+		ConnectStart: func(string, string) {
+			// Only the first of several addresses tried starts the phase
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(string, string, error) {
+			t.connectDone = time.Now()
+		},
+		TLSHandshakeStart: func() {
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.tlsDone = time.Now()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.wroteRequest = time.Now()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// phases returns the time spent in each traced phase of the request.
+func (t *requestTrace) phases() Phases {
+	return Phases{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connectStart, t.connectDone),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		Server:  between(t.wroteRequest, t.firstByte),
+	}
+}
+
+// between returns the time from start to end, or zero unless both were set.
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// sinceStart returns the time between start and t, or zero if t was never set.
+func sinceStart(start, t time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Sub(start)
+}
@@ -2,24 +2,63 @@ package runner
 
 import (
 	"time"
+
+	"percipio.com/gopi/lib/validate"
 )
 
 type Task struct {
+	Name    string // Name the endpoint was given in the config, if any
 	URL     string
 	Method  string
 	Headers map[string]string
 	Body    []byte
+	Rules   []validate.Rule   // Checks a response must pass to count as successful
+	Hosts   *HostPool         // Hosts the requests are spread across, nil to send them all to URL
+	Poll    *Poll             // Polls for completion after each request, nil for synchronous endpoints
+	Extract []Extract         // Values to capture from the response for later scenario steps
+	Weight  int               // Share of the requests relative to other tasks, 1 if unset
+	fault   string            // Fault injected into this request, see FaultConfig
+	target  Target            // Host picked from Hosts for this request
+	vars    map[string]string // Values extracted by earlier steps of the journey
+	warmup  bool              // Whether the request only warms up the target, see SetWarmup
 }
 
 type Result struct {
-	URL        string
-	Method     string
-	StatusCode int
-	Duration   time.Duration
-	Error      error
-	ThreadID   int
-	StartTime  time.Time
-	EndTime    time.Time
+	Name             string // Name of the endpoint the request was sent to, if any
+	URL              string
+	Method           string
+	Headers          map[string]string // Headers as they were sent
+	Body             []byte            // Body as it was sent
+	StatusCode       int
+	Proto            string // Protocol of the response, such as "HTTP/1.1" or "HTTP/2.0"
+	Duration         time.Duration
+	TTFB             time.Duration // Time until the first response byte arrived
+	ConnID           string        // Identifies the connection the request was sent on
+	ConnReused       bool          // Whether the connection was reused from the pool
+	ConnWait         time.Duration // Time spent waiting to obtain a connection
+	Phases           Phases        // Time spent in each phase of the request
+	BodyHash         string        // Hash of the response body, only set when checking consistency
+	BodySize         int           // Size of the response body, only set when checking consistency
+	BytesIn          int64         // Response body bytes received, as decoded by the transport unless negotiating compression
+	BytesOut         int64         // Request body bytes sent
+	CompressedSize   int           // Response body bytes as received, only set when negotiating compression, see SetAcceptEncoding
+	UncompressedSize int           // Response body bytes once decoded, only set when negotiating compression
+	Error            error
+	FailedRule       string  // Type of the first validation rule the response failed
+	TargetRPS        float64 // Dispatch rate the request was sent under, 0 if unlimited
+	Retries          int     // Number of times the request was retried
+	Polls            int     // Status polls an asynchronous request needed, see Poll
+	Fault            string  // Fault deliberately injected into the request, if any
+	Scenario         string  // Scenario the request was part of, if any
+	Step             int     // Position of the request in its scenario, from 1
+	Host             string  // Host the request was sent to when its endpoint has several
+	TargetURL        string  // URL the request was sent to, on its host and with placeholders filled
+	Warmup           bool    // Sent to warm up the target, so left out of the statistics
+	ThreadID         int
+	StartTime        time.Time
+	EndTime          time.Time
+
+	extracted map[string]string // Values captured by the task's Extract rules
 }
 
 type UserLoadConfig struct {
@@ -27,6 +66,14 @@ type UserLoadConfig struct {
 	MaxUsers        int
 	StepUsers       int
 	DurationPerStep time.Duration
+	ThinkTime       ThinkTime
+	IdleTimeout     time.Duration // Abort the test after this long without a successful response, 0 to never abort
+
+	// RampDuration, if set, replaces the steps with a linear ramp that adds
+	// users one at a time from StartUsers to MaxUsers over this long, see
+	// runLinearRamp. StepUsers, DurationPerStep and Resume are then unused.
+	RampDuration time.Duration
+	Resume
 }
 
 type DataLoadConfig struct {
@@ -34,12 +81,27 @@ type DataLoadConfig struct {
 	MaxDataSize        int
 	DataSizeMultiplier float64
 	StepsCount         int
+	Resume
+}
+
+// Resume lets a multi-step load test continue where an interrupted run left
+// off and checkpoint its own progress.
+type Resume struct {
+	Completed []LoadTestResult             // Steps already completed by an earlier run, which are not repeated
+	OnStep    func(steps []LoadTestResult) // Called with every completed step so far after each new one
 }
 
 type LoadTestResult struct {
 	UserCount  int // For user load tests
 	DataSize   int // For data load tests
 	Results    []Result
+	Journeys   map[string]int // Completed journeys per scenario
 	Timestamp  time.Time
 	StepNumber int
+
+	// AbortedAfter is how far into the step the test was stopped because the
+	// target stopped responding successfully, 0 if it ran to completion.
+	AbortedAfter time.Duration
+
+	ScenarioSteps []ScenarioStepStats // Requests and latency of each scenario step played
 }
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	unixScheme = "unix://"
+
+	// unixHostSuffix marks a request host that encodes a socket path. Giving
+	// each socket its own host keeps the transport from pooling connections
+	// to different sockets together.
+	unixHostSuffix = ".unix-socket"
+)
+
+// requestURL maps a task URL to the URL actually sent over the wire. Socket
+// URLs of the form unix:///path/to.sock:/api/endpoint become plain HTTP
+// requests to a host that dialContext resolves back to the socket; any
+// other URL is returned unchanged.
+func requestURL(taskURL string) string {
+	rest, ok := strings.CutPrefix(taskURL, unixScheme)
+	if !ok {
+		return taskURL
+	}
+
+	socket, path, found := strings.Cut(rest, ":")
+	if !found || path == "" {
+		path = "/"
+	}
+	return "http://" + hex.EncodeToString([]byte(socket)) + unixHostSuffix + path
+}
+
+// newDialContext returns a dial function that dials Unix sockets for hosts
+// produced by requestURL and falls back to a regular network dial for
+// everything else. A timeout of 0 leaves connecting unbounded apart from the
+// overall request timeout. Addresses found in resolve, keyed by lowercase
+// host:port, are dialed at the address they map to instead; since only the
+// dial changes, the request keeps its Host header and TLS server name. Every
+// connection is slowed down by throttle.
+func newDialContext(timeout time.Duration, resolve map[string]string, throttle Throttle) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if target, ok := resolve[strings.ToLower(addr)]; ok {
+			addr = target
+		}
+		conn, err := dialContext(dialer, ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return throttle.wrap(conn), nil
+	}
+}
+
+func dialContext(dialer *net.Dialer, ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err == nil {
+		if encoded, ok := strings.CutSuffix(host, unixHostSuffix); ok {
+			if socket, err := hex.DecodeString(encoded); err == nil {
+				return dialer.DialContext(ctx, "unix", string(socket))
+			}
+		}
+	}
+
+	return dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// weight returns the task's share of the requests relative to other tasks,
+// treating an unset weight as 1.
+func (t Task) weight() int {
+	if t.Weight <= 0 {
+		return 1
+	}
+	return t.Weight
+}
+
+// requestCounts splits the requestCount per task of a run between the tasks
+// in proportion to their weights, keeping the total. The requests left over
+// from rounding down go to the tasks with the largest remainders, so equal
+// weights give every task exactly requestCount. A task whose share rounds to
+// nothing still gets a request, taken from the task with the most, so that
+// every endpoint is measured.
+func (r *Runner) requestCounts() []int {
+	total := len(r.tasks) * r.requestCount
+	weights := 0
+	for _, task := range r.tasks {
+		weights += task.weight()
+	}
+
+	counts := make([]int, len(r.tasks))
+	remainders := make([]int, len(r.tasks))
+	assigned := 0
+	for i, task := range r.tasks {
+		share := total * task.weight()
+		counts[i] = share / weights
+		remainders[i] = share % weights
+		assigned += counts[i]
+	}
+
+	order := make([]int, len(r.tasks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for _, i := range order[:total-assigned] {
+		counts[i]++
+	}
+
+	for i := range counts {
+		if counts[i] > 0 {
+			continue
+		}
+		most := 0
+		for j := range counts {
+			if counts[j] > counts[most] {
+				most = j
+			}
+		}
+		counts[most]--
+		counts[i]++
+	}
+	return counts
+}
+
+// pickTask chooses a task at random, weighted by Weight.
+func (r *Runner) pickTask() Task {
+	total := 0
+	for _, task := range r.tasks {
+		total += task.weight()
+	}
+
+	n := rand.Intn(total)
+	for _, task := range r.tasks {
+		if n < task.weight() {
+			return task
+		}
+		n -= task.weight()
+	}
+	return r.tasks[len(r.tasks)-1]
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequestCounts(t *testing.T) {
+	tests := []struct {
+		name         string
+		weights      []int
+		requestCount int
+		want         []int
+	}{
+		{"equal weights", []int{1, 1, 1}, 5, []int{5, 5, 5}},
+		{"unset weights count as 1", []int{0, 0}, 3, []int{3, 3}},
+		{"proportional", []int{10, 1}, 100, []int{182, 18}},
+		{"largest remainder", []int{1, 1, 1}, 1, []int{1, 1, 1}},
+		{"rounds to zero", []int{100, 1}, 1, []int{1, 1}},
+		{"several round to zero", []int{100, 1, 1, 1}, 1, []int{1, 1, 1, 1}},
+		{"taken from the largest", []int{50, 30, 1}, 2, []int{3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRunner(1, tt.requestCount)
+			for _, weight := range tt.weights {
+				r.AddTask(Task{URL: "http://localhost", Method: "GET", Weight: weight})
+			}
+			if got := r.requestCounts(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestCounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package score
+
+import (
+	"time"
+
+	"percipio.com/gopi/lib/stats"
+)
+
+// Weights controls how much each component contributes to the health score
+type Weights struct {
+	SuccessRate float64
+	Latency     float64
+	Throughput  float64
+}
+
+// Config holds the weights and SLA targets used to score a run
+type Config struct {
+	Weights    Weights
+	LatencySLA time.Duration // P95 latency each endpoint should stay under
+	TargetRPS  float64       // Overall throughput the run should reach, 0 to ignore
+}
+
+// HealthScore is a 0-100 score for a run along with the component scores
+// it was built from
+type HealthScore struct {
+	Total       float64 `json:"total"`
+	SuccessRate float64 `json:"successRate"`
+	Latency     float64 `json:"latency"`
+	Throughput  float64 `json:"throughput"`
+}
+
+// Calculate combines success rate, P95 latency against the SLA and
+// throughput against the target into a single weighted score.
+func Calculate(s *stats.Statistics, cfg Config) HealthScore {
+	var score HealthScore
+	if len(s.EndpointStats) == 0 {
+		return score
+	}
+
+	var totalRequests, successRequests int
+	var latencyTotal, rps float64
+	for _, es := range s.EndpointStats {
+		totalRequests += es.TotalRequests
+		successRequests += es.SuccessRequests
+		latencyTotal += latencyScore(es.P95Latency, cfg.LatencySLA)
+		rps += es.RequestsPerSecond
+	}
+
+	if totalRequests > 0 {
+		score.SuccessRate = float64(successRequests) / float64(totalRequests) * 100
+	}
+	score.Latency = latencyTotal / float64(len(s.EndpointStats))
+
+	weights := cfg.Weights
+	if cfg.TargetRPS > 0 {
+		score.Throughput = clamp(rps/cfg.TargetRPS*100, 0, 100)
+	} else {
+		// Without a target there is nothing to score throughput against
+		score.Throughput = 100
+		weights.Throughput = 0
+	}
+
+	totalWeight := weights.SuccessRate + weights.Latency + weights.Throughput
+	if totalWeight <= 0 {
+		return score
+	}
+
+	score.Total = (score.SuccessRate*weights.SuccessRate +
+		score.Latency*weights.Latency +
+		score.Throughput*weights.Throughput) / totalWeight
+
+	return score
+}
+
+// latencyScore is 100 when p95 is within the SLA and falls off in proportion
+// to how far the SLA was exceeded.
+func latencyScore(p95, sla time.Duration) float64 {
+	if sla <= 0 || p95 <= sla {
+		return 100
+	}
+	return float64(sla) / float64(p95) * 100
+}
+
+func clamp(v, lower, upper float64) float64 {
+	if v < lower {
+		return lower
+	}
+	if v > upper {
+		return upper
+	}
+	return v
+}
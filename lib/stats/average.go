@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// AverageEndpointStats combines an endpoint's statistics from several runs
+// into one holding their mean request counts, latencies and throughput, as a
+// baseline that a single noisy run can't skew. The runs' latency samples are
+// pooled, so a distribution test sees all of them.
+func AverageEndpointStats(runs []*EndpointStatistics) *EndpointStatistics {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	last := runs[len(runs)-1]
+	avg := &EndpointStatistics{URL: last.URL, Method: last.Method}
+	var total, success, failed int
+	var pooled []time.Duration
+	for _, run := range runs {
+		total += run.TotalRequests
+		success += run.SuccessRequests
+		failed += run.FailedRequests
+		avg.TotalDuration += run.TotalDuration
+		avg.AverageDuration += run.AverageDuration
+		avg.MedianDuration += run.MedianDuration
+		avg.StdDevDuration += run.StdDevDuration
+		avg.Percentile95 += run.Percentile95
+		avg.Percentile99 += run.Percentile99
+		avg.P50Latency += run.P50Latency
+		avg.P95Latency += run.P95Latency
+		avg.P99Latency += run.P99Latency
+		avg.P999Latency += run.P999Latency
+		avg.RequestsPerSecond += run.RequestsPerSecond
+		avg.AchievedRPS += run.AchievedRPS
+		pooled = append(pooled, run.LatencySample...)
+	}
+
+	n := len(runs)
+	meanCount := func(sum int) int {
+		return int(math.Round(float64(sum) / float64(n)))
+	}
+	avg.TotalRequests = meanCount(total)
+	avg.SuccessRequests = meanCount(success)
+	avg.FailedRequests = meanCount(failed)
+	for _, d := range []*time.Duration{
+		&avg.TotalDuration, &avg.AverageDuration, &avg.MedianDuration, &avg.StdDevDuration,
+		&avg.Percentile95, &avg.Percentile99,
+		&avg.P50Latency, &avg.P95Latency, &avg.P99Latency, &avg.P999Latency,
+	} {
+		*d /= time.Duration(n)
+	}
+	avg.RequestsPerSecond /= float64(n)
+	avg.AchievedRPS /= float64(n)
+
+	slices.Sort(pooled)
+	avg.LatencySample = sampleSorted(pooled, maxLatencySamples)
+	return avg
+}
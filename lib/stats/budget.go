@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// BudgetCheck is the outcome of comparing one endpoint's P95 latency with
+// its latency budget.
+type BudgetCheck struct {
+	Endpoint string
+	Budget   time.Duration
+	P95      time.Duration
+	Met      bool
+}
+
+// CheckBudgets compares the P95 latency of every endpoint that has a budget,
+// keyed like EndpointStats, and returns the checks sorted by
+// endpoint. Endpoints that weren't run are skipped.
+func (s *Statistics) CheckBudgets(budgets map[string]time.Duration) []BudgetCheck {
+	var checks []BudgetCheck
+	for endpoint, budget := range budgets {
+		es, ok := s.EndpointStats[endpoint]
+		if !ok {
+			continue
+		}
+		checks = append(checks, BudgetCheck{
+			Endpoint: endpoint,
+			Budget:   budget,
+			P95:      es.P95Latency,
+			Met:      es.P95Latency <= budget,
+		})
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Endpoint < checks[j].Endpoint
+	})
+	return checks
+}
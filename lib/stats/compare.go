@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// FormatComparison renders a side-by-side comparison of two runs of the same
+// endpoint set against different deployments. Endpoints are matched by method
+// and path so the differing hosts don't prevent a match.
+func FormatComparison(labelA string, a *Statistics, labelB string, b *Statistics) string {
+	statsA := byMethodAndPath(a)
+	statsB := byMethodAndPath(b)
+
+	keys := make([]string, 0, len(statsA))
+	for key := range statsA {
+		keys = append(keys, key)
+	}
+	for key := range statsB {
+		if _, exists := statsA[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("Deployment Comparison\n")
+	sb.WriteString("=====================\n")
+	sb.WriteString(fmt.Sprintf("A: %s\n", labelA))
+	sb.WriteString(fmt.Sprintf("B: %s\n\n", labelB))
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Endpoint\tAvg A\tAvg B\tChange\tP95 A\tP95 B\tChange\tRPS A\tRPS B\tChange\tErr% A\tErr% B")
+	for _, key := range keys {
+		esA, esB := statsA[key], statsB[key]
+		if esA == nil || esB == nil {
+			side := "B"
+			if esA != nil {
+				side = "A"
+			}
+			fmt.Fprintf(tw, "%s\t(only ran against %s)\n", key, side)
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%.2f\t%.2f\t%s\t%.2f\t%.2f\n",
+			key,
+			formatMs(esA.AverageDuration), formatMs(esB.AverageDuration),
+			formatChange(esA.AverageDuration.Seconds(), esB.AverageDuration.Seconds()),
+			formatMs(esA.P95Latency), formatMs(esB.P95Latency),
+			formatChange(esA.P95Latency.Seconds(), esB.P95Latency.Seconds()),
+			esA.RequestsPerSecond, esB.RequestsPerSecond,
+			formatChange(esA.RequestsPerSecond, esB.RequestsPerSecond),
+			errorRate(esA), errorRate(esB))
+	}
+	tw.Flush()
+
+	return sb.String()
+}
+
+func byMethodAndPath(s *Statistics) map[string]*EndpointStatistics {
+	keyed := make(map[string]*EndpointStatistics, len(s.EndpointStats))
+	for _, es := range s.EndpointStats {
+		path := es.URL
+		if parsed, err := url.Parse(es.URL); err == nil {
+			path = parsed.RequestURI()
+		}
+		keyed[fmt.Sprintf("%s %s", es.Method, path)] = es
+	}
+	return keyed
+}
+
+func formatMs(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d.Microseconds())/1000)
+}
+
+func formatChange(a, b float64) string {
+	if a == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.2f%%", (b-a)/a*100)
+}
+
+func errorRate(es *EndpointStatistics) float64 {
+	if es.TotalRequests == 0 {
+		return 0
+	}
+	return float64(es.FailedRequests) / float64(es.TotalRequests) * 100
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"percipio.com/gopi/lib/runner"
+)
+
+// CompressionStatistics describes how much an endpoint's response bodies
+// were compressed on the wire, for runs that negotiated compression.
+type CompressionStatistics struct {
+	Responses         int   // Responses with a body
+	CompressedBytes   int64 // Body bytes received
+	UncompressedBytes int64 // Body bytes once decoded
+}
+
+// AverageCompressed returns the average body size received.
+func (c *CompressionStatistics) AverageCompressed() int64 {
+	return c.CompressedBytes / int64(c.Responses)
+}
+
+// AverageUncompressed returns the average body size once decoded.
+func (c *CompressionStatistics) AverageUncompressed() int64 {
+	return c.UncompressedBytes / int64(c.Responses)
+}
+
+// Ratio returns how many times smaller the bodies were on the wire, 1 when
+// they weren't compressed.
+func (c *CompressionStatistics) Ratio() float64 {
+	if c.CompressedBytes == 0 {
+		return 1
+	}
+	return float64(c.UncompressedBytes) / float64(c.CompressedBytes)
+}
+
+// calculateCompressionStats fills in stat.Compression from the responses whose
+// body sizes were recorded, leaving it nil when none were.
+func calculateCompressionStats(stat *EndpointStatistics, results []runner.Result) {
+	var cs *CompressionStatistics
+	for _, result := range results {
+		if result.UncompressedSize == 0 {
+			continue
+		}
+
+		if cs == nil {
+			cs = &CompressionStatistics{}
+		}
+		cs.Responses++
+		cs.CompressedBytes += int64(result.CompressedSize)
+		cs.UncompressedBytes += int64(result.UncompressedSize)
+	}
+	stat.Compression = cs
+}
@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/util"
+)
+
+// ConnectionStatistics describes how requests to an endpoint were spread over
+// connections, to show whether keep-alive reuse is helping or whether
+// requests are queueing behind a few busy connections.
+type ConnectionStatistics struct {
+	Connections           int
+	NewConnRequests       int
+	ReusedRequests        int
+	RequestsPerConnection float64
+	AverageConnWait       time.Duration
+	MaxConnWait           time.Duration
+	NewConnP50Latency     time.Duration
+	NewConnP95Latency     time.Duration
+	ReusedP50Latency      time.Duration
+	ReusedP95Latency      time.Duration
+}
+
+// ReusedPct returns the percentage of requests sent on a reused connection,
+// which is near 100 when keep-alive is working.
+func (cs ConnectionStatistics) ReusedPct() float64 {
+	requests := cs.NewConnRequests + cs.ReusedRequests
+	if requests == 0 {
+		return 0
+	}
+	return float64(cs.ReusedRequests) / float64(requests) * 100
+}
+
+func calculateConnectionStats(stat *EndpointStatistics, results []runner.Result) {
+	conns := make(map[string]int)
+	var fresh, reused []time.Duration
+	var totalWait time.Duration
+	cs := &stat.ConnectionStats
+
+	for _, result := range results {
+		if result.Error != nil || result.ConnID == "" {
+			continue
+		}
+
+		conns[result.ConnID]++
+		totalWait += result.ConnWait
+		if result.ConnWait > cs.MaxConnWait {
+			cs.MaxConnWait = result.ConnWait
+		}
+		if result.ConnReused {
+			reused = append(reused, result.Duration)
+		} else {
+			fresh = append(fresh, result.Duration)
+		}
+	}
+
+	if len(conns) == 0 {
+		return
+	}
+
+	requests := len(fresh) + len(reused)
+	cs.Connections = len(conns)
+	cs.NewConnRequests = len(fresh)
+	cs.ReusedRequests = len(reused)
+	cs.RequestsPerConnection = float64(requests) / float64(len(conns))
+	cs.AverageConnWait = totalWait / time.Duration(requests)
+	cs.NewConnP50Latency, cs.NewConnP95Latency = p50p95(fresh)
+	cs.ReusedP50Latency, cs.ReusedP95Latency = p50p95(reused)
+}
+
+func p50p95(durations []time.Duration) (time.Duration, time.Duration) {
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	return util.Percentile(durations, 50), util.Percentile(durations, 95)
+}
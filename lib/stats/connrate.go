@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"net/url"
+	"sort"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// ConnectionRate describes how fast new connections were opened to a host. A
+// high rate against the request rate means keep-alive isn't working and the
+// target's load balancers and proxies have to absorb the connection churn.
+type ConnectionRate struct {
+	Requests       int
+	NewConnections int
+	AveragePerSec  float64 // Over the whole run, counted as at least one second
+	PeakPerSec     int     // Most new connections within one second of the run
+}
+
+// ConnectionRateHosts returns the hosts of ConnectionRates in alphabetical
+// order.
+func (s *Statistics) ConnectionRateHosts() []string {
+	hosts := make([]string, 0, len(s.ConnectionRates))
+	for host := range s.ConnectionRates {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// calculateConnectionRates counts the new connections opened to each host,
+// per second of the run. A request opened one if it got a connection that
+// wasn't reused, at the time it got it.
+func calculateConnectionRates(results []runner.Result) map[string]*ConnectionRate {
+	if len(results) == 0 {
+		return nil
+	}
+
+	first, last := results[0].StartTime, results[0].EndTime
+	for _, result := range results {
+		if result.StartTime.Before(first) {
+			first = result.StartTime
+		}
+		if result.EndTime.After(last) {
+			last = result.EndTime
+		}
+	}
+
+	rates := make(map[string]*ConnectionRate)
+	perSecond := make(map[string]map[int]int)
+	for _, result := range results {
+		host := requestHost(result)
+		rate, exists := rates[host]
+		if !exists {
+			rate = &ConnectionRate{}
+			rates[host] = rate
+			perSecond[host] = make(map[int]int)
+		}
+		rate.Requests++
+		if result.ConnID == "" || result.ConnReused {
+			continue
+		}
+
+		rate.NewConnections++
+		second := int(result.StartTime.Add(result.ConnWait).Sub(first) / time.Second)
+		perSecond[host][second]++
+		rate.PeakPerSec = max(rate.PeakPerSec, perSecond[host][second])
+	}
+
+	seconds := max(last.Sub(first).Seconds(), 1)
+	for _, rate := range rates {
+		rate.AveragePerSec = float64(rate.NewConnections) / seconds
+	}
+	return rates
+}
+
+// requestHost returns the host:port result was sent to, or its URL if it has
+// no host such as for Unix sockets.
+func requestHost(result runner.Result) string {
+	target := result.URL
+	if result.Host != "" {
+		target = result.Host
+	}
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return target
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"sort"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// ConsistencyStatistics describes how much the responses to an endpoint's
+// identical requests varied. Varying bodies or status codes point at caching
+// problems or backends that disagree with each other.
+type ConsistencyStatistics struct {
+	BodyVariants map[string]int // Responses by body hash
+	StatusCodes  map[int]int    // Responses by status code, including failed validations
+	MinBodySize  int
+	MaxBodySize  int
+}
+
+// Inconsistent reports whether the endpoint returned more than one distinct
+// body or status code.
+func (c *ConsistencyStatistics) Inconsistent() bool {
+	return len(c.BodyVariants) > 1 || len(c.StatusCodes) > 1
+}
+
+// StatusList returns the status codes that were returned, in ascending order.
+func (c *ConsistencyStatistics) StatusList() []int {
+	codes := make([]int, 0, len(c.StatusCodes))
+	for code := range c.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// calculateConsistencyStats fills in stat.Consistency from the responses
+// whose bodies were hashed, leaving it nil when none were.
+func calculateConsistencyStats(stat *EndpointStatistics, results []runner.Result) {
+	var cs *ConsistencyStatistics
+	for _, result := range results {
+		if result.BodyHash == "" {
+			continue
+		}
+
+		if cs == nil {
+			cs = &ConsistencyStatistics{
+				BodyVariants: make(map[string]int),
+				StatusCodes:  make(map[int]int),
+				MinBodySize:  result.BodySize,
+				MaxBodySize:  result.BodySize,
+			}
+		}
+		cs.BodyVariants[result.BodyHash]++
+		cs.StatusCodes[result.StatusCode]++
+		cs.MinBodySize = min(cs.MinBodySize, result.BodySize)
+		cs.MaxBodySize = max(cs.MaxBodySize, result.BodySize)
+	}
+	stat.Consistency = cs
+}
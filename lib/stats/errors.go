@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"regexp"
+	"sort"
+)
+
+var (
+	// requestPrefix matches the `Get "http://host/path": ` prefix net/http
+	// puts in front of transport errors. The endpoint is already known, and
+	// templated URLs would otherwise give every request its own error.
+	requestPrefix = regexp.MustCompile(`^[A-Za-z]+ "[^"]*": `)
+	// address matches IPv4 and bracketed IPv6 addresses, and host:port pairs,
+	// whose ephemeral local ports differ between otherwise identical errors.
+	address = regexp.MustCompile(`\[[0-9A-Fa-f.%\w]*:[0-9A-Fa-f:.%\w]*\](:\d+)?|\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b|\b[\w.-]+:\d+\b`)
+)
+
+// ErrorCount is a normalized error message and how often it occurred.
+type ErrorCount struct {
+	Message string
+	Count   int
+}
+
+// NormalizeError reduces an error message to a form that groups identical
+// failures together, replacing addresses and ports with <addr>.
+func NormalizeError(msg string) string {
+	msg = requestPrefix.ReplaceAllString(msg, "")
+	return address.ReplaceAllString(msg, "<addr>")
+}
+
+// UniqueErrors returns the endpoint's distinct errors, most frequent first.
+func (es *EndpointStatistics) UniqueErrors() []ErrorCount {
+	errs := make([]ErrorCount, 0, len(es.Errors))
+	for msg, count := range es.Errors {
+		errs = append(errs, ErrorCount{Message: msg, Count: count})
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Count != errs[j].Count {
+			return errs[i].Count > errs[j].Count
+		}
+		return errs[i].Message < errs[j].Message
+	})
+	return errs
+}
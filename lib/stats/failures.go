@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// FailureCategories counts an endpoint's failed requests by what went wrong,
+// separating a slow server from an unreachable or misconfigured one.
+type FailureCategories struct {
+	Timeouts         int // Ran out of time connecting or waiting for the response
+	DNSErrors        int // The host name couldn't be resolved
+	ConnectionErrors int // Connections refused, reset or closed early
+	Validation       int // Responses that failed a validate rule, see RuleFailures
+	Other            int
+}
+
+// count adds the failed result to its category.
+func (f *FailureCategories) count(result runner.Result) {
+	err := result.Error
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case result.FailedRule != "":
+		f.Validation++
+	case errors.As(err, &dnsErr):
+		f.DNSErrors++
+	case errors.Is(err, runner.ErrTimeout), errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		f.Timeouts++
+	case isConnectionError(err):
+		f.ConnectionErrors++
+	default:
+		f.countMessage(err.Error())
+	}
+}
+
+// countMessage categorizes an error by its message alone, for results loaded
+// from --merge files where only the message survives.
+func (f *FailureCategories) countMessage(msg string) {
+	switch {
+	case strings.Contains(msg, "no such host"):
+		f.DNSErrors++
+	case strings.Contains(msg, runner.ErrTimeout.Error()), strings.Contains(msg, "timeout"):
+		f.Timeouts++
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "connection reset"),
+		strings.Contains(msg, "broken pipe"), strings.HasSuffix(msg, "EOF"):
+		f.ConnectionErrors++
+	default:
+		f.Other++
+	}
+}
+
+func isConnectionError(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Total returns the number of failures counted.
+func (f FailureCategories) Total() int {
+	return f.Timeouts + f.DNSErrors + f.ConnectionErrors + f.Validation + f.Other
+}
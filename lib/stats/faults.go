@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"slices"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// splitInjected separates requests with a deliberately injected fault from
+// the real ones. results is returned as is when nothing was injected.
+func splitInjected(results []runner.Result) (real, injected []runner.Result) {
+	if !slices.ContainsFunc(results, func(r runner.Result) bool { return r.Fault != "" }) {
+		return results, nil
+	}
+
+	for _, result := range results {
+		if result.Fault != "" {
+			injected = append(injected, result)
+		} else {
+			real = append(real, result)
+		}
+	}
+	return real, injected
+}
+
+func (s *EndpointStatistics) countInjected(result runner.Result) {
+	s.InjectedFaults[result.Fault]++
+	if result.Error != nil || result.StatusCode >= 400 {
+		s.InjectedRejected++
+	}
+}
+
+// InjectedRequests returns how many deliberately invalid requests were sent.
+func (s *EndpointStatistics) InjectedRequests() int {
+	total := 0
+	for _, count := range s.InjectedFaults {
+		total += count
+	}
+	return total
+}
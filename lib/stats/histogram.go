@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// DefaultHistogramBounds are log-scaled bucket bounds from 1ms to 10s, three
+// per decade.
+var DefaultHistogramBounds = LogBounds(time.Millisecond, 10*time.Second)
+
+// Histogram counts latencies into buckets with fixed upper bounds, so its size
+// depends on the number of buckets rather than the number of samples, and a
+// multimodal distribution shows up as separate peaks.
+type Histogram struct {
+	Bounds []time.Duration // Inclusive upper bound of each bucket, ascending
+	Counts []int           // Samples per bucket, with one extra for samples above the last bound
+}
+
+// NewHistogram returns an empty histogram with the given ascending bucket
+// bounds.
+func NewHistogram(bounds []time.Duration) *Histogram {
+	return &Histogram{
+		Bounds: bounds,
+		Counts: make([]int, len(bounds)+1),
+	}
+}
+
+// SetHistogramBounds recounts each endpoint's latency histogram over bounds
+// in place of DefaultHistogramBounds. Empty bounds keep the defaults.
+func (s *Statistics) SetHistogramBounds(results []runner.Result, bounds []time.Duration) {
+	if len(bounds) == 0 {
+		return
+	}
+	results, _ = dropWarmup(results)
+
+	for _, es := range s.EndpointStats {
+		if es.Histogram != nil {
+			es.Histogram = NewHistogram(bounds)
+		}
+	}
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		if es := s.EndpointStats[s.resultKey(result)]; es != nil && es.Histogram != nil {
+			es.Histogram.Add(result.Duration)
+		}
+	}
+}
+
+// LogBounds returns bounds on the 1-2-5 series from lower up to and including
+// upper, such as 1ms, 2ms, 5ms, 10ms, ..., 10s.
+func LogBounds(lower, upper time.Duration) []time.Duration {
+	var bounds []time.Duration
+	for decade := lower; decade <= upper; decade *= 10 {
+		for _, step := range []time.Duration{1, 2, 5} {
+			if bound := decade * step; bound <= upper {
+				bounds = append(bounds, bound)
+			}
+		}
+	}
+	return bounds
+}
+
+// Add counts d in the first bucket whose bound it doesn't exceed.
+func (h *Histogram) Add(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool {
+		return d <= h.Bounds[i]
+	})
+	h.Counts[i]++
+}
+
+// Range returns the indexes of the first and last non-empty buckets, or -1, -1
+// if the histogram is empty.
+func (h *Histogram) Range() (int, int) {
+	first, last := -1, -1
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	return first, last
+}
+
+// Label describes bucket i, e.g. "<=5ms", or ">10s" for the overflow bucket.
+func (h *Histogram) Label(i int) string {
+	if i >= len(h.Bounds) {
+		return ">" + h.Bounds[len(h.Bounds)-1].String()
+	}
+	return "<=" + h.Bounds[i].String()
+}
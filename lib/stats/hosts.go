@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/util"
+)
+
+// HostStatistics summarizes the requests of an endpoint that one of its hosts
+// served, so that a slow or failing instance stands out from the rest.
+type HostStatistics struct {
+	Requests        int
+	FailedRequests  int
+	AverageDuration time.Duration
+	P50Latency      time.Duration
+	P95Latency      time.Duration
+}
+
+// HostList returns the hosts the endpoint's requests were spread across, in
+// alphabetical order.
+func (s *EndpointStatistics) HostList() []string {
+	hosts := make([]string, 0, len(s.Hosts))
+	for host := range s.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// calculateHostStats fills in stat.Hosts from the results that record the host
+// they were sent to, leaving it nil when none do.
+func calculateHostStats(stat *EndpointStatistics, results []runner.Result) {
+	var hosts map[string]*HostStatistics
+	durations := make(map[string][]time.Duration)
+	for _, result := range results {
+		if result.Host == "" {
+			continue
+		}
+
+		if hosts == nil {
+			hosts = make(map[string]*HostStatistics)
+		}
+		hs, exists := hosts[result.Host]
+		if !exists {
+			hs = &HostStatistics{}
+			hosts[result.Host] = hs
+		}
+		hs.Requests++
+		if result.Error != nil {
+			hs.FailedRequests++
+			continue
+		}
+		durations[result.Host] = append(durations[result.Host], result.Duration)
+	}
+
+	for host, hs := range hosts {
+		sorted := durations[host]
+		if len(sorted) == 0 {
+			continue
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		})
+
+		var total time.Duration
+		for _, d := range sorted {
+			total += d
+		}
+		hs.AverageDuration = total / time.Duration(len(sorted))
+		hs.P50Latency = util.Percentile(sorted, 50)
+		hs.P95Latency = util.Percentile(sorted, 95)
+	}
+	stat.Hosts = hosts
+}
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// Key strategies choose which requests are grouped together as one endpoint,
+// and so how EndpointStats, the history trends and the reports are keyed.
+const (
+	KeyMethodURL = "method-url" // "GET https://api.example.com/users?page=2", the default
+	KeyURL       = "url"        // The URL alone, merging methods
+	KeyName      = "name"       // The endpoint's name, or method-url if it has none
+	KeyPath      = "path"       // Method and path template, with IDs replaced and the query dropped
+)
+
+// idSegment matches path segments that identify a resource rather than name
+// a route: numbers, UUIDs and long hex strings such as hashes or object IDs.
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// EndpointKey returns the key a request with the given method, URL and
+// endpoint name is grouped under by strategy.
+func EndpointKey(strategy, method, rawURL, name string) string {
+	switch strategy {
+	case KeyURL:
+		return rawURL
+	case KeyName:
+		if name != "" {
+			return name
+		}
+	case KeyPath:
+		return fmt.Sprintf("%s %s", method, PathTemplate(rawURL))
+	}
+	return fmt.Sprintf("%s %s", method, rawURL)
+}
+
+// PathTemplate drops the query of rawURL and replaces the IDs in its path
+// with {id}, so /users/42?fields=name becomes /users/{id}.
+func PathTemplate(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	// Built by hand as setting the path would escape the braces
+	template := strings.Join(segments, "/")
+	if u.Scheme != "" {
+		template = u.Scheme + "://" + u.Host + template
+	}
+	return template
+}
+
+// resultKey returns the key result is grouped under by the strategy the
+// statistics were calculated with.
+func (s *Statistics) resultKey(result runner.Result) string {
+	return EndpointKey(s.keyBy, result.Method, result.URL, result.Name)
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"testing"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+func TestEndpointKey(t *testing.T) {
+	const rawURL = "https://api.example.com/users/42?page=2"
+	tests := []struct {
+		strategy, name, want string
+	}{
+		{KeyMethodURL, "users", "GET https://api.example.com/users/42?page=2"},
+		{KeyURL, "users", "https://api.example.com/users/42?page=2"},
+		{KeyName, "users", "users"},
+		{KeyName, "", "GET https://api.example.com/users/42?page=2"},
+		{KeyPath, "users", "GET https://api.example.com/users/{id}"},
+		{"", "users", "GET https://api.example.com/users/42?page=2"},
+	}
+	for _, tt := range tests {
+		if got := EndpointKey(tt.strategy, "GET", rawURL, tt.name); got != tt.want {
+			t.Errorf("EndpointKey(%q, name %q) = %q, want %q", tt.strategy, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		rawURL, want string
+	}{
+		{"https://api.example.com/users/42", "https://api.example.com/users/{id}"},
+		{"https://api.example.com/users/42/orders/7?fields=id", "https://api.example.com/users/{id}/orders/{id}"},
+		{"https://api.example.com/items/3f2504e0-4f89-11d3-9a0c-0305e82c3301", "https://api.example.com/items/{id}"},
+		{"https://api.example.com/items/3F2504E0-4F89-11D3-9A0C-0305E82C3301/", "https://api.example.com/items/{id}/"},
+		{"https://api.example.com/blobs/0123456789abcdef0123", "https://api.example.com/blobs/{id}"},
+		// Route names, versions and short hex words are kept
+		{"https://api.example.com/v2/users/me", "https://api.example.com/v2/users/me"},
+		{"https://api.example.com/cafe/beef", "https://api.example.com/cafe/beef"},
+		{"https://api.example.com/users/42abc", "https://api.example.com/users/42abc"},
+		// Relative URLs keep no scheme or host
+		{"/users/42?x=1", "/users/{id}"},
+	}
+	for _, tt := range tests {
+		if got := PathTemplate(tt.rawURL); got != tt.want {
+			t.Errorf("PathTemplate(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+// TestCalculateByGroups checks that the statistics are keyed by the same
+// strategy as EndpointKey, and mark what the grouped requests don't share.
+func TestCalculateByGroups(t *testing.T) {
+	results := []runner.Result{
+		{Method: "GET", URL: "https://api.example.com/users/1", StatusCode: 200},
+		{Method: "GET", URL: "https://api.example.com/users/2", StatusCode: 200},
+		{Method: "PUT", URL: "https://api.example.com/users/2", StatusCode: 200},
+	}
+
+	byPath := CalculateBy(results, KeyPath)
+	if got := len(byPath.EndpointStats); got != 2 {
+		t.Fatalf("path keys: %d endpoints, want 2", got)
+	}
+	if es := byPath.EndpointStats["GET https://api.example.com/users/{id}"]; es == nil || es.TotalRequests != 2 {
+		t.Errorf("path keys: GET /users/{id} = %+v, want 2 requests", es)
+	}
+
+	byURL := CalculateBy(results, KeyURL)
+	es := byURL.EndpointStats["https://api.example.com/users/2"]
+	if es == nil || es.TotalRequests != 2 || es.Method != "*" {
+		t.Errorf("url keys: /users/2 = %+v, want 2 requests with method *", es)
+	}
+}
@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// maxLatencySamples bounds how many points of each endpoint's latency
+	// distribution are kept with its statistics for later comparison
+	maxLatencySamples = 1000
+
+	// ksSignificanceLevel is the p-value below which two distributions are
+	// considered different
+	ksSignificanceLevel = 0.05
+)
+
+// KSResult is the outcome of a two-sample Kolmogorov-Smirnov test between
+// two latency distributions.
+type KSResult struct {
+	Statistic   float64 `json:"statistic"`
+	PValue      float64 `json:"pValue"`
+	Significant bool    `json:"significant"`
+}
+
+// KSTest compares the latency distributions of two runs of an endpoint and
+// reports whether they differ significantly. It returns nil when either run
+// has no retained latency samples.
+func KSTest(current, baseline *EndpointStatistics) *KSResult {
+	if len(current.LatencySample) == 0 || len(baseline.LatencySample) == 0 {
+		return nil
+	}
+
+	d := ksStatistic(current.LatencySample, baseline.LatencySample)
+
+	// The samples are a compressed view of the full runs, so the effective
+	// sample sizes come from the request counts
+	n := float64(max(current.SuccessRequests, len(current.LatencySample)))
+	m := float64(max(baseline.SuccessRequests, len(baseline.LatencySample)))
+	ne := n * m / (n + m)
+	p := kolmogorovQ((math.Sqrt(ne) + 0.12 + 0.11/math.Sqrt(ne)) * d)
+
+	return &KSResult{
+		Statistic:   d,
+		PValue:      p,
+		Significant: p < ksSignificanceLevel,
+	}
+}
+
+// ksStatistic returns the largest distance between the empirical CDFs of
+// two sorted samples.
+func ksStatistic(a, b []time.Duration) float64 {
+	var i, j int
+	var d float64
+	for i < len(a) && j < len(b) {
+		v := min(a[i], b[j])
+		for i < len(a) && a[i] <= v {
+			i++
+		}
+		for j < len(b) && b[j] <= v {
+			j++
+		}
+		diff := math.Abs(float64(i)/float64(len(a)) - float64(j)/float64(len(b)))
+		if diff > d {
+			d = diff
+		}
+	}
+	return d
+}
+
+// kolmogorovQ is the complementary CDF of the Kolmogorov distribution.
+func kolmogorovQ(lambda float64) float64 {
+	if lambda < 1e-3 {
+		return 1
+	}
+
+	var sum float64
+	sign := 1.0
+	for j := 1; j <= 100; j++ {
+		term := sign * math.Exp(-2*float64(j*j)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-10 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Max(0, math.Min(1, 2*sum))
+}
+
+// sampleSorted picks up to limit evenly spaced values from sorted durations,
+// keeping the shape of the distribution while bounding its size.
+func sampleSorted(sorted []time.Duration, limit int) []time.Duration {
+	if len(sorted) <= limit {
+		sample := make([]time.Duration, len(sorted))
+		copy(sample, sorted)
+		return sample
+	}
+
+	sample := make([]time.Duration, limit)
+	for i := range sample {
+		sample[i] = sorted[i*(len(sorted)-1)/(limit-1)]
+	}
+	return sample
+}
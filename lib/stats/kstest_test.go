@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestKolmogorovQ(t *testing.T) {
+	tests := []struct {
+		lambda float64
+		want   float64
+	}{
+		{0, 1},
+		{0.5, 0.9639452},
+		{1, 0.2699997},
+		{1.36, 0.0494859},
+	}
+	for _, tt := range tests {
+		if got := kolmogorovQ(tt.lambda); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("kolmogorovQ(%v) = %.7f, want %.7f", tt.lambda, got, tt.want)
+		}
+	}
+}
+
+func TestKSTest(t *testing.T) {
+	// millis returns the latencies from to to, in ms, as a sorted sample
+	millis := func(from, to int) []time.Duration {
+		var sample []time.Duration
+		for ms := from; ms <= to; ms++ {
+			sample = append(sample, time.Duration(ms)*time.Millisecond)
+		}
+		return sample
+	}
+
+	// With 10 requests on each side the effective sample size is 5, so the
+	// p-value is kolmogorovQ((sqrt(5) + 0.12 + 0.11/sqrt(5)) * D)
+	tests := []struct {
+		name        string
+		current     []time.Duration
+		baseline    []time.Duration
+		wantD       float64
+		wantP       float64
+		significant bool
+	}{
+		{"identical", millis(1, 10), millis(1, 10), 0, 1, false},
+		{"shifted by half", millis(6, 15), millis(1, 10), 0.5, 0.1108403, false},
+		{"disjoint", millis(101, 110), millis(1, 10), 1, 0.0000189, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &EndpointStatistics{SuccessRequests: len(tt.current), LatencySample: tt.current}
+			baseline := &EndpointStatistics{SuccessRequests: len(tt.baseline), LatencySample: tt.baseline}
+			got := KSTest(current, baseline)
+			if got == nil {
+				t.Fatal("KSTest() = nil, want a result")
+			}
+			if math.Abs(got.Statistic-tt.wantD) > 1e-9 {
+				t.Errorf("D = %v, want %v", got.Statistic, tt.wantD)
+			}
+			if math.Abs(got.PValue-tt.wantP) > 1e-6 {
+				t.Errorf("p = %.7f, want %.7f", got.PValue, tt.wantP)
+			}
+			if got.Significant != tt.significant {
+				t.Errorf("Significant = %v, want %v", got.Significant, tt.significant)
+			}
+		})
+	}
+}
+
+func TestKSTestWithoutSamples(t *testing.T) {
+	sample := &EndpointStatistics{SuccessRequests: 1, LatencySample: []time.Duration{time.Millisecond}}
+	if got := KSTest(sample, &EndpointStatistics{}); got != nil {
+		t.Errorf("KSTest() = %+v, want nil without baseline samples", got)
+	}
+}
@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"percipio.com/gopi/lib/util"
+)
+
+// WriteOpenMetrics writes the final per-endpoint metrics of a run in the
+// OpenMetrics text exposition format, suitable for pushing to a Prometheus
+// Pushgateway. The given labels, e.g. commit and branch, are added to every
+// sample.
+func WriteOpenMetrics(w io.Writer, s *Statistics, labels map[string]string) error {
+	bw := bufio.NewWriter(w)
+
+	keys := make([]string, 0, len(s.EndpointStats))
+	for key := range s.EndpointStats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	labelSets := make(map[string]string, len(keys))
+	for _, key := range keys {
+		es := s.EndpointStats[key]
+		labelSets[key] = formatLabels(labels, es.Method, es.URL)
+	}
+
+	fmt.Fprintln(bw, "# TYPE gopi_requests counter")
+	fmt.Fprintln(bw, "# HELP gopi_requests Requests sent to the endpoint.")
+	for _, key := range keys {
+		fmt.Fprintf(bw, "gopi_requests_total{%s} %d\n", labelSets[key], s.EndpointStats[key].TotalRequests)
+	}
+
+	fmt.Fprintln(bw, "# TYPE gopi_request_errors counter")
+	fmt.Fprintln(bw, "# HELP gopi_request_errors Requests that failed or didn't pass validation.")
+	for _, key := range keys {
+		fmt.Fprintf(bw, "gopi_request_errors_total{%s} %d\n", labelSets[key], s.EndpointStats[key].FailedRequests)
+	}
+
+	fmt.Fprintln(bw, "# TYPE gopi_request_duration_seconds summary")
+	fmt.Fprintln(bw, "# UNIT gopi_request_duration_seconds seconds")
+	fmt.Fprintln(bw, "# HELP gopi_request_duration_seconds Latency of successful requests.")
+	for _, key := range keys {
+		es := s.EndpointStats[key]
+		quantiles := []struct {
+			q     string
+			value time.Duration
+		}{
+			{"0.5", es.P50Latency},
+			{"0.95", es.P95Latency},
+			{"0.99", es.P99Latency},
+			{"0.999", es.P999Latency},
+		}
+		for _, quantile := range quantiles {
+			fmt.Fprintf(bw, "gopi_request_duration_seconds{%s,quantile=\"%s\"} %g\n",
+				labelSets[key], quantile.q, quantile.value.Seconds())
+		}
+		fmt.Fprintf(bw, "gopi_request_duration_seconds_sum{%s} %g\n", labelSets[key], es.TotalDuration.Seconds())
+		fmt.Fprintf(bw, "gopi_request_duration_seconds_count{%s} %d\n", labelSets[key], es.SuccessRequests)
+	}
+
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
+
+func formatLabels(labels map[string]string, method, url string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names)+2)
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", name, util.EscapeLabelValue(labels[name])))
+	}
+	pairs = append(pairs,
+		fmt.Sprintf("method=\"%s\"", util.EscapeLabelValue(method)),
+		fmt.Sprintf("url=\"%s\"", util.EscapeLabelValue(url)))
+	return strings.Join(pairs, ",")
+}
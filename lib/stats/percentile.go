@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/util"
+)
+
+// PercentileLatency is the latency at one of the percentiles requested with
+// AddPercentiles.
+type PercentileLatency struct {
+	Percentile float64
+	Latency    time.Duration
+}
+
+// fixedPercentiles are always reported through their own fields, such as
+// EndpointStatistics.P95Latency.
+var fixedPercentiles = map[float64]bool{50: true, 95: true, 99: true, 99.9: true}
+
+// ExtraPercentiles returns the requested percentiles that aren't among the
+// fixed P50/P95/P99/P99.9, so summaries can list them without repeats.
+func (es *EndpointStatistics) ExtraPercentiles() []PercentileLatency {
+	var extra []PercentileLatency
+	for _, pl := range es.Percentiles {
+		if !fixedPercentiles[pl.Percentile] {
+			extra = append(extra, pl)
+		}
+	}
+	return extra
+}
+
+// Label names the percentile the way the reports do, e.g. "P99.9".
+func (pl PercentileLatency) Label() string {
+	return "P" + strconv.FormatFloat(pl.Percentile, 'f', -1, 64)
+}
+
+// AddPercentiles computes the given latency percentiles (0-100) of each
+// endpoint's successful requests into its Percentiles, for reporting
+// percentiles other than the fixed P50/P95/P99/P99.9.
+func (s *Statistics) AddPercentiles(results []runner.Result, percentiles []float64) {
+	if len(percentiles) == 0 {
+		return
+	}
+	results, _ = dropWarmup(results)
+
+	durations := make(map[string][]time.Duration)
+	for _, result := range results {
+		if result.Error != nil || result.Fault != "" {
+			continue
+		}
+		key := s.resultKey(result)
+		durations[key] = append(durations[key], result.Duration)
+	}
+
+	for key, es := range s.EndpointStats {
+		sorted := durations[key]
+		if len(sorted) == 0 {
+			continue
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		})
+
+		es.Percentiles = make([]PercentileLatency, len(percentiles))
+		for i, p := range percentiles {
+			es.Percentiles[i] = PercentileLatency{Percentile: p, Latency: util.Percentile(sorted, p)}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// PhaseBreakdown is the average time an endpoint's successful requests spent
+// in each phase, showing whether it is network, handshake or server bound.
+// Other is the remaining latency: waiting for a connection, sending the
+// request and reading the response.
+type PhaseBreakdown struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	Server  time.Duration
+	Other   time.Duration
+}
+
+// Total returns the average latency the phases add up to.
+func (p PhaseBreakdown) Total() time.Duration {
+	return p.DNS + p.Connect + p.TLS + p.Server + p.Other
+}
+
+func calculatePhaseStats(stat *EndpointStatistics, results []runner.Result) {
+	var sum PhaseBreakdown
+	count := 0
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+
+		phases := result.Phases
+		sum.DNS += phases.DNS
+		sum.Connect += phases.Connect
+		sum.TLS += phases.TLS
+		sum.Server += phases.Server
+		traced := phases.DNS + phases.Connect + phases.TLS + phases.Server
+		sum.Other += max(result.Duration-traced, 0)
+		count++
+	}
+
+	if count == 0 {
+		return
+	}
+
+	n := time.Duration(count)
+	stat.Phases = PhaseBreakdown{
+		DNS:     sum.DNS / n,
+		Connect: sum.Connect / n,
+		TLS:     sum.TLS / n,
+		Server:  sum.Server / n,
+		Other:   sum.Other / n,
+	}
+}
@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// latencySpread returns the standard deviation and variance (in ms²) of
+// durations. It uses Welford's algorithm, which stays accurate over the
+// hundreds of thousands of samples of a long run where summing squares
+// would lose precision.
+func latencySpread(durations []time.Duration) (time.Duration, float64) {
+	if len(durations) < 2 {
+		return 0, 0
+	}
+
+	var mean, m2 float64
+	for i, d := range durations {
+		ms := float64(d) / float64(time.Millisecond)
+		delta := ms - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (ms - mean)
+	}
+
+	variance := m2 / float64(len(durations))
+	return time.Duration(math.Sqrt(variance) * float64(time.Millisecond)), variance
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/util"
 )
 
 type EndpointStatistics struct {
@@ -20,22 +21,59 @@ type EndpointStatistics struct {
 	MinDuration       time.Duration
 	MaxDuration       time.Duration
 	MedianDuration    time.Duration
+	StdDevDuration    time.Duration // Standard deviation of the successful requests' latency
+	VarianceMS        float64       // Latency variance in ms², as a squared duration would overflow
 	Percentile95      time.Duration
 	Percentile99      time.Duration
 	RequestsPerSecond float64
+	TargetRPS         float64 // Offered load, 0 if the run wasn't rate limited
+	AchievedRPS       float64 // Completed requests per second of wall-clock time
+	BytesIn           int64   // Response body bytes received
+	BytesOut          int64   // Request body bytes sent
+	BytesInPerSecond  float64 // Response body bytes received per second of wall-clock time
+	BytesOutPerSecond float64 // Request body bytes sent per second of wall-clock time
 	StatusCodes       map[int]int
+	Protocols         map[string]int // Responses by negotiated protocol, such as "HTTP/2.0"
+	RuleFailures      map[string]int // Validation failures by rule type
+	Errors            map[string]int // Failures by normalized error message
+	Failures          FailureCategories
+	InjectedFaults    map[string]int // Deliberately invalid requests sent, by fault kind
+	InjectedRejected  int            // Injected requests that failed or got a 4xx/5xx, as intended
+	Retries           int            // Retry attempts sent on top of the requests
+	RetriedRequests   int            // Requests that needed at least one retry
 	SuccessCodes      int
 	ClientErrors      int
 	ServerErrors      int
 	P50Latency        time.Duration
 	P95Latency        time.Duration
 	P99Latency        time.Duration
+	P999Latency       time.Duration
+	AverageTTFB       time.Duration
+	P50TTFB           time.Duration
+	P95TTFB           time.Duration
+	P99TTFB           time.Duration
+	ConnectionStats   ConnectionStatistics
+	Phases            PhaseBreakdown             // Average time per request phase
+	Consistency       *ConsistencyStatistics     // Response variance, only set when bodies were hashed
+	Compression       *CompressionStatistics     // Response body sizes, only set when compression was negotiated
+	Hosts             map[string]*HostStatistics // Requests by host, only set when spread across several
+	LatencySample     []time.Duration            // Sorted sample of the latency distribution, up to maxLatencySamples, saved with every run
+	Histogram         *Histogram                 // Latency distribution over DefaultHistogramBounds, see SetHistogramBounds
+	Windows           []LatencyWindow            // Percentiles over time, see AddLatencyWindows
+	Percentiles       []PercentileLatency        // Requested latency percentiles, see AddPercentiles
 }
 
 type Statistics struct {
-	EndpointStats map[string]*EndpointStatistics
-	TotalRequests int
-	TotalDuration time.Duration
+	EndpointStats   map[string]*EndpointStatistics
+	TotalRequests   int
+	TotalDuration   time.Duration
+	TotalBytesIn    int64 // Response body bytes received over all endpoints
+	TotalBytesOut   int64 // Request body bytes sent over all endpoints
+	HealthScore     float64
+	ConnectionRates map[string]*ConnectionRate // New connections opened per host
+	WarmupRequests  int                        // Warmup requests left out of the statistics
+
+	keyBy string // Key strategy EndpointStats is keyed by
 }
 
 type LoadTestStats struct {
@@ -45,16 +83,21 @@ type LoadTestStats struct {
 	MaxLatency     time.Duration        `json:"maxLatency"`
 	MinLatency     time.Duration        `json:"minLatency"`
 	TotalRequests  int                  `json:"totalRequests"`
+	FailedRequests int                  `json:"failedRequests"`
 	TestDuration   time.Duration        `json:"testDuration"`
 }
 
 type StepStatistics struct {
-	UserCount         int           `json:"userCount,omitempty"`
-	DataSize          int           `json:"dataSize,omitempty"`
-	AverageLatency    time.Duration `json:"averageLatency"`
-	RequestsPerSecond float64       `json:"requestsPerSecond"`
-	SuccessRate       float64       `json:"successRate"`
-	ErrorRate         float64       `json:"errorRate"`
+	UserCount         int            `json:"userCount,omitempty"`
+	DataSize          int            `json:"dataSize,omitempty"`
+	AverageLatency    time.Duration  `json:"averageLatency"`
+	RequestsPerSecond float64        `json:"requestsPerSecond"`
+	SuccessRate       float64        `json:"successRate"`
+	ErrorRate         float64        `json:"errorRate"`
+	Journeys          map[string]int `json:"journeys,omitempty"`     // Completed scenario journeys by name
+	AbortedAfter      time.Duration  `json:"abortedAfter,omitempty"` // Time into the step it was aborted for unresponsiveness
+
+	ScenarioSteps []runner.ScenarioStepStats `json:"scenarioSteps,omitempty"` // Latency of each step of the scenarios played
 }
 
 type LoadStats struct {
@@ -68,28 +111,57 @@ type LoadStats struct {
 	MaxDataSize       int           `json:"maxDataSize"`
 }
 
+// Calculate computes the statistics of results per "METHOD URL".
 func Calculate(results []runner.Result) *Statistics {
+	return CalculateBy(results, KeyMethodURL)
+}
+
+// CalculateBy computes the statistics of results per endpoint, grouping the
+// requests into endpoints by keyBy, one of the Key strategies.
+func CalculateBy(results []runner.Result, keyBy string) *Statistics {
+	// Warmup requests only prepare the target, so they aren't measured
+	results, warmup := dropWarmup(results)
 	stats := &Statistics{
-		EndpointStats: make(map[string]*EndpointStatistics),
+		EndpointStats:   make(map[string]*EndpointStatistics),
+		ConnectionRates: calculateConnectionRates(results),
+		WarmupRequests:  warmup,
+		keyBy:           keyBy,
+	}
+
+	// Injected faults are expected to fail, so they are counted on their own
+	// rather than skewing the latency and error figures of real requests
+	results, injected := splitInjected(results)
+	for _, result := range injected {
+		stats.endpoint(result).countInjected(result)
 	}
 
+	grouped := make(map[string][]runner.Result)
 	for _, result := range results {
-		key := fmt.Sprintf("%s %s", result.Method, result.URL)
-		if _, exists := stats.EndpointStats[key]; !exists {
-			stats.EndpointStats[key] = &EndpointStatistics{
-				URL:         result.URL,
-				Method:      result.Method,
-				MinDuration: time.Hour,
-				StatusCodes: make(map[int]int),
-			}
-		}
+		key := stats.resultKey(result)
+		grouped[key] = append(grouped[key], result)
 
-		endpointStat := stats.EndpointStats[key]
+		endpointStat := stats.endpoint(result)
 		endpointStat.TotalRequests++
 		stats.TotalRequests++
+		endpointStat.BytesIn += result.BytesIn
+		endpointStat.BytesOut += result.BytesOut
+		stats.TotalBytesIn += result.BytesIn
+		stats.TotalBytesOut += result.BytesOut
+		if result.Retries > 0 {
+			endpointStat.Retries += result.Retries
+			endpointStat.RetriedRequests++
+		}
+		if result.Proto != "" {
+			endpointStat.Protocols[result.Proto]++
+		}
 
 		if result.Error != nil {
 			endpointStat.FailedRequests++
+			if result.FailedRule != "" {
+				endpointStat.RuleFailures[result.FailedRule]++
+			}
+			endpointStat.Errors[NormalizeError(result.Error.Error())]++
+			endpointStat.Failures.count(result)
 			continue
 		}
 
@@ -117,18 +189,66 @@ func Calculate(results []runner.Result) *Statistics {
 		}
 	}
 
-	for _, stat := range stats.EndpointStats {
-		calculateEndpointStats(stat, results)
+	for key, stat := range stats.EndpointStats {
+		calculateEndpointStats(stat, grouped[key])
 	}
 
 	return stats
 }
 
+// endpoint returns the statistics of the endpoint result was sent to, adding
+// them on first use. When the endpoint groups requests with different methods
+// or URLs, its Method or URL is "*".
+func (s *Statistics) endpoint(result runner.Result) *EndpointStatistics {
+	endpointURL := result.URL
+	if s.keyBy == KeyPath {
+		endpointURL = PathTemplate(endpointURL)
+	}
+
+	key := s.resultKey(result)
+	if es, exists := s.EndpointStats[key]; exists {
+		if es.Method != result.Method {
+			es.Method = "*"
+		}
+		if es.URL != endpointURL {
+			es.URL = "*"
+		}
+		return es
+	}
+
+	es := &EndpointStatistics{
+		URL:            endpointURL,
+		Method:         result.Method,
+		TargetRPS:      result.TargetRPS,
+		MinDuration:    time.Hour,
+		StatusCodes:    make(map[int]int),
+		Protocols:      make(map[string]int),
+		RuleFailures:   make(map[string]int),
+		Errors:         make(map[string]int),
+		InjectedFaults: make(map[string]int),
+	}
+	s.EndpointStats[key] = es
+	return es
+}
+
+// calculateEndpointStats fills in the figures of stat that need all of its
+// results at once, such as percentiles. results are the endpoint's own.
 func calculateEndpointStats(stat *EndpointStatistics, results []runner.Result) {
 	var durations []time.Duration
+	var ttfbs []time.Duration
+	var first, last time.Time
 	for _, result := range results {
-		if result.URL == stat.URL && result.Error == nil {
+		if first.IsZero() || result.StartTime.Before(first) {
+			first = result.StartTime
+		}
+		if result.EndTime.After(last) {
+			last = result.EndTime
+		}
+		if result.Error == nil {
 			durations = append(durations, result.Duration)
+			if result.TTFB > 0 {
+				ttfbs = append(ttfbs, result.TTFB)
+			}
 		}
 	}
 
@@ -138,12 +258,36 @@ func calculateEndpointStats(stat *EndpointStatistics, results []runner.Result) {
 		})
 
 		stat.AverageDuration = time.Duration(stat.TotalDuration.Nanoseconds() / int64(stat.SuccessRequests))
-		stat.MedianDuration = durations[len(durations)/2]
-		stat.Percentile95 = durations[int(float64(len(durations))*0.95)]
-		stat.Percentile99 = durations[int(float64(len(durations))*0.99)]
-		stat.RequestsPerSecond = float64(stat.SuccessRequests) / stat.TotalDuration.Seconds()
+		stat.MedianDuration = util.Percentile(durations, 50)
+		stat.Percentile95 = util.Percentile(durations, 95)
+		stat.Percentile99 = util.Percentile(durations, 99)
+		stat.StdDevDuration, stat.VarianceMS = latencySpread(durations)
 		stat.calculatePercentiles(durations)
+		stat.LatencySample = sampleSorted(durations, maxLatencySamples)
+		stat.Histogram = NewHistogram(DefaultHistogramBounds)
+		for _, d := range durations {
+			stat.Histogram.Add(d)
+		}
+	}
+
+	if len(ttfbs) > 0 {
+		stat.calculateTTFB(ttfbs)
 	}
+
+	// Throughput is over the wall-clock time the endpoint's requests spanned,
+	// not the sum of their durations, which concurrent requests overlap in
+	if window := last.Sub(first); window > 0 {
+		stat.RequestsPerSecond = float64(stat.SuccessRequests) / window.Seconds()
+		stat.AchievedRPS = float64(stat.TotalRequests) / window.Seconds()
+		stat.BytesInPerSecond = float64(stat.BytesIn) / window.Seconds()
+		stat.BytesOutPerSecond = float64(stat.BytesOut) / window.Seconds()
+	}
+
+	calculateConnectionStats(stat, results)
+	calculateConsistencyStats(stat, results)
+	calculateCompressionStats(stat, results)
+	calculatePhaseStats(stat, results)
+	calculateHostStats(stat, results)
 }
 
 func (s *EndpointStatistics) calculatePercentiles(durations []time.Duration) {
@@ -151,10 +295,49 @@ func (s *EndpointStatistics) calculatePercentiles(durations []time.Duration) {
 		return durations[i] < durations[j]
 	})
 
-	l := len(durations)
-	s.P50Latency = durations[l*50/100]
-	s.P95Latency = durations[l*95/100]
-	s.P99Latency = durations[l*99/100]
+	s.P50Latency = util.Percentile(durations, 50)
+	s.P95Latency = util.Percentile(durations, 95)
+	s.P99Latency = util.Percentile(durations, 99)
+	s.P999Latency = util.Percentile(durations, 99.9)
+}
+
+func (s *EndpointStatistics) calculateTTFB(ttfbs []time.Duration) {
+	sort.Slice(ttfbs, func(i, j int) bool {
+		return ttfbs[i] < ttfbs[j]
+	})
+
+	var total time.Duration
+	for _, t := range ttfbs {
+		total += t
+	}
+
+	s.AverageTTFB = total / time.Duration(len(ttfbs))
+	s.P50TTFB = util.Percentile(ttfbs, 50)
+	s.P95TTFB = util.Percentile(ttfbs, 95)
+	s.P99TTFB = util.Percentile(ttfbs, 99)
+}
+
+// BelowTarget reports whether the achieved throughput fell short of the target
+// by more than tolerance percent. Endpoints without a target never fall short.
+func (s *EndpointStatistics) BelowTarget(tolerance float64) bool {
+	if s.TargetRPS <= 0 {
+		return false
+	}
+	return s.AchievedRPS < s.TargetRPS*(1-tolerance/100)
+}
+
+// ErrorRate returns the percentage of failed requests across all endpoints.
+func (s *Statistics) ErrorRate() float64 {
+	failed := 0
+	total := 0
+	for _, es := range s.EndpointStats {
+		failed += es.FailedRequests
+		total += es.TotalRequests
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(failed) / float64(total) * 100
 }
 
 func (s *Statistics) String() string {
@@ -170,14 +353,63 @@ func (s *Statistics) String() string {
 		sb.WriteString(fmt.Sprintf("Total Requests:    %d\n", stat.TotalRequests))
 		sb.WriteString(fmt.Sprintf("Successful:        %d\n", stat.SuccessRequests))
 		sb.WriteString(fmt.Sprintf("Failed:            %d\n", stat.FailedRequests))
-		sb.WriteString(fmt.Sprintf("Requests/second:   %.2f\n\n", stat.RequestsPerSecond))
+		if f := stat.Failures; f.Total() > 0 {
+			sb.WriteString(fmt.Sprintf("  Timeouts:        %d\n", f.Timeouts))
+			sb.WriteString(fmt.Sprintf("  DNS errors:      %d\n", f.DNSErrors))
+			sb.WriteString(fmt.Sprintf("  Connection:      %d\n", f.ConnectionErrors))
+			sb.WriteString(fmt.Sprintf("  Validation:      %d\n", f.Validation))
+			sb.WriteString(fmt.Sprintf("  Other:           %d\n", f.Other))
+		}
+		sb.WriteString(fmt.Sprintf("Requests/second:   %.2f\n", stat.RequestsPerSecond))
+		if stat.TargetRPS > 0 {
+			sb.WriteString(fmt.Sprintf("Target RPS:        %.2f\n", stat.TargetRPS))
+			sb.WriteString(fmt.Sprintf("Achieved RPS:      %.2f\n", stat.AchievedRPS))
+		}
+		sb.WriteString(fmt.Sprintf("Bytes in / out:    %s / %s\n", util.FormatBytes(float64(stat.BytesIn)), util.FormatBytes(float64(stat.BytesOut))))
+		sb.WriteString(fmt.Sprintf("Bandwidth in/out:  %s/s / %s/s\n", util.FormatBytes(stat.BytesInPerSecond), util.FormatBytes(stat.BytesOutPerSecond)))
+		sb.WriteString("\n")
 		sb.WriteString("Latency Statistics:\n")
 		sb.WriteString(fmt.Sprintf("  Average:    %v\n", stat.AverageDuration))
+		sb.WriteString(fmt.Sprintf("  Std dev:    %v (variance %.2fms²)\n", stat.StdDevDuration, stat.VarianceMS))
 		sb.WriteString(fmt.Sprintf("  Median:     %v\n", stat.MedianDuration))
 		sb.WriteString(fmt.Sprintf("  Minimum:    %v\n", stat.MinDuration))
 		sb.WriteString(fmt.Sprintf("  Maximum:    %v\n", stat.MaxDuration))
 		sb.WriteString(fmt.Sprintf("  95th %%:     %v\n", stat.Percentile95))
-		sb.WriteString(fmt.Sprintf("  99th %%:     %v\n\n", stat.Percentile99))
+		sb.WriteString(fmt.Sprintf("  99th %%:     %v\n", stat.Percentile99))
+		sb.WriteString(fmt.Sprintf("  99.9th %%:   %v\n", stat.P999Latency))
+		for _, pl := range stat.ExtraPercentiles() {
+			sb.WriteString(fmt.Sprintf("  %-11s %v\n", pl.Label()+":", pl.Latency))
+		}
+		sb.WriteString("\n")
+
+		sb.WriteString("Time To First Byte:\n")
+		sb.WriteString(fmt.Sprintf("  Average:    %v\n", stat.AverageTTFB))
+		sb.WriteString(fmt.Sprintf("  50th %%:     %v\n", stat.P50TTFB))
+		sb.WriteString(fmt.Sprintf("  95th %%:     %v\n", stat.P95TTFB))
+		sb.WriteString(fmt.Sprintf("  99th %%:     %v\n\n", stat.P99TTFB))
+
+		sb.WriteString("Average Time per Phase:\n")
+		sb.WriteString(fmt.Sprintf("  DNS:        %v\n", stat.Phases.DNS))
+		sb.WriteString(fmt.Sprintf("  Connect:    %v\n", stat.Phases.Connect))
+		sb.WriteString(fmt.Sprintf("  TLS:        %v\n", stat.Phases.TLS))
+		sb.WriteString(fmt.Sprintf("  Server:     %v\n", stat.Phases.Server))
+		sb.WriteString(fmt.Sprintf("  Other:      %v\n\n", stat.Phases.Other))
+
+		cs := stat.ConnectionStats
+		sb.WriteString("Connections:\n")
+		sb.WriteString(fmt.Sprintf("  Connections used:     %d\n", cs.Connections))
+		sb.WriteString(fmt.Sprintf("  Requests/connection:  %.2f\n", cs.RequestsPerConnection))
+		sb.WriteString(fmt.Sprintf("  New / reused:         %d / %d (%.1f%% reused)\n", cs.NewConnRequests, cs.ReusedRequests, cs.ReusedPct()))
+		sb.WriteString(fmt.Sprintf("  Avg connection wait:  %v (max %v)\n", cs.AverageConnWait, cs.MaxConnWait))
+		sb.WriteString(fmt.Sprintf("  New conn P50/P95:     %v / %v\n", cs.NewConnP50Latency, cs.NewConnP95Latency))
+		sb.WriteString(fmt.Sprintf("  Reused conn P50/P95:  %v / %v\n\n", cs.ReusedP50Latency, cs.ReusedP95Latency))
+
+		if c := stat.Compression; c != nil {
+			sb.WriteString("Response Bodies:\n")
+			sb.WriteString(fmt.Sprintf("  Avg received:         %d bytes\n", c.AverageCompressed()))
+			sb.WriteString(fmt.Sprintf("  Avg decoded:          %d bytes\n", c.AverageUncompressed()))
+			sb.WriteString(fmt.Sprintf("  Compression ratio:    %.2fx\n\n", c.Ratio()))
+		}
 
 		sb.WriteString("\nStatus Code Distribution:\n")
 		for code, count := range stat.StatusCodes {
@@ -186,6 +418,13 @@ func (s *Statistics) String() string {
 		sb.WriteString(fmt.Sprintf("  2xx Responses: %d\n", stat.SuccessCodes))
 		sb.WriteString(fmt.Sprintf("  4xx Responses: %d\n", stat.ClientErrors))
 		sb.WriteString(fmt.Sprintf("  5xx Responses: %d\n", stat.ServerErrors))
+
+		if len(stat.RuleFailures) > 0 {
+			sb.WriteString("\nValidation Failures:\n")
+			for rule, count := range stat.RuleFailures {
+				sb.WriteString(fmt.Sprintf("  %s: %d requests\n", rule, count))
+			}
+		}
 		sb.WriteString("\n")
 	}
 
@@ -208,10 +447,16 @@ func CalculateLoadTest(results []runner.LoadTestResult) *LoadTestStats {
 			RequestsPerSecond: calculateOverallRPS(stepStats),
 			SuccessRate:       calculateOverallSuccessRate(stepStats),
 			ErrorRate:         calculateOverallErrorRate(stepStats),
+			Journeys:          result.Journeys,
+			AbortedAfter:      result.AbortedAfter,
+			ScenarioSteps:     result.ScenarioSteps,
 		})
 
 		// Update aggregate stats
 		stats.TotalRequests += countTotalRequests(stepStats)
+		for _, es := range stepStats.EndpointStats {
+			stats.FailedRequests += es.FailedRequests
+		}
 		updateLatencyStats(stats, avgLatency)
 	}
 
@@ -257,6 +502,14 @@ func calculateOverallErrorRate(stats *Statistics) float64 {
 	return 100 - calculateOverallSuccessRate(stats)
 }
 
+// ErrorRate returns the percentage of failed requests across all steps.
+func (s *LoadTestStats) ErrorRate() float64 {
+	if s.TotalRequests == 0 {
+		return 0
+	}
+	return float64(s.FailedRequests) / float64(s.TotalRequests) * 100
+}
+
 func countTotalRequests(stats *Statistics) int {
 	total := 0
 	for _, es := range stats.EndpointStats {
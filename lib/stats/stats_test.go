@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// TestRequestsPerSecondWallClock checks that throughput is over the
+// wall-clock span of an endpoint's requests rather than the sum of their
+// durations, which overlap when requests run concurrently.
+func TestRequestsPerSecondWallClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := func(url string, offset, duration time.Duration, err error) runner.Result {
+		return runner.Result{
+			URL:        url,
+			Method:     "GET",
+			StatusCode: 200,
+			Duration:   duration,
+			StartTime:  start.Add(offset),
+			EndTime:    start.Add(offset + duration),
+			Error:      err,
+		}
+	}
+
+	var results []runner.Result
+	// /a: 10 overlapping 1.1s requests, 100ms apart, spanning 2s
+	for i := 0; i < 10; i++ {
+		results = append(results, result("/a", time.Duration(i)*100*time.Millisecond, 1100*time.Millisecond, nil))
+	}
+	// /b: 4 back to back 1s requests spanning 4s, one of which failed
+	for i := 0; i < 4; i++ {
+		var err error
+		if i == 3 {
+			err = errors.New("connection reset")
+		}
+		results = append(results, result("/b", time.Duration(i)*time.Second, time.Second, err))
+	}
+
+	statistics := Calculate(results)
+	tests := []struct {
+		key              string
+		rps, achievedRPS float64
+	}{
+		{"GET /a", 5, 5},    // 10 successes in 2s, where summed durations would give 0.91
+		{"GET /b", 0.75, 1}, // 3 successes of 4 requests in 4s
+	}
+	for _, tt := range tests {
+		es := statistics.EndpointStats[tt.key]
+		if es == nil {
+			t.Fatalf("no statistics for %s", tt.key)
+		}
+		if !approx(es.RequestsPerSecond, tt.rps) {
+			t.Errorf("%s RequestsPerSecond = %v, want %v", tt.key, es.RequestsPerSecond, tt.rps)
+		}
+		if !approx(es.AchievedRPS, tt.achievedRPS) {
+			t.Errorf("%s AchievedRPS = %v, want %v", tt.key, es.AchievedRPS, tt.achievedRPS)
+		}
+	}
+
+	if got := calculateOverallRPS(statistics); !approx(got, 5.75) {
+		t.Errorf("overall RPS = %v, want 5.75", got)
+	}
+}
+
+func approx(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
+
+func TestSetHistogramBounds(t *testing.T) {
+	var results []runner.Result
+	for _, ms := range []int{5, 20, 40, 300} {
+		results = append(results, runner.Result{URL: "/a", Method: "GET", StatusCode: 200, Duration: time.Duration(ms) * time.Millisecond})
+	}
+	results = append(results, runner.Result{URL: "/a", Method: "GET", Duration: time.Second, Error: errors.New("timeout")})
+
+	statistics := Calculate(results)
+	statistics.SetHistogramBounds(results, []time.Duration{10 * time.Millisecond, 50 * time.Millisecond})
+
+	h := statistics.EndpointStats["GET /a"].Histogram
+	want := []int{1, 2, 1}
+	if len(h.Counts) != len(want) {
+		t.Fatalf("histogram has %d buckets, want %d", len(h.Counts), len(want))
+	}
+	for i, count := range want {
+		if h.Counts[i] != count {
+			t.Errorf("bucket %s has %d requests, want %d", h.Label(i), h.Counts[i], count)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package stats
+
+import (
+	"slices"
+
+	"percipio.com/gopi/lib/runner"
+)
+
+// dropWarmup returns results without the requests that only warmed up the
+// target, and how many of them there were. results is returned as is when
+// there were none.
+func dropWarmup(results []runner.Result) ([]runner.Result, int) {
+	if !slices.ContainsFunc(results, func(r runner.Result) bool { return r.Warmup }) {
+		return results, 0
+	}
+
+	measured := make([]runner.Result, 0, len(results))
+	for _, result := range results {
+		if !result.Warmup {
+			measured = append(measured, result)
+		}
+	}
+	return measured, len(results) - len(measured)
+}
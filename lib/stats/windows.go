@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/util"
+)
+
+// LatencyWindow holds the latency percentiles of the successful requests that
+// started within one time window of a run.
+type LatencyWindow struct {
+	Offset     time.Duration // Start of the window relative to the start of the run
+	Requests   int
+	P50Latency time.Duration
+	P95Latency time.Duration
+	P99Latency time.Duration
+}
+
+// AddLatencyWindows splits each endpoint's successful requests into windows of
+// the given size by start time and computes percentiles per window, so a
+// latency spike partway through a run isn't hidden by the aggregate numbers.
+// Windows without any successful requests are left out.
+func (s *Statistics) AddLatencyWindows(results []runner.Result, size time.Duration) {
+	results, _ = dropWarmup(results)
+	if size <= 0 || len(results) == 0 {
+		return
+	}
+
+	start := results[0].StartTime
+	for _, result := range results {
+		if result.StartTime.Before(start) {
+			start = result.StartTime
+		}
+	}
+
+	buckets := make(map[string]map[int][]time.Duration)
+	for _, result := range results {
+		if result.Error != nil || result.Fault != "" {
+			continue
+		}
+		key := s.resultKey(result)
+		if buckets[key] == nil {
+			buckets[key] = make(map[int][]time.Duration)
+		}
+		index := int(result.StartTime.Sub(start) / size)
+		buckets[key][index] = append(buckets[key][index], result.Duration)
+	}
+
+	for key, windows := range buckets {
+		es, exists := s.EndpointStats[key]
+		if !exists {
+			continue
+		}
+
+		indexes := make([]int, 0, len(windows))
+		for index := range windows {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+
+		es.Windows = make([]LatencyWindow, 0, len(indexes))
+		for _, index := range indexes {
+			durations := windows[index]
+			sort.Slice(durations, func(i, j int) bool {
+				return durations[i] < durations[j]
+			})
+			es.Windows = append(es.Windows, LatencyWindow{
+				Offset:     time.Duration(index) * size,
+				Requests:   len(durations),
+				P50Latency: util.Percentile(durations, 50),
+				P95Latency: util.Percentile(durations, 95),
+				P99Latency: util.Percentile(durations, 99),
+			})
+		}
+	}
+}
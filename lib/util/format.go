@@ -1,6 +1,9 @@
 package util
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 func FormatChange(value float64) string {
 	if value > 0 {
@@ -13,9 +16,43 @@ func FormatFloat(value float64) string {
 	return fmt.Sprintf("%.2f", value)
 }
 
+// FormatBytes formats a byte count or rate with a binary unit, such as
+// "1.50 MiB".
+func FormatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for bytes >= 1024 && i < len(units)-1 {
+		bytes /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	return fmt.Sprintf("%.2f %s", bytes, units[i])
+}
+
 func CalculatePercentageChange(current, previous float64) float64 {
 	if previous == 0 {
 		return 0
 	}
 	return ((current - previous) / previous) * 100
 }
+
+// labelEscaper escapes label values for the Prometheus and OpenMetrics text
+// formats.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// EscapeLabelValue escapes value for use as a quoted label value in the
+// Prometheus and OpenMetrics text formats.
+func EscapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// ShortHash returns the first n characters of a commit hash, or the whole
+// hash when it is shorter, such as a hand-edited or timestamp-based one.
+func ShortHash(hash string, n int) string {
+	if len(hash) <= n {
+		return hash
+	}
+	return hash[:n]
+}
@@ -0,0 +1,39 @@
+package util
+
+import "testing"
+
+func TestShortHash(t *testing.T) {
+	tests := []struct {
+		hash string
+		n    int
+		want string
+	}{
+		{"ab12c", 8, "ab12c"},
+		{"", 7, ""},
+		{"ab12cd3", 7, "ab12cd3"},
+		{"0123456789abcdef", 7, "0123456"},
+		{"0123456789abcdef", 8, "01234567"},
+	}
+	for _, tt := range tests {
+		if got := ShortHash(tt.hash, tt.n); got != tt.want {
+			t.Errorf("ShortHash(%q, %d) = %q, want %q", tt.hash, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"GET /users", "GET /users"},
+		{`say "hi"`, `say \"hi\"`},
+		{`C:\path`, `C:\\path`},
+		{"two\nlines", `two\nlines`},
+	}
+	for _, tt := range tests {
+		if got := EscapeLabelValue(tt.value); got != tt.want {
+			t.Errorf("EscapeLabelValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package util
+
+import "time"
+
+// Percentile returns the p-th percentile (0-100) of sorted, linearly
+// interpolating between the two nearest samples (the R-7 method of most
+// statistics packages). p is clamped to [0, 100], so high percentiles such as
+// P99.9 never index past the end of small slices. An empty slice gives 0.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	p = min(max(p, 0), 100)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	frac := rank - float64(lower)
+	return sorted[lower] + time.Duration(frac*float64(sorted[lower+1]-sorted[lower]))
+}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(values ...float64) []time.Duration {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		durations[i] = time.Duration(v * float64(time.Millisecond))
+	}
+	return durations
+}
+
+// TestPercentileR7 checks the interpolation against values computed by hand
+// with the R-7 method: rank = p/100 * (n-1), interpolated between the samples
+// either side of it.
+func TestPercentileR7(t *testing.T) {
+	sorted := ms(10, 20, 30, 40)
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 10 * time.Millisecond},
+		{25, 17500 * time.Microsecond}, // rank 0.75
+		{50, 25 * time.Millisecond},    // rank 1.5
+		{90, 37 * time.Millisecond},    // rank 2.7
+		{100, 40 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v, %v) = %v, want %v", sorted, tt.p, got, tt.want)
+		}
+	}
+}
+
+// series returns the samples 1ms, 2ms, ... nms.
+func series(n int) []time.Duration {
+	durations := make([]time.Duration, n)
+	for i := range durations {
+		durations[i] = time.Duration(i+1) * time.Millisecond
+	}
+	return durations
+}
+
+// near reports whether got is within a nanosecond of want, allowing for the
+// float rounding of the interpolation.
+func near(got, want time.Duration) bool {
+	return got-want <= 1 && want-got <= 1
+}
+
+func TestPercentileSampleSizes(t *testing.T) {
+	tests := []struct {
+		n                  int
+		p0, p50, p99, p100 time.Duration
+	}{
+		{0, 0, 0, 0, 0},
+		{1, time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond},
+		{2, time.Millisecond, 1500 * time.Microsecond, 1990 * time.Microsecond, 2 * time.Millisecond},
+		{100, time.Millisecond, 50500 * time.Microsecond, 99010 * time.Microsecond, 100 * time.Millisecond},
+		{1000, time.Millisecond, 500500 * time.Microsecond, 990010 * time.Microsecond, 1000 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		sorted := series(tt.n)
+		for _, c := range []struct {
+			p    float64
+			want time.Duration
+		}{{0, tt.p0}, {50, tt.p50}, {99, tt.p99}, {100, tt.p100}} {
+			if got := Percentile(sorted, c.p); !near(got, c.want) {
+				t.Errorf("n=%d: P%v = %v, want %v", tt.n, c.p, got, c.want)
+			}
+		}
+	}
+}
+
+// TestPercentileP999 covers the lengths at which P99.9 used to index past the
+// end of the samples, and percentiles beyond 100, which are clamped.
+func TestPercentileP999(t *testing.T) {
+	tests := []struct {
+		n    int
+		p    float64
+		want time.Duration
+	}{
+		{1, 99.9, time.Millisecond},
+		{2, 99.9, 1999 * time.Microsecond},
+		{999, 99.9, 998002 * time.Microsecond},  // rank 997.002
+		{1000, 99.9, 999001 * time.Microsecond}, // rank 998.001
+		{1000, 100.5, 1000 * time.Millisecond},
+		{1000, -1, time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := Percentile(series(tt.n), tt.p); !near(got, tt.want) {
+			t.Errorf("n=%d: P%v = %v, want %v", tt.n, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep is one field name or array index in a JSON path
+type pathStep struct {
+	field string
+	index int
+	isIdx bool
+}
+
+// parsePath parses the dotted JSON path subset supported by the rules:
+// "$.field.nested[0].name". The leading "$" is optional.
+func parsePath(path string) ([]pathStep, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return nil, fmt.Errorf("empty JSON path")
+	}
+
+	var steps []pathStep
+	for _, part := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name != "" {
+			steps = append(steps, pathStep{field: name})
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("unterminated index in JSON path %q", path)
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index %q in JSON path %q", idx, path)
+			}
+			steps = append(steps, pathStep{index: n, isIdx: true})
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return steps, nil
+}
+
+// Lookup resolves path against a decoded JSON document.
+func Lookup(doc interface{}, path string) (interface{}, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, step := range steps {
+		if step.isIdx {
+			arr, ok := current.([]interface{})
+			if !ok || step.index >= len(arr) {
+				return nil, fmt.Errorf("%s: index %d not found", path, step.index)
+			}
+			current = arr[step.index]
+			continue
+		}
+
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: field %q not found", path, step.field)
+		}
+		if current, ok = obj[step.field]; !ok {
+			return nil, fmt.Errorf("%s: field %q not found", path, step.field)
+		}
+	}
+	return current, nil
+}
+
+// CheckPath reports whether path is a JSON path Lookup can resolve.
+func CheckPath(path string) error {
+	_, err := parsePath(path)
+	return err
+}
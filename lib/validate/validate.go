@@ -0,0 +1,208 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RuleStatusIn       = "status-in"
+	RuleBodyContains   = "body-contains"
+	RuleJSONPathEquals = "json-path-equals"
+	RuleHeaderEquals   = "header-equals"
+	RuleMaxLatency     = "max-latency"
+	RuleExpectStatus   = "expect-status"
+)
+
+// statusPattern matches the statuses an expect-status rule can expect: a
+// code such as "200" or a class such as "2xx".
+var statusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+// Rule is a single check a response must pass to count as successful
+type Rule struct {
+	Type     string `json:"type"`
+	Statuses []int  `json:"statuses,omitempty"` // status-in
+	Path     string `json:"path,omitempty"`     // json-path-equals, e.g. "$.data.items[0].id"
+	Header   string `json:"header,omitempty"`   // header-equals
+	Value    string `json:"value,omitempty"`    // body-contains, json-path-equals, header-equals, expect-status
+	MaxMs    int    `json:"maxMs,omitempty"`    // max-latency
+}
+
+// Response is the part of an HTTP response the rules are evaluated against
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration
+}
+
+// RuleError reports a rule a response did not pass
+type RuleError struct {
+	Rule   Rule
+	Reason string
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("validation %s failed: %s", e.Rule.Type, e.Reason)
+}
+
+// Check reports configuration problems in rules, such as unknown rule types
+// or missing parameters.
+func Check(rules []Rule) error {
+	for _, rule := range rules {
+		switch rule.Type {
+		case RuleStatusIn:
+			if len(rule.Statuses) == 0 {
+				return fmt.Errorf("%s rule requires statuses", rule.Type)
+			}
+		case RuleBodyContains:
+			if rule.Value == "" {
+				return fmt.Errorf("%s rule requires a value", rule.Type)
+			}
+		case RuleJSONPathEquals:
+			if _, err := parsePath(rule.Path); err != nil {
+				return fmt.Errorf("%s rule: %w", rule.Type, err)
+			}
+		case RuleHeaderEquals:
+			if rule.Header == "" {
+				return fmt.Errorf("%s rule requires a header", rule.Type)
+			}
+		case RuleMaxLatency:
+			if rule.MaxMs <= 0 {
+				return fmt.Errorf("%s rule requires maxMs greater than 0", rule.Type)
+			}
+		case RuleExpectStatus:
+			if !statusPattern.MatchString(strings.ToLower(rule.Value)) {
+				return fmt.Errorf("%s rule requires a status code or class such as \"2xx\", got %q", rule.Type, rule.Value)
+			}
+		default:
+			return fmt.Errorf("unknown validation rule type %q", rule.Type)
+		}
+	}
+	return nil
+}
+
+// NeedsBody reports whether any of the rules inspect the response body.
+func NeedsBody(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.Type == RuleBodyContains || rule.Type == RuleJSONPathEquals {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate evaluates every rule against resp. It returns nil if all of them
+// pass, otherwise an error joining a *RuleError for each failed rule.
+func Validate(rules []Rule, resp Response) error {
+	var errs []error
+	for _, rule := range rules {
+		if reason := evaluate(rule, resp); reason != "" {
+			errs = append(errs, &RuleError{Rule: rule, Reason: reason})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FailedRule returns the type of the first rule that failed in err, or an
+// empty string if err is not a validation failure.
+func FailedRule(err error) string {
+	var ruleErr *RuleError
+	if errors.As(err, &ruleErr) {
+		return ruleErr.Rule.Type
+	}
+	return ""
+}
+
+func evaluate(rule Rule, resp Response) string {
+	switch rule.Type {
+	case RuleStatusIn:
+		if !slices.Contains(rule.Statuses, resp.StatusCode) {
+			return fmt.Sprintf("status %d not in %v", resp.StatusCode, rule.Statuses)
+		}
+	case RuleBodyContains:
+		if !bytes.Contains(resp.Body, []byte(rule.Value)) {
+			return fmt.Sprintf("body does not contain %q", rule.Value)
+		}
+	case RuleJSONPathEquals:
+		doc, err := DecodeJSON(resp.Body)
+		if err != nil {
+			return fmt.Sprintf("body is not valid JSON: %v", err)
+		}
+		value, err := Lookup(doc, rule.Path)
+		if err != nil {
+			return err.Error()
+		}
+		if actual := FormatValue(value); actual != rule.Value {
+			return fmt.Sprintf("%s is %q, expected %q", rule.Path, actual, rule.Value)
+		}
+	case RuleHeaderEquals:
+		if actual := resp.Header.Get(rule.Header); actual != rule.Value {
+			return fmt.Sprintf("header %s is %q, expected %q", rule.Header, actual, rule.Value)
+		}
+	case RuleMaxLatency:
+		if limit := time.Duration(rule.MaxMs) * time.Millisecond; resp.Duration > limit {
+			return fmt.Sprintf("latency %v exceeds %v", resp.Duration, limit)
+		}
+	case RuleExpectStatus:
+		if !statusMatches(rule.Value, resp.StatusCode) {
+			return fmt.Sprintf("status %d, expected %s", resp.StatusCode, rule.Value)
+		}
+	}
+	return ""
+}
+
+// statusMatches reports whether code is the status code or in the status
+// class given by expected.
+func statusMatches(expected string, code int) bool {
+	expected = strings.ToLower(expected)
+	if class, ok := strings.CutSuffix(expected, "xx"); ok {
+		return strconv.Itoa(code/100) == class
+	}
+	return strconv.Itoa(code) == expected
+}
+
+// DecodeJSON decodes a JSON document for Lookup and FormatValue, keeping
+// numbers as json.Number so large IDs stay exact and numbers keep the form
+// they were written in rather than becoming floats such as 1e+06.
+func DecodeJSON(data []byte) (interface{}, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	// Decode stops after the first value, where Unmarshal rejects the rest
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return doc, nil
+}
+
+// FormatValue renders a value decoded by DecodeJSON the way it would be
+// written in a rule, so numbers, booleans and strings can all be compared as
+// text.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,24 @@
+package validate
+
+import "testing"
+
+func TestJSONPathEqualsNumbers(t *testing.T) {
+	tests := []struct {
+		body, expected string
+		pass           bool
+	}{
+		{`{"id": 1000000}`, "1000000", true},
+		{`{"id": 9007199254740993}`, "9007199254740993", true},
+		{`{"id": 1.5}`, "1.5", true},
+		{`{"id": 2}`, "3", false},
+		{`{"id": "1000000"}`, "1000000", true},
+		{`{"id": 1} trailing`, "1", false},
+	}
+	for _, tt := range tests {
+		rule := Rule{Type: RuleJSONPathEquals, Path: "$.id", Value: tt.expected}
+		reason := evaluate(rule, Response{StatusCode: 200, Body: []byte(tt.body)})
+		if (reason == "") != tt.pass {
+			t.Errorf("body %s, expected %s: got %q, want pass %v", tt.body, tt.expected, reason, tt.pass)
+		}
+	}
+}
@@ -1,18 +1,22 @@
 package viz
 
 import (
+	"cmp"
 	_ "embed"
 	"fmt"
 	"html/template"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	hist "percipio.com/gopi/lib/history"
 	"percipio.com/gopi/lib/logger"
+	"percipio.com/gopi/lib/stats"
 	"percipio.com/gopi/lib/util"
 )
 
@@ -25,6 +29,33 @@ const (
 	xPadding          = 50.0
 )
 
+// GraphOptions controls the dimensions of the rendered graphs. A zero Width or
+// Padding falls back to the package default; a PointLimit of 0 shows all points.
+// A TopN above 0 graphs only that many of the worst endpoints and summarizes
+// the rest in a table. A ChangeThreshold above 0 leaves out endpoints that
+// didn't move by more than that percentage against the baseline.
+type GraphOptions struct {
+	Width           float64
+	PointLimit      int
+	Padding         float64
+	TopN            int
+	ChangeThreshold float64
+	Metadata        *ReportMetadata // Settings the run used, shown in the header if set
+}
+
+func (o GraphOptions) withDefaults() GraphOptions {
+	if o.Width <= 0 {
+		o.Width = fixedGraphWidth
+	}
+	if o.PointLimit < 0 {
+		o.PointLimit = defaultPointLimit
+	}
+	if o.Padding <= 0 {
+		o.Padding = xPadding
+	}
+	return o
+}
+
 const htmlTemplate = `
 <!DOCTYPE html>
 <html>
@@ -42,6 +73,19 @@ const htmlTemplate = `
         .axis { stroke: #333; }
         .label { font-size: 12px; fill: #333; }
         .grid { stroke: #eee; stroke-width: 1; }
+        .health-score {
+            margin: 20px;
+            font-size: 18px;
+        }
+        .health-score-label {
+            color: #666;
+            margin-right: 10px;
+        }
+        .health-score-value {
+            font-size: 28px;
+            font-weight: bold;
+            color: #333;
+        }
         .endpoint-selector {
             margin: 20px;
             padding: 10px;
@@ -156,6 +200,48 @@ const htmlTemplate = `
             width: 100%;
             height: 450px;
         }
+        .window-p50 { stroke: #4ecdc4; }
+        .window-p95 { stroke: #ff6b6b; }
+        .window-p99 { stroke: #8e44ad; }
+        .phase-bar {
+            width: 100%;
+            height: 60px;
+        }
+        .phase-dns { fill: #f7b731; color: #f7b731; }
+        .phase-connect { fill: #fa8231; color: #fa8231; }
+        .phase-tls { fill: #8e44ad; color: #8e44ad; }
+        .phase-server { fill: #4ecdc4; color: #4ecdc4; }
+        .phase-other { fill: #a5b1c2; color: #a5b1c2; }
+        .window-legend {
+            font-size: 14px;
+            margin-left: 20px;
+        }
+        .window-legend span {
+            margin-right: 15px;
+        }
+        .omitted-endpoints, .report-metadata {
+            margin: 20px;
+        }
+        .report-metadata {
+            font-size: 13px;
+            color: #666;
+        }
+        .report-metadata table {
+            border-collapse: collapse;
+            margin-top: 6px;
+        }
+        .report-metadata td {
+            padding: 2px 12px 2px 0;
+        }
+        .omitted-endpoints table {
+            border-collapse: collapse;
+            font-size: 14px;
+        }
+        .omitted-endpoints th, .omitted-endpoints td {
+            padding: 6px 12px;
+            border-bottom: 1px solid #eee;
+            text-align: left;
+        }
         .point-group, .label-group, .lines-container {
             transition: all 0.3s ease;
         }
@@ -169,6 +255,28 @@ const htmlTemplate = `
 </head>
 <body>
     <h1>Performance Test Results</h1>
+    {{with .Options.Metadata}}
+    <div class="report-metadata">
+        gopi {{.Version}} &middot; generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}} &middot; run took {{.Duration}}
+        <table>
+            {{range .Settings}}
+            <tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
+    {{if .HasHealthScore}}
+    <div class="health-score">
+        <span class="health-score-label">Health Score</span>
+        <span class="health-score-value">{{printf "%.1f" .HealthScore}}</span>
+        <span class="stat-unit">/ 100</span>
+    </div>
+    {{end}}
+    {{if .Unchanged}}
+    <div class="trend-info" style="margin: 20px;">
+        Showing only endpoints that changed by more than {{printf "%.0f%%" .Options.ChangeThreshold}}; {{.Unchanged}} stable endpoints hidden.
+    </div>
+    {{end}}
     <div class="endpoint-selector">
         <select id="endpointSelect" onchange="showEndpoint(this.value)">
             <option value="">Select an endpoint</option>
@@ -181,10 +289,9 @@ const htmlTemplate = `
     <div class="point-limit-selector">
         <label>Show points: </label>
         <select id="pointLimit" onchange="updatePointLimit(this.value)">
-            <option value="10">Last 10</option>
-            <option value="20" selected>Last 20</option>
-            <option value="30">Last 30</option>
-            <option value="0">All</option>
+            {{range .PointLimitOptions}}
+            <option value="{{.}}"{{if eq . $.Options.PointLimit}} selected{{end}}>{{if eq . 0}}All{{else}}Last {{.}}{{end}}</option>
+            {{end}}
         </select>
     </div>
 
@@ -195,8 +302,8 @@ const htmlTemplate = `
                 <div class="stat-box">
                     <div class="stat-label">Average Response Time</div>
                     <div class="stat-value">
-                        {{$value.Stats.AvgLatency}}
-                        <span class="change-indicator {{if isPositive $value.Stats.LatencyChange}}change-positive{{else}}change-negative{{end}}">
+                        <span data-stat="avg">{{$value.Stats.AvgLatency}}</span>
+                        <span class="change-indicator {{if isPositive $value.Stats.LatencyChange}}change-positive{{else}}change-negative{{end}}" data-change="avg">
                             {{$value.Stats.LatencyChange}}
                         </span>
                     </div>
@@ -205,8 +312,8 @@ const htmlTemplate = `
                 <div class="stat-box">
                     <div class="stat-label">Success Rate</div>
                     <div class="stat-value">
-                        {{$value.Stats.SuccessRate}}
-                        <span class="change-indicator {{if isPositive $value.Stats.SuccessRateChange}}change-positive{{else}}change-negative{{end}}">
+                        <span data-stat="success-rate">{{$value.Stats.SuccessRate}}</span>
+                        <span class="change-indicator {{if isPositive $value.Stats.SuccessRateChange}}change-positive{{else}}change-negative{{end}}" data-change="success-rate">
                             {{$value.Stats.SuccessRateChange}}
                         </span>
                     </div>
@@ -215,8 +322,8 @@ const htmlTemplate = `
                 <div class="stat-box">
                     <div class="stat-label">Requests/Second</div>
                     <div class="stat-value">
-                        {{$value.Stats.RPS}}
-                        <span class="change-indicator {{if isPositive $value.Stats.RPSChange}}change-positive{{else}}change-negative{{end}}">
+                        <span data-stat="rps">{{$value.Stats.RPS}}</span>
+                        <span class="change-indicator {{if isPositive $value.Stats.RPSChange}}change-positive{{else}}change-negative{{end}}" data-change="rps">
                             {{$value.Stats.RPSChange}}
                         </span>
                     </div>
@@ -224,7 +331,7 @@ const htmlTemplate = `
                 </div>
                 <div class="stat-box">
                     <div class="stat-label">Total Requests</div>
-                    <div class="stat-value">{{$value.Stats.TotalRequests}}</div>
+                    <div class="stat-value" data-stat="requests">{{$value.Stats.TotalRequests}}</div>
                     <div class="stat-unit">requests</div>
                 </div>
             </div>
@@ -232,8 +339,8 @@ const htmlTemplate = `
                 <div class="stat-box">
                     <div class="stat-label">Error Rate</div>
                     <div class="stat-value">
-                        {{$value.Stats.ErrorRate}}
-                        <span class="change-indicator {{if isPositive $value.Stats.ErrorRateChange}}change-positive{{else}}change-negative{{end}}">
+                        <span data-stat="error-rate">{{$value.Stats.ErrorRate}}</span>
+                        <span class="change-indicator {{if isPositive $value.Stats.ErrorRateChange}}change-positive{{else}}change-negative{{end}}" data-change="error-rate">
                             {{$value.Stats.ErrorRateChange}}
                         </span>
                     </div>
@@ -241,17 +348,17 @@ const htmlTemplate = `
                 </div>
                 <div class="stat-box">
                     <div class="stat-label">P50 Latency</div>
-                    <div class="stat-value">{{$value.Stats.P50Latency}}</div>
+                    <div class="stat-value" data-stat="p50">{{$value.Stats.P50Latency}}</div>
                     <div class="stat-unit">ms</div>
                 </div>
                 <div class="stat-box">
                     <div class="stat-label">P95 Latency</div>
-                    <div class="stat-value">{{$value.Stats.P95Latency}}</div>
+                    <div class="stat-value" data-stat="p95">{{$value.Stats.P95Latency}}</div>
                     <div class="stat-unit">ms</div>
                 </div>
                 <div class="stat-box">
                     <div class="stat-label">P99 Latency</div>
-                    <div class="stat-value">{{$value.Stats.P99Latency}}</div>
+                    <div class="stat-value" data-stat="p99">{{$value.Stats.P99Latency}}</div>
                     <div class="stat-unit">ms</div>
                 </div>
             </div>
@@ -266,8 +373,8 @@ const htmlTemplate = `
                 </span>
             </div>
             <div class="graph-container">
-                <svg viewBox="0 0 1200 450" preserveAspectRatio="xMidYMid meet" class="graph">
-                    <g transform="translate(50, 20)">
+                <svg viewBox="0 0 {{$.ViewWidth}} 450" preserveAspectRatio="xMidYMid meet" class="graph">
+                    <g transform="translate({{$.Options.Padding}}, 20)">
                         <!-- Y Axis -->
                         <line x1="0" y1="0" x2="0" y2="300" class="axis"/>
                         {{range $value.YAxisLabels}}
@@ -276,14 +383,16 @@ const htmlTemplate = `
 
                         <!-- Graph Content -->
                         <g id="graphContent">
-                            <line x1="0" y1="300" x2="1100" y2="300" class="axis"/>
+                            <line x1="0" y1="300" x2="{{$.AxisWidth}}" y2="300" class="axis"/>
                             
                             <g class="lines-container">
                                 <path d="{{$value.ConnectionPath}}" class="connection-line" />
                             </g>
                             
                             {{range $i, $p := $value.Points}}
-                            <g class="point-group" data-index="{{$i}}">
+                            <g class="point-group" data-index="{{$i}}" data-requests="{{$p.Stats.Requests}}"
+                               data-avg="{{$p.Stats.AvgMS}}" data-p50="{{$p.Stats.P50MS}}" data-p95="{{$p.Stats.P95MS}}"
+                               data-p99="{{$p.Stats.P99MS}}" data-rps="{{$p.Stats.RPS}}" data-error-rate="{{$p.Stats.ErrorRate}}">
                                 <circle cx="{{$p.X}}" cy="{{$p.Y}}" r="4" class="point latency"/>
                             </g>
                             {{end}}
@@ -296,7 +405,7 @@ const htmlTemplate = `
 
                             <line 
                                 x1="0" y1="{{$value.BaselineY}}" 
-                                x2="1100" y2="{{$value.CurrentY}}"
+                                x2="{{$.AxisWidth}}" y2="{{$value.CurrentY}}"
                                 class="trend-line {{if isPositive $value.TrendPercent}}trend-up{{else}}trend-down{{end}}"
                             />
                         </g>
@@ -304,19 +413,96 @@ const htmlTemplate = `
                 </svg>
             </div>
         </div>
+
+        {{with $value.LatencyOverTime}}
+        <div class="metric">
+            <h3>Latency Over This Run</h3>
+            <div class="graph-container">
+                <svg viewBox="0 0 {{$.ViewWidth}} 450" preserveAspectRatio="xMidYMid meet" class="graph">
+                    <g transform="translate({{$.Options.Padding}}, 20)">
+                        <line x1="0" y1="0" x2="0" y2="300" class="axis"/>
+                        <line x1="0" y1="300" x2="{{$.AxisWidth}}" y2="300" class="axis"/>
+                        {{range .YAxisLabels}}
+                        <text x="-40" y="{{.Y}}" class="label">{{printf "%.1f" .Value}} ms</text>
+                        {{end}}
+                        {{range .XAxisLabels}}
+                        <text x="{{.X}}" y="320" class="commit-label">{{.Label}}</text>
+                        {{end}}
+                        <path d="{{.P50Path}}" class="line window-p50"/>
+                        <path d="{{.P95Path}}" class="line window-p95"/>
+                        <path d="{{.P99Path}}" class="line window-p99"/>
+                    </g>
+                </svg>
+                <div class="window-legend">
+                    <span style="color: #4ecdc4;">&#9632; P50</span>
+                    <span style="color: #ff6b6b;">&#9632; P95</span>
+                    <span style="color: #8e44ad;">&#9632; P99</span>
+                    <span class="trend-label">per {{.Window}} window</span>
+                </div>
+            </div>
+        </div>
+        {{end}}
+
+        {{with $value.Phases}}
+        <div class="metric">
+            <h3>Where Time Went (average {{.Total}})</h3>
+            <div class="graph-container">
+                <svg viewBox="0 0 {{$.ViewWidth}} 60" preserveAspectRatio="xMidYMid meet" class="phase-bar">
+                    <g transform="translate({{$.Options.Padding}}, 10)">
+                        {{range .Segments}}
+                        <rect x="{{.X}}" y="0" width="{{.Width}}" height="30" class="phase-{{.Class}}">
+                            <title>{{.Name}}: {{.Duration}} ({{printf "%.1f%%" .Percent}})</title>
+                        </rect>
+                        {{end}}
+                    </g>
+                </svg>
+                <div class="window-legend">
+                    {{range .Segments}}
+                    <span class="phase-{{.Class}}">&#9632; {{.Name}} {{.Duration}} ({{printf "%.1f%%" .Percent}})</span>
+                    {{end}}
+                </div>
+            </div>
+        </div>
+        {{end}}
+    </div>
+    {{end}}
+
+    {{if .Omitted}}
+    <div class="omitted-endpoints">
+        <h3>Other Endpoints ({{len .Omitted}} not graphed)</h3>
+        <table>
+            <tr><th>Endpoint</th><th>Avg Latency (ms)</th><th>P95 Latency (ms)</th><th>Trend</th></tr>
+            {{range .Omitted}}
+            <tr>
+                <td>{{.Endpoint}}</td>
+                <td>{{printf "%.2f" .AvgLatencyMS}}</td>
+                <td>{{printf "%.2f" .P95LatencyMS}}</td>
+                <td class="{{if isPositive .TrendPercent}}trend-up{{else}}trend-down{{end}}">{{printf "%.2f%%" .TrendPercent}}</td>
+            </tr>
+            {{end}}
+        </table>
     </div>
     {{end}}
 
     <script>
+        const graphOptions = { width: {{.Options.Width}}, padding: {{.Options.Padding}}, pointLimit: {{.Options.PointLimit}} };
         {{.JavaScript}}
     </script>
 </body>
 </html>`
 
 type GraphData struct {
-	Trends      map[string]TrendGraph
-	TotalPoints int
-	JavaScript  template.JS
+	Trends            map[string]TrendGraph
+	Omitted           []OmittedEndpoint
+	Unchanged         int
+	TotalPoints       int
+	HealthScore       float64
+	HasHealthScore    bool // Whether a run has been scored, as a score of 0 is a valid score
+	JavaScript        template.JS
+	Options           GraphOptions
+	PointLimitOptions []int
+	ViewWidth         float64
+	AxisWidth         float64
 }
 
 type TrendGraph struct {
@@ -336,6 +522,44 @@ type TrendGraph struct {
 	ConnectionPath string
 	TotalPoints    int
 	VisiblePoints  int
+
+	LatencyOverTime *WindowGraph
+	Phases          *PhaseBar
+}
+
+// PhaseBar is a stacked bar of where the average latency of an endpoint went
+// in the current run.
+type PhaseBar struct {
+	Total    time.Duration
+	Segments []PhaseSegment
+}
+
+// PhaseSegment is one phase of a PhaseBar, positioned along its width.
+type PhaseSegment struct {
+	Name     string
+	Class    string
+	X        float64
+	Width    float64
+	Duration time.Duration
+	Percent  float64
+}
+
+// WindowGraph plots latency percentiles over the course of the current run.
+type WindowGraph struct {
+	P50Path     string
+	P95Path     string
+	P99Path     string
+	YAxisLabels []AxisLabel
+	XAxisLabels []AxisLabel
+	Window      time.Duration
+}
+
+// OmittedEndpoint summarizes an endpoint left out of the graphs by TopN.
+type OmittedEndpoint struct {
+	Endpoint     string
+	AvgLatencyMS float64
+	P95LatencyMS float64
+	TrendPercent float64
 }
 
 type AxisLabel struct {
@@ -351,76 +575,174 @@ type Point struct {
 	Y     float64
 	Value float64
 	Label string
+	Stats PointStats
+}
+
+// PointStats are the figures of the run a point stands for. They are embedded
+// in the page so graph.js can recompute the stats panel for the points shown.
+type PointStats struct {
+	Requests  int
+	AvgMS     float64
+	P50MS     float64
+	P95MS     float64
+	P99MS     float64
+	RPS       float64
+	ErrorRate float64
 }
 
-func GenerateGraph(summary *hist.Summary, outputDir string) (string, error) {
+// GenerateGraph writes the HTML report for summary to a new timestamped file
+// in outputDir and returns its path. current is the run the report is for and
+// may be nil; it's only needed to filter by opts.ChangeThreshold.
+func GenerateGraph(summary *hist.Summary, current *hist.TestHistory, outputDir string, opts GraphOptions) (string, error) {
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return "", err
 	}
 
+	outputFile := filepath.Join(outputDir, fmt.Sprintf("performance_%s.html",
+		time.Now().Format("20060102_150405")))
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := WriteGraph(f, summary, current, opts); err != nil {
+		return "", err
+	}
+
+	return outputFile, nil
+}
+
+// WriteGraph renders the HTML report for summary to w.
+func WriteGraph(w io.Writer, summary *hist.Summary, current *hist.TestHistory, opts GraphOptions) error {
+	opts = opts.withDefaults()
 	data := &GraphData{
-		Trends:     make(map[string]TrendGraph),
-		JavaScript: template.JS(graphJS),
+		Trends:            make(map[string]TrendGraph),
+		HealthScore:       summary.HealthScore,
+		HasHealthScore:    summary.RunCount > 0,
+		JavaScript:        template.JS(graphJS),
+		Options:           opts,
+		PointLimitOptions: pointLimitOptions(opts.PointLimit),
+		ViewWidth:         opts.Width + 4*opts.Padding,
+		AxisWidth:         opts.Width + 2*opts.Padding,
 	}
 
+	changed := changedEndpoints(current, opts.ChangeThreshold)
+
 	maxPoints := 0
 	for endpoint, trend := range summary.Trends {
+		if changed != nil && !changed[endpoint] {
+			data.Unchanged++
+			continue
+		}
+
 		logger.Info("Processing endpoint %s with trend data: ms=%.2f, reqs=%d\n",
 			endpoint, trend.AvgLatencyMS, trend.TotalRequests)
 
 		history := summary.EndpointHistory[endpoint]
-		data.Trends[endpoint] = generateEndpointGraph(trend, history)
+		graph := generateEndpointGraph(trend, history, opts)
+		if current != nil && current.Statistics != nil {
+			if es, exists := current.Statistics.EndpointStats[endpoint]; exists {
+				graph.LatencyOverTime = generateWindowGraph(es.Windows, opts)
+				graph.Phases = generatePhaseBar(es.Phases, opts)
+			}
+		}
+		data.Trends[endpoint] = graph
 		if len(history) > maxPoints {
 			maxPoints = len(history)
 		}
 	}
 	data.TotalPoints = maxPoints
+	data.Omitted = limitToWorst(data.Trends, summary.Trends, opts.TopN)
 
+	tmpl, err := parseGraphTemplate()
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, data)
+}
+
+// parseGraphTemplate parses the HTML report template.
+func parseGraphTemplate() (*template.Template, error) {
 	funcMap := template.FuncMap{
-		"toFloat64": func(v interface{}) float64 {
-			switch value := v.(type) {
-			case int:
-				return float64(value)
-			case int64:
-				return float64(value)
-			case float32:
-				return float64(value)
-			case float64:
-				return value
-			case string:
-				f, _ := strconv.ParseFloat(value, 64)
-				return f
-			default:
-				return 0
-			}
-		},
+		"toFloat64": toFloat64,
 		"isPositive": func(v interface{}) bool {
-			f := toFloat64(v)
-			return f > 0
+			return toFloat64(v) > 0
 		},
 	}
+	return template.New("graph").Funcs(funcMap).Parse(htmlTemplate)
+}
 
-	tmpl, err := template.New("graph").Funcs(funcMap).Parse(strings.Replace(htmlTemplate,
-		`{{if gt (toFloat64 $data.TrendPercent) 0}}`,
-		`{{if isPositive $data.TrendPercent}}`,
-		-1))
-	if err != nil {
-		return "", err
+// pointLimitOptions returns the choices offered in the point limit selector,
+// making sure the configured default is one of them.
+func pointLimitOptions(limit int) []int {
+	options := []int{10, 20, 30}
+	if limit > 0 && !slices.Contains(options, limit) {
+		options = append(options, limit)
+		slices.Sort(options)
 	}
+	return append(options, 0)
+}
 
-	outputFile := filepath.Join(outputDir, fmt.Sprintf("performance_%s.html",
-		time.Now().Format("20060102_150405")))
-	f, err := os.Create(outputFile)
-	if err != nil {
-		return "", err
+// changedEndpoints returns the endpoints of current whose latency, error rate,
+// throughput or success rate moved by more than threshold percent in either
+// direction against the baseline. It returns nil, meaning no filtering, when
+// the threshold is off or there is no baseline to compare against.
+func changedEndpoints(current *hist.TestHistory, threshold float64) map[string]bool {
+	if threshold <= 0 {
+		return nil
+	}
+	if current == nil || current.BaselineID == "" {
+		logger.Warn("No baseline to compare against, showing all endpoints in the report")
+		return nil
 	}
-	defer f.Close()
 
-	if err := tmpl.Execute(f, data); err != nil {
-		return "", err
+	changed := make(map[string]bool)
+	for endpoint, comparison := range current.Endpoints {
+		c := comparison.Changes
+		for _, pct := range []float64{c.LatencyIncrease, c.ErrorRateIncrease, c.ThroughputDecrease, c.SuccessRateDecrease} {
+			if math.Abs(pct) > threshold {
+				changed[endpoint] = true
+				break
+			}
+		}
 	}
+	return changed
+}
 
-	return outputFile, nil
+// limitToWorst keeps the topN endpoints with the biggest regression in graphs,
+// using average latency to break ties, and returns the rest in ranked order.
+func limitToWorst(graphs map[string]TrendGraph, trends map[string]hist.TrendReport, topN int) []OmittedEndpoint {
+	if topN <= 0 || len(graphs) <= topN {
+		return nil
+	}
+
+	ranked := make([]string, 0, len(graphs))
+	for endpoint := range graphs {
+		ranked = append(ranked, endpoint)
+	}
+	slices.SortFunc(ranked, func(a, b string) int {
+		if c := cmp.Compare(graphs[b].TrendPercent, graphs[a].TrendPercent); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(trends[b].AvgLatencyMS, trends[a].AvgLatencyMS); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	omitted := make([]OmittedEndpoint, 0, len(ranked)-topN)
+	for _, endpoint := range ranked[topN:] {
+		omitted = append(omitted, OmittedEndpoint{
+			Endpoint:     endpoint,
+			AvgLatencyMS: trends[endpoint].AvgLatencyMS,
+			P95LatencyMS: trends[endpoint].P95LatencyMS,
+			TrendPercent: graphs[endpoint].TrendPercent,
+		})
+		delete(graphs, endpoint)
+	}
+	return omitted
 }
 
 func percentageChange(current, previous float64) float64 {
@@ -430,12 +752,12 @@ func percentageChange(current, previous float64) float64 {
 	return ((current - previous) / previous) * 100
 }
 
-func generateEndpointGraph(t hist.TrendReport, history []hist.TrendReport) TrendGraph {
+func generateEndpointGraph(t hist.TrendReport, history []hist.TrendReport, opts GraphOptions) TrendGraph {
 	graph := TrendGraph{}
 
 	points := make([]hist.TrendReport, 0, len(history)+1)
 	points = append(points, history...)
-	if len(points) == 0 || points[len(points)-1].CommitHash != t.CommitHash {
+	if len(points) == 0 || points[len(points)-1].CommitHash != t.CommitHash || points[len(points)-1].Dirty != t.Dirty {
 		points = append(points, t)
 	}
 
@@ -458,28 +780,37 @@ func generateEndpointGraph(t hist.TrendReport, history []hist.TrendReport) Trend
 		})
 	}
 
-	spacing := fixedGraphWidth
+	spacing := opts.Width
 	if len(points) > 1 {
-		spacing = fixedGraphWidth / float64(len(points)-1)
+		spacing = opts.Width / float64(len(points)-1)
 	}
 
 	for i, h := range points {
-		x := xPadding + (float64(i) * spacing)
+		x := opts.Padding + (float64(i) * spacing)
 		y := scaleValue(h.AvgLatencyMS, 0, maxMs, 300, 0)
 
 		logger.Debug("Point %d: hash=%s, x=%.1f, y=%.1f, ms=%.2f\n",
-			i, h.CommitHash[:8], x, y, h.AvgLatencyMS)
+			i, util.ShortHash(h.CommitHash, 8), x, y, h.AvgLatencyMS)
 
 		graph.Points = append(graph.Points, Point{
 			X:     x,
 			Y:     y,
 			Value: h.AvgLatencyMS,
+			Stats: PointStats{
+				Requests:  h.TotalRequests,
+				AvgMS:     h.AvgLatencyMS,
+				P50MS:     h.P50LatencyMS,
+				P95MS:     h.P95LatencyMS,
+				P99MS:     h.P99LatencyMS,
+				RPS:       h.RPS,
+				ErrorRate: h.ErrorRateTrend,
+			},
 		})
 
 		graph.XAxisLabels = append(graph.XAxisLabels, AxisLabel{
 			X:     x,
-			Label: h.CommitHash[:7],
-			Title: fmt.Sprintf("%s\n%s", h.CommitHash, h.CommitTime.Format("2006-01-02 15:04:05")),
+			Label: commitLabel(h),
+			Title: fmt.Sprintf("%s\n%s", commitTitle(h), h.CommitTime.Format("2006-01-02 15:04:05")),
 		})
 	}
 
@@ -526,7 +857,7 @@ func generateEndpointGraph(t hist.TrendReport, history []hist.TrendReport) Trend
 	if len(points) > 1 {
 		firstPoint := points[0]
 		lastPoint := points[len(points)-1]
-		graph.BaselineHash = firstPoint.CommitHash[:7]
+		graph.BaselineHash = commitLabel(firstPoint)
 		graph.TrendPercent = percentageChange(lastPoint.IterationMS, firstPoint.IterationMS)
 		graph.BaselineY = scaleValue(firstPoint.IterationMS, 0, maxMs, 300, 0)
 		graph.CurrentY = scaleValue(lastPoint.IterationMS, 0, maxMs, 300, 0)
@@ -540,12 +871,130 @@ func generateEndpointGraph(t hist.TrendReport, history []hist.TrendReport) Trend
 			TotalRequests: fmt.Sprintf("%d", t.TotalRequests),
 			ErrorRate:     fmt.Sprintf("%.2f", t.ErrorRateTrend),
 		}
-		graph.BaselineHash = t.CommitHash[:7]
+		graph.BaselineHash = commitLabel(t)
 	}
 
 	return graph
 }
 
+// commitLabel returns the short hash a trend point is labeled with, marked
+// when it was recorded with uncommitted changes.
+func commitLabel(t hist.TrendReport) string {
+	if t.Dirty {
+		return util.ShortHash(t.CommitHash, 7) + "-dirty"
+	}
+	return util.ShortHash(t.CommitHash, 7)
+}
+
+// commitTitle is commitLabel with the full hash.
+func commitTitle(t hist.TrendReport) string {
+	if t.Dirty {
+		return t.CommitHash + " (uncommitted changes)"
+	}
+	return t.CommitHash
+}
+
+// maxWindowLabels bounds how many time labels the latency-over-time graph
+// shows, so long runs with many windows stay readable.
+const maxWindowLabels = 10
+
+// generateWindowGraph plots the windowed percentiles of the current run. It
+// returns nil when there are too few windows to show a trend.
+func generateWindowGraph(windows []stats.LatencyWindow, opts GraphOptions) *WindowGraph {
+	if len(windows) < 2 {
+		return nil
+	}
+
+	graph := &WindowGraph{Window: windows[1].Offset - windows[0].Offset}
+	for i := 2; i < len(windows); i++ {
+		graph.Window = min(graph.Window, windows[i].Offset-windows[i-1].Offset)
+	}
+
+	var maxMs float64
+	for _, w := range windows {
+		maxMs = math.Max(maxMs, durationMs(w.P99Latency))
+	}
+	maxMs = math.Max(maxMs*1.2, 1)
+
+	for i := 0; i <= 5; i++ {
+		value := float64(i) * maxMs / 5.0
+		graph.YAxisLabels = append(graph.YAxisLabels, AxisLabel{
+			Y:     scaleValue(value, 0, maxMs, 300, 0),
+			Value: value,
+		})
+	}
+
+	last := windows[len(windows)-1].Offset
+	x := func(offset time.Duration) float64 {
+		return opts.Padding + opts.Width*float64(offset)/float64(last)
+	}
+
+	labelStep := (len(windows) + maxWindowLabels - 1) / maxWindowLabels
+	var p50, p95, p99 strings.Builder
+	for i, w := range windows {
+		command := " L"
+		if i == 0 {
+			command = "M"
+		}
+		fmt.Fprintf(&p50, "%s %f %f", command, x(w.Offset), scaleValue(durationMs(w.P50Latency), 0, maxMs, 300, 0))
+		fmt.Fprintf(&p95, "%s %f %f", command, x(w.Offset), scaleValue(durationMs(w.P95Latency), 0, maxMs, 300, 0))
+		fmt.Fprintf(&p99, "%s %f %f", command, x(w.Offset), scaleValue(durationMs(w.P99Latency), 0, maxMs, 300, 0))
+
+		if i%labelStep == 0 {
+			graph.XAxisLabels = append(graph.XAxisLabels, AxisLabel{
+				X:     x(w.Offset),
+				Label: w.Offset.String(),
+			})
+		}
+	}
+	graph.P50Path = p50.String()
+	graph.P95Path = p95.String()
+	graph.P99Path = p99.String()
+
+	return graph
+}
+
+// generatePhaseBar lays out the phases of phases side by side in proportion to
+// their share of the total latency. It returns nil if nothing was recorded.
+func generatePhaseBar(phases stats.PhaseBreakdown, opts GraphOptions) *PhaseBar {
+	total := phases.Total()
+	if total <= 0 {
+		return nil
+	}
+
+	bar := &PhaseBar{Total: total.Round(10 * time.Microsecond)}
+	var x float64
+	for _, phase := range []struct {
+		name, class string
+		duration    time.Duration
+	}{
+		{"DNS", "dns", phases.DNS},
+		{"Connect", "connect", phases.Connect},
+		{"TLS", "tls", phases.TLS},
+		{"Server", "server", phases.Server},
+		{"Transfer & other", "other", phases.Other},
+	} {
+		if phase.duration <= 0 {
+			continue
+		}
+		share := float64(phase.duration) / float64(total)
+		bar.Segments = append(bar.Segments, PhaseSegment{
+			Name:     phase.name,
+			Class:    phase.class,
+			X:        x,
+			Width:    share * opts.Width,
+			Duration: phase.duration.Round(10 * time.Microsecond),
+			Percent:  share * 100,
+		})
+		x += share * opts.Width
+	}
+	return bar
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
 func scaleValue(value, minInput, maxInput, minOutput, maxOutput float64) float64 {
 	return (value-minInput)*(maxOutput-minOutput)/(maxInput-minInput) + minOutput
 }
@@ -0,0 +1,85 @@
+package viz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	hist "percipio.com/gopi/lib/history"
+)
+
+// minimalSummary returns a summary with a single run of one endpoint,
+// recorded against commitHash.
+func minimalSummary(commitHash string) *hist.Summary {
+	trend := hist.TrendReport{
+		CommitHash:     commitHash,
+		CommitTime:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		TotalRequests:  100,
+		AvgLatencyMS:   12,
+		P50LatencyMS:   10,
+		P95LatencyMS:   30,
+		P99LatencyMS:   45,
+		RPS:            50,
+		ErrorRateTrend: 1,
+	}
+	const key = "GET https://api.example.com/users"
+	return &hist.Summary{
+		RunCount:        1,
+		HealthScore:     91.5,
+		Trends:          map[string]hist.TrendReport{key: trend},
+		EndpointHistory: map[string][]hist.TrendReport{key: {trend}},
+	}
+}
+
+func TestWriteGraph(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGraph(&buf, minimalSummary("0123456789abcdef0123456789abcdef01234567"), nil, GraphOptions{}); err != nil {
+		t.Fatalf("WriteGraph: %v", err)
+	}
+
+	report := buf.String()
+	for _, marker := range []string{"<html", "</html>", "GET https://api.example.com/users", "0123456", "91.5"} {
+		if !strings.Contains(report, marker) {
+			t.Errorf("report is missing %q", marker)
+		}
+	}
+}
+
+func TestWriteGraphZeroHealthScore(t *testing.T) {
+	summary := minimalSummary("0123456789abcdef0123456789abcdef01234567")
+	summary.HealthScore = 0
+
+	var buf bytes.Buffer
+	if err := WriteGraph(&buf, summary, nil, GraphOptions{}); err != nil {
+		t.Fatalf("WriteGraph: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<span class="health-score-value">0.0</span>`) {
+		t.Error("report is missing the health score of 0")
+	}
+}
+
+// TestWriteGraphShortCommitHash renders history whose commit hashes are
+// shorter than the graph labels, which used to panic.
+func TestWriteGraphShortCommitHash(t *testing.T) {
+	for _, dirty := range []bool{false, true} {
+		summary := minimalSummary("ab12c")
+		for key, trend := range summary.Trends {
+			trend.Dirty = dirty
+			summary.Trends[key] = trend
+			summary.EndpointHistory[key] = []hist.TrendReport{trend, trend}
+		}
+
+		var buf bytes.Buffer
+		if err := WriteGraph(&buf, summary, nil, GraphOptions{}); err != nil {
+			t.Fatalf("WriteGraph with dirty %v: %v", dirty, err)
+		}
+		want := "ab12c"
+		if dirty {
+			want = "ab12c-dirty"
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report with dirty %v is missing the label %q", dirty, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package viz
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	hist "percipio.com/gopi/lib/history"
+)
+
+// GenerateJSONReport writes summary as a JSON report to a new timestamped file
+// in outputDir and returns its path.
+func GenerateJSONReport(summary *hist.Summary, metadata *ReportMetadata, outputDir string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	outputFile := filepath.Join(outputDir, fmt.Sprintf("performance_%s.json",
+		time.Now().Format("20060102_150405")))
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := WriteJSONReport(f, summary, metadata); err != nil {
+		return "", err
+	}
+
+	return outputFile, nil
+}
+
+// jsonReport adds the run's metadata to the summary fields.
+type jsonReport struct {
+	*hist.Summary
+	Metadata *ReportMetadata `json:"metadata,omitempty"`
+}
+
+// WriteJSONReport writes summary to w as indented JSON, along with metadata
+// if it is set.
+func WriteJSONReport(w io.Writer, summary *hist.Summary, metadata *ReportMetadata) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonReport{Summary: summary, Metadata: metadata})
+}
@@ -0,0 +1,103 @@
+package viz
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	hist "percipio.com/gopi/lib/history"
+	"percipio.com/gopi/lib/stats"
+)
+
+// WriteMarkdown writes the per-endpoint statistics of a run as a
+// GitHub-flavored Markdown table, suitable for posting as a PR comment.
+// When current was compared against a baseline, each endpoint is marked as
+// passing or regressed and its changes are included. current may be nil.
+func WriteMarkdown(w io.Writer, statistics *stats.Statistics, current *hist.TestHistory) error {
+	var sb strings.Builder
+	sb.WriteString("## Performance Results\n\n")
+
+	compared := current != nil && current.BaselineID != ""
+	if current != nil {
+		commit := current.GitInfo.ShortHash
+		if commit == "" {
+			commit = current.RunID
+		}
+		fmt.Fprintf(&sb, "Run `%s`", commit)
+		if compared {
+			fmt.Fprintf(&sb, " compared against baseline `%s` (threshold %.0f%%)", current.BaselineID, current.ThresholdPct)
+		}
+		sb.WriteString(".\n\n")
+	}
+
+	sb.WriteString("| | Endpoint | Requests | Success | P50 | P95 | P99 | RPS |")
+	if compared {
+		fmt.Fprintf(&sb, " Latency Δ (%s) | Throughput Δ | Success Δ |", current.LatencyMetric)
+	}
+	sb.WriteString("\n|---|---|--:|--:|--:|--:|--:|--:|")
+	if compared {
+		sb.WriteString("--:|--:|--:|")
+	}
+	sb.WriteString("\n")
+
+	keys := make([]string, 0, len(statistics.EndpointStats))
+	for key := range statistics.EndpointStats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	regressions := 0
+	for _, key := range keys {
+		es := statistics.EndpointStats[key]
+
+		var comparison *hist.Comparison
+		if compared {
+			comparison = current.Endpoints[key]
+		}
+		status := "✅"
+		if comparison != nil && comparison.Degradation {
+			status = "⚠️"
+			regressions++
+		}
+
+		success := 0.0
+		if es.TotalRequests > 0 {
+			success = float64(es.SuccessRequests) / float64(es.TotalRequests) * 100
+		}
+		fmt.Fprintf(&sb, "| %s | `%s` | %d | %.1f%% | %.2fms | %.2fms | %.2fms | %.2f |",
+			status, key, es.TotalRequests, success,
+			durationMs(es.P50Latency), durationMs(es.P95Latency), durationMs(es.P99Latency),
+			es.RequestsPerSecond)
+
+		if compared {
+			if comparison == nil {
+				sb.WriteString(" new | new | new |")
+			} else {
+				// Changes are recorded as increases/decreases; flip the
+				// decreases so every column reads as new vs baseline
+				fmt.Fprintf(&sb, " %s | %s | %s |",
+					signedPct(comparison.Changes.LatencyIncrease),
+					signedPct(-comparison.Changes.ThroughputDecrease),
+					signedPct(-comparison.Changes.SuccessRateDecrease))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if compared {
+		sb.WriteString("\n")
+		if regressions == 0 {
+			sb.WriteString("✅ No regressions against the baseline.\n")
+		} else {
+			fmt.Fprintf(&sb, "⚠️ **%d of %d endpoints regressed** against the baseline.\n", regressions, len(keys))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func signedPct(pct float64) string {
+	return fmt.Sprintf("%+.1f%%", pct)
+}
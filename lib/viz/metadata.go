@@ -0,0 +1,18 @@
+package viz
+
+import "time"
+
+// ReportMetadata records how the numbers in a report were produced, so
+// reports run with different settings aren't compared unknowingly.
+type ReportMetadata struct {
+	Version     string        `json:"version"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Duration    time.Duration `json:"duration"` // Wall-clock time of the run
+	Settings    []Setting     `json:"settings"`
+}
+
+// Setting is one effective configuration value of a run.
+type Setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,46 @@
+package viz
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// reportFilePattern matches the report names written by GenerateGraph, so
+// pruning never touches anything else in the output directory.
+var reportFilePattern = regexp.MustCompile(`^performance_\d{8}_\d{6}\.(html|json)$`)
+
+// PruneReports deletes all but the keep most recent reports in outputDir and
+// returns the paths it removed.
+func PruneReports(outputDir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []string
+	for _, entry := range entries {
+		if !entry.IsDir() && reportFilePattern.MatchString(entry.Name()) {
+			reports = append(reports, entry.Name())
+		}
+	}
+
+	if len(reports) <= keep {
+		return nil, nil
+	}
+
+	// Report names embed their timestamp, so lexical order is chronological
+	sort.Strings(reports)
+
+	var removed []string
+	for _, name := range reports[:len(reports)-keep] {
+		path := filepath.Join(outputDir, name)
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
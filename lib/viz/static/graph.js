@@ -9,11 +9,13 @@ function showEndpoint(endpoint) {
 
   if (endpoint) {
     document.getElementById(endpoint).classList.add("active");
+    updatePointLimit(document.getElementById("pointLimit").value);
   }
 }
 
 /**
- * Updates the graph to show only the specified number of latest points
+ * Updates the graph and its stats panel to show only the specified number of
+ * latest points
  * @param {string|number} limit - Number of points to show, or "0" for all points
  */
 function updatePointLimit(limit) {
@@ -27,10 +29,9 @@ function updatePointLimit(limit) {
   const startIndex = limitNum === 0 ? 0 : Math.max(0, totalPoints - limitNum);
   const visiblePoints = allPoints.slice(startIndex);
 
-  const graphWidth = 1000;
-  const spacing = graphWidth / Math.max(1, visiblePoints.length - 1);
+  const spacing = graphOptions.width / Math.max(1, visiblePoints.length - 1);
 
-  updatePositions(visiblePoints, 50, spacing);
+  updatePositions(activeGraph, visiblePoints, graphOptions.padding, spacing);
 
   allPoints.slice(0, startIndex).forEach((point) => {
     point.style.display = "none";
@@ -43,16 +44,84 @@ function updatePointLimit(limit) {
   const path = activeGraph.querySelector(".connection-line");
   if (visiblePoints.length > 0) {
     path.setAttribute("d", generatePathData(visiblePoints));
+    updateStats(activeGraph, visiblePoints);
   }
 }
 
+/**
+ * Recomputes the stats panel from the runs of the visible points. Latencies
+ * and error rates are averaged weighted by each run's requests; percentiles of
+ * separate runs can't be merged exactly, so they are approximated the same
+ * way. Changes compare the newest visible run against the oldest.
+ * @param {Element} activeGraph - The endpoint graph containing the panel
+ * @param {Array<Element>} points - Array of visible point elements
+ */
+const updateStats = (activeGraph, points) => {
+  const runs = points.map((p) => ({
+    requests: Number(p.dataset.requests),
+    avg: Number(p.dataset.avg),
+    p50: Number(p.dataset.p50),
+    p95: Number(p.dataset.p95),
+    p99: Number(p.dataset.p99),
+    rps: Number(p.dataset.rps),
+    errorRate: Number(p.dataset.errorRate),
+  }));
+  const totalRequests = runs.reduce((sum, run) => sum + run.requests, 0);
+  const weighted = (key) =>
+    totalRequests === 0
+      ? 0
+      : runs.reduce((sum, run) => sum + run[key] * run.requests, 0) /
+        totalRequests;
+  const errorRate = weighted("errorRate");
+
+  const values = {
+    avg: weighted("avg").toFixed(2),
+    "success-rate": (100 - errorRate).toFixed(2),
+    rps: (runs.reduce((sum, run) => sum + run.rps, 0) / runs.length).toFixed(2),
+    requests: String(totalRequests),
+    "error-rate": errorRate.toFixed(2),
+    p50: weighted("p50").toFixed(2),
+    p95: weighted("p95").toFixed(2),
+    p99: weighted("p99").toFixed(2),
+  };
+  Object.entries(values).forEach(([stat, value]) => {
+    const el = activeGraph.querySelector(`[data-stat="${stat}"]`);
+    if (el) el.textContent = value;
+  });
+
+  const first = runs[0];
+  const last = runs[runs.length - 1];
+  const changes = {
+    avg: last.avg - first.avg,
+    "success-rate": first.errorRate - last.errorRate,
+    rps: last.rps - first.rps,
+    "error-rate": last.errorRate - first.errorRate,
+  };
+  Object.entries(changes).forEach(([stat, change]) => {
+    const el = activeGraph.querySelector(`[data-change="${stat}"]`);
+    if (!el) return;
+    el.textContent = runs.length > 1 ? formatChange(change) : "";
+    el.classList.toggle("change-positive", change > 0);
+    el.classList.toggle("change-negative", change <= 0);
+  });
+};
+
+/**
+ * Formats a change like the report generator, with a sign when positive
+ * @param {number} value - The change to format
+ * @returns {string} The formatted change
+ */
+const formatChange = (value) =>
+  value > 0 ? "+" + value.toFixed(2) : value.toFixed(2);
+
 /**
  * Updates the position and visibility of graph elements
+ * @param {Element} activeGraph - The endpoint graph containing the points
  * @param {Array<Element>} points - Array of point elements to reposition
  * @param {number} startX - Starting X coordinate
  * @param {number} spacing - Space between points
  */
-const updatePositions = (points, startX, spacing) => {
+const updatePositions = (activeGraph, points, startX, spacing) => {
   points.forEach((point, i) => {
     const x = startX + i * spacing;
     point.style.display = "";
@@ -91,8 +160,8 @@ const generatePathData = (points) => {
 
 /**
  * Initializes the graph display when the page loads
- * - Selects the first endpoint by default
- * - Sets default point limit to 20
+ * - Selects the first endpoint by default, which applies the configured
+ *   default point limit
  */
 window.onload = function () {
   const select = document.getElementById("endpointSelect");
@@ -100,5 +169,4 @@ window.onload = function () {
     select.selectedIndex = 1;
     showEndpoint(select.value);
   }
-  updatePointLimit(20);
 };
@@ -0,0 +1,108 @@
+package viz
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	hist "percipio.com/gopi/lib/history"
+	"percipio.com/gopi/lib/runner"
+	"percipio.com/gopi/lib/stats"
+)
+
+// ValidateTemplate parses the HTML report template and renders it against
+// synthetic data covering its optional sections (baseline comparison, latency
+// over time, phase breakdown, omitted endpoints and run metadata), so template
+// errors show up without having to complete a real run first.
+func ValidateTemplate() error {
+	if _, err := parseGraphTemplate(); err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	summary, current := syntheticReport()
+	opts := GraphOptions{
+		TopN: 1,
+		Metadata: &ReportMetadata{
+			Version:     "validate",
+			GeneratedAt: time.Now(),
+			Duration:    time.Second,
+			Settings:    []Setting{{Name: "Mode", Value: "template validation"}},
+		},
+	}
+	if err := WriteGraph(io.Discard, summary, current, opts); err != nil {
+		return fmt.Errorf("failed to render report template: %w", err)
+	}
+	return nil
+}
+
+// syntheticReport builds a summary with a few runs of history for two
+// endpoints, and a current run with latency windows compared against the
+// previous one.
+func syntheticReport() (*hist.Summary, *hist.TestHistory) {
+	start := time.Now().Add(-time.Hour)
+	endpoints := []string{"http://example.com/fast", "http://example.com/slow"}
+
+	summary := &hist.Summary{
+		LastRun:         start,
+		RunCount:        3,
+		HealthScore:     87.5,
+		Trends:          make(map[string]hist.TrendReport),
+		EndpointHistory: make(map[string][]hist.TrendReport),
+	}
+	var results []runner.Result
+	for i, endpoint := range endpoints {
+		key := "GET " + endpoint
+		for run := 0; run < 3; run++ {
+			latency := float64(10*(i+1) + 5*run)
+			trend := hist.TrendReport{
+				CommitHash:     fmt.Sprintf("%040x", run+1),
+				CommitTime:     start.Add(time.Duration(run) * time.Minute),
+				TotalRequests:  100,
+				AvgLatencyMS:   latency,
+				P50LatencyMS:   latency,
+				P95LatencyMS:   latency * 2,
+				P99LatencyMS:   latency * 3,
+				RPS:            50,
+				ErrorRateTrend: float64(run),
+			}
+			summary.EndpointHistory[key] = append(summary.EndpointHistory[key], trend)
+			summary.Trends[key] = trend
+		}
+
+		for n := 0; n < 20; n++ {
+			requestStart := start.Add(time.Duration(n) * 500 * time.Millisecond)
+			duration := time.Duration(10*(i+1)+n) * time.Millisecond
+			results = append(results, runner.Result{
+				URL:        endpoint,
+				Method:     "GET",
+				StatusCode: 200,
+				Duration:   duration,
+				StartTime:  requestStart,
+				EndTime:    requestStart.Add(duration),
+				Phases: runner.Phases{
+					Connect: duration / 10,
+					Server:  duration / 2,
+				},
+			})
+		}
+	}
+
+	statistics := stats.Calculate(results)
+	statistics.AddLatencyWindows(results, 2*time.Second)
+
+	current := &hist.TestHistory{
+		RunID:      "validate",
+		Timestamp:  start,
+		Statistics: statistics,
+		Endpoints:  make(map[string]*hist.Comparison),
+		BaselineID: "baseline",
+	}
+	for key, es := range statistics.EndpointStats {
+		current.Endpoints[key] = &hist.Comparison{
+			Current:  es,
+			Previous: es,
+			Changes:  hist.DegradationReport{LatencyIncrease: 20},
+		}
+	}
+	return summary, current
+}